@@ -2,9 +2,10 @@ package aqlgraph
 
 import (
 	"bytes"
+	"fmt"
 	"strconv"
-	"strings"
 
+	"github.com/flowchartsman/aql/jsonmatcher"
 	"github.com/flowchartsman/aql/parser"
 	"github.com/flowchartsman/aql/parser/ast"
 	"github.com/goccy/go-graphviz"
@@ -56,7 +57,17 @@ func (n *namer) next() string {
 	return name
 }
 
+// QueryGraph creates a graph of the structure of a query.
 func QueryGraph(query string) (*Graph, error) {
+	return QueryStatsGraph(query, nil)
+}
+
+// QueryStatsGraph creates a graph of the structure of a query, annotated with
+// the match statistics collected by a [jsonmatcher.Matcher] for that query.
+// Each node is colored by its selectivity, from red for nodes that rarely
+// match, to green for nodes that usually do. Nodes that have never been
+// checked are gray.
+func QueryStatsGraph(query string, stats *jsonmatcher.MatchStats) (*Graph, error) {
 	root, err := parser.ParseQuery(query)
 	if err != nil {
 		return nil, err
@@ -84,7 +95,7 @@ func QueryGraph(query string) (*Graph, error) {
 		return nil, err
 	}
 	startNode.SetShape(cgraph.PointShape)
-	rootNode := visitNodes(ng, root)
+	rootNode := visitNodes(ng, root, stats)
 	firstEdge := ng.NewEdge(startNode, rootNode)
 	firstEdge.SetPenWidth(3)
 	return &Graph{
@@ -93,40 +104,37 @@ func QueryGraph(query string) (*Graph, error) {
 	}, nil
 }
 
-func visitNodes(graph *namegraph, anode ast.Node) *cgraph.Node {
+func visitNodes(graph *namegraph, anode ast.Node, stats *jsonmatcher.MatchStats) *cgraph.Node {
 	n := graph.NewNode()
 	n.SetStyle(cgraph.FilledNodeStyle)
 	n.SafeSet("fontname", "Courier", "")
 	n.SafeSet("fontsize", "10px", "")
-	var (
-		left  ast.Node
-		right ast.Node
-	)
+	var children []ast.Node
 	switch a := anode.(type) {
 	case *ast.NotNode:
-		n.SetLabel("NOT")
+		stats = statsFor(stats, jsonmatcher.NodeNot, 1)
+		n.SetLabel("NOT" + statsLabel(stats))
 		n.SetShape(cgraph.BoxShape)
-		n.SetFillColor(colorNot)
-		left = a.Expr
+		n.SetFillColor(colorFor(stats, colorNot))
+		children = []ast.Node{a.Expr}
 	case *ast.AndNode:
-		n.SetLabel("AND")
+		children, stats = chainChildren(anode, stats, jsonmatcher.NodeAnd)
+		n.SetLabel("AND" + statsLabel(stats))
 		n.SetShape(cgraph.BoxShape)
-		n.SetFillColor(colorAnd)
-		left = a.Left
-		right = a.Right
+		n.SetFillColor(colorFor(stats, colorAnd))
 	case *ast.OrNode:
-		n.SetLabel("OR")
+		children, stats = chainChildren(anode, stats, jsonmatcher.NodeOr)
+		n.SetLabel("OR" + statsLabel(stats))
 		n.SetShape(cgraph.BoxShape)
-		n.SetFillColor(colorOr)
-		left = a.Left
-		right = a.Right
+		n.SetFillColor(colorFor(stats, colorOr))
 	case *ast.ExprNode:
+		stats = statsFor(stats, jsonmatcher.NodeExpr, 0)
 		n.SetShape(cgraph.PlainShape)
 		n.SetFillColor(colorExpr)
 		// Populate the labeltable :D
 		// Probably want the "EXPR here"
 		hn := htmlNode{
-			Field: strings.Join(a.Field, "."),
+			Field: a.Subject(),
 			Props: []NodeProp{
 				{
 					Name:  "op",
@@ -134,6 +142,10 @@ func visitNodes(graph *namegraph, anode ast.Node) *cgraph.Node {
 				},
 			},
 		}
+		if stats != nil {
+			hn.FieldColor = selectivityColor(stats.Selectivity())
+			hn.Props = append(hn.Props, statsProps(stats)...)
+		}
 		for _, rv := range a.RVals {
 			valColor := ""
 			valType := ""
@@ -174,16 +186,134 @@ func visitNodes(graph *namegraph, anode ast.Node) *cgraph.Node {
 		}
 
 		n.SetLabel(graph.StrdupHTML(lb.String()))
-		// case *ast.SubdocNode:
-	}
-	if left != nil {
-		lNode := visitNodes(graph, left)
-		ledge := graph.NewEdge(n, lNode)
-		ledge.SetPenWidth(3)
+	case *ast.SubdocNode:
+		stats = statsFor(stats, jsonmatcher.NodeSubdoc, 1)
+		n.SetLabel(ast.FieldString(a.Field) + "{}" + statsLabel(stats))
+		n.SetShape(cgraph.BoxShape)
+		if stats != nil {
+			n.SetFillColor(colorFor(stats, colorSub))
+		} else {
+			n.SetFillColor(colorSub)
+			n.SetFontColor(colorSubFont)
+		}
+		children = []ast.Node{a.Expr}
 	}
-	if right != nil {
-		lNode := visitNodes(graph, right)
-		graph.NewEdge(n, lNode)
+	for i, child := range children {
+		cNode := visitNodes(graph, child, childStats(stats, i))
+		edge := graph.NewEdge(n, cNode)
+		if i == 0 {
+			edge.SetPenWidth(3)
+		}
 	}
 	return n
 }
+
+// chainChildren returns the children to graph for an AND or OR node, and its
+// stats. A matcher built with jsonmatcher.Optimize flattens chains of them
+// into a single node, so if the stats have one child for each operand of the
+// chain, the whole chain is graphed as one node. Otherwise, the node is
+// graphed as written.
+func chainChildren(node ast.Node, stats *jsonmatcher.MatchStats, nodeType string) ([]ast.Node, *jsonmatcher.MatchStats) {
+	if chain := chainOperands(node); len(chain) > 2 {
+		if chainStats := statsFor(stats, nodeType, len(chain)); chainStats != nil {
+			return chain, chainStats
+		}
+	}
+	return operands(node), statsFor(stats, nodeType, 2)
+}
+
+// chainOperands returns the operands of a chain of AND or OR nodes of the same
+// kind as node, flattening (a AND (b AND c)) into [a, b, c].
+func chainOperands(node ast.Node) []ast.Node {
+	var out []ast.Node
+	for _, operand := range operands(node) {
+		if sameChain(node, operand) {
+			out = append(out, chainOperands(operand)...)
+			continue
+		}
+		out = append(out, operand)
+	}
+	return out
+}
+
+// operands returns the left and right operands of an AND or OR node.
+func operands(node ast.Node) []ast.Node {
+	switch n := node.(type) {
+	case *ast.AndNode:
+		return []ast.Node{n.Left, n.Right}
+	case *ast.OrNode:
+		return []ast.Node{n.Left, n.Right}
+	}
+	return nil
+}
+
+func sameChain(a, b ast.Node) bool {
+	switch a.(type) {
+	case *ast.AndNode:
+		_, ok := b.(*ast.AndNode)
+		return ok
+	case *ast.OrNode:
+		_, ok := b.(*ast.OrNode)
+		return ok
+	}
+	return false
+}
+
+// statsFor returns the stats if they correspond to the expected node type and
+// number of children, so that stats from a different query are ignored rather
+// than mislabeling the graph.
+func statsFor(stats *jsonmatcher.MatchStats, nodeType string, numChildren int) *jsonmatcher.MatchStats {
+	if stats == nil || stats.NodeType != nodeType || len(stats.Children) != numChildren {
+		return nil
+	}
+	return stats
+}
+
+func childStats(stats *jsonmatcher.MatchStats, idx int) *jsonmatcher.MatchStats {
+	if stats == nil || idx >= len(stats.Children) {
+		return nil
+	}
+	return stats.Children[idx]
+}
+
+func statsLabel(stats *jsonmatcher.MatchStats) string {
+	if stats == nil {
+		return ""
+	}
+	return fmt.Sprintf("\n%d/%d (%s)", stats.TimesMatched, stats.TimesChecked, selectivityString(stats.Selectivity()))
+}
+
+func statsProps(stats *jsonmatcher.MatchStats) []NodeProp {
+	return []NodeProp{
+		{
+			Name:  "checked",
+			Value: strconv.FormatInt(stats.TimesChecked, 10),
+		},
+		{
+			Name:  "matched",
+			Value: strconv.FormatInt(stats.TimesMatched, 10),
+		},
+		{
+			Name:  "selectivity",
+			Value: selectivityString(stats.Selectivity()),
+		},
+		{
+			Name:  "avg time",
+			Value: stats.AverageTime().String(),
+		},
+	}
+}
+
+func selectivityString(selectivity float64) string {
+	if selectivity < 0 {
+		return "unchecked"
+	}
+	return strconv.FormatFloat(selectivity*100, 'f', 1, 64) + "%"
+}
+
+func colorFor(stats *jsonmatcher.MatchStats, defaultColor string) string {
+	if stats == nil {
+		return defaultColor
+	}
+	return selectivityColor(stats.Selectivity())
+}
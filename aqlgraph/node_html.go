@@ -7,8 +7,9 @@ const (
 	colorOr  = `#f4e452`
 	colorNot = `#ba4932`
 	// colorExpr = `#00507c`
-	colorExpr = `#427baa`
-	colorSub  = `#000000`
+	colorExpr    = `#427baa`
+	colorSub     = `#000000`
+	colorSubFont = `#ffffff`
 
 	colorFloat  = `#24b581`
 	colorInt    = `#007a55`
@@ -20,9 +21,10 @@ const (
 )
 
 type htmlNode struct {
-	Field  string
-	Props  []NodeProp
-	Values []NodeVal
+	Field      string
+	FieldColor string
+	Props      []NodeProp
+	Values     []NodeVal
 }
 
 type NodeProp struct {
@@ -38,7 +40,7 @@ type NodeVal struct {
 
 var labelTmpl = template.Must(template.New("").Parse(`<TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0" CELLPADDING="2">
 <TR>
-	<TD COLSPAN="2" CELLPADDING="8">{{ .Field }}</TD>
+	<TD COLSPAN="2" CELLPADDING="8"{{ if .FieldColor }} BGCOLOR="{{ .FieldColor }}"{{ end }}>{{ .Field }}</TD>
 </TR>
 {{- range .Props}}
 <TR>
@@ -0,0 +1,36 @@
+package aqlgraph
+
+import "fmt"
+
+const colorUnchecked = `#bbbbbb`
+
+// selectivity gradient stops, from never matching to always matching
+var selectivityStops = [...][3]float64{
+	{0xd7, 0x30, 0x27},
+	{0xfe, 0xe0, 0x8b},
+	{0x1a, 0x98, 0x50},
+}
+
+// selectivityColor returns a color on a red-yellow-green gradient for a
+// selectivity between 0 and 1. Negative selectivity means the node was never
+// checked.
+func selectivityColor(selectivity float64) string {
+	if selectivity < 0 {
+		return colorUnchecked
+	}
+	if selectivity > 1 {
+		selectivity = 1
+	}
+	pos := selectivity * float64(len(selectivityStops)-1)
+	i := int(pos)
+	if i >= len(selectivityStops)-1 {
+		i = len(selectivityStops) - 2
+	}
+	frac := pos - float64(i)
+	var rgb [3]int
+	for c := range rgb {
+		from, to := selectivityStops[i][c], selectivityStops[i+1][c]
+		rgb[c] = int(from + (to-from)*frac + 0.5)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2])
+}
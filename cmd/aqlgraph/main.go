@@ -1,18 +1,20 @@
 package main
 
 import (
+	"encoding/json"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/flowchartsman/aql/aqlgraph"
+	"github.com/flowchartsman/aql/jsonmatcher"
 )
 
 func main() {
 	log.SetFlags(0)
 	if len(os.Args) < 2 {
-		log.Fatalf("usage: %s <aql query string> [output file]", os.Args[0])
+		log.Fatalf("usage: %s <aql query string> [output file] [match stats JSON file]", os.Args[0])
 	}
 	query := os.Args[1]
 	outputFile := "query_graph.svg"
@@ -21,7 +23,17 @@ func main() {
 		outputFile = os.Args[2]
 		ext = strings.ToLower(filepath.Ext(outputFile))
 	}
-	graph, err := aqlgraph.QueryGraph(query)
+	var stats *jsonmatcher.MatchStats
+	if len(os.Args) > 3 {
+		statsB, err := os.ReadFile(os.Args[3])
+		if err != nil {
+			log.Fatalf("could not open stats file: %v", err)
+		}
+		if err := json.Unmarshal(statsB, &stats); err != nil {
+			log.Fatalf("invalid stats file: %v", err)
+		}
+	}
+	graph, err := aqlgraph.QueryStatsGraph(query, stats)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -16,7 +16,7 @@ import (
 func main() {
 	log.SetFlags(0)
 	if len(os.Args) != 3 {
-		log.Fatal("Usage: aql 'EXPR' <json file>")
+		log.Fatal("Usage: aql 'EXPR' <json file|ndjson file|->")
 	}
 
 	query := os.Args[1]
@@ -30,7 +30,7 @@ func main() {
 	}
 
 	printer := fmtmsg.NewTerminalFormatter(-1).WithQuery(query)
-	m, err := jsonmatcher.NewMatcher(query)
+	m, err := jsonmatcher.NewMatcher(query, jsonmatcher.TrackQueryStats(), jsonmatcher.TrackFieldStats())
 	if err != nil {
 		var parseErr *parser.ParseError
 		if errors.As(err, &parseErr) {
@@ -39,25 +39,51 @@ func main() {
 		log.Fatal(err)
 	}
 
-	input, err := os.ReadFile(os.Args[2])
-	if err != nil {
-		log.Fatalf("could not open file: %v", err)
-	}
-
 	for _, m := range m.Messages() {
 		log.Println(printer.Sprint(m))
 	}
 
-	result, err := m.Match(input)
-	if err != nil {
-		log.Fatal(err)
+	// matching documents are written to stdout when filtering a stream, so
+	// the stats go to stderr to keep the output valid NDJSON
+	statsOut := os.Stderr
+	switch inputFile := os.Args[2]; {
+	case inputFile == "-":
+		if err := m.FilterParallel(os.Stdin, os.Stdout, jsonmatcher.OnDocumentError(func(err error) { log.Println(err) })); err != nil {
+			log.Fatal(err)
+		}
+	case strings.HasSuffix(inputFile, ".ndjson"), strings.HasSuffix(inputFile, ".jsonl"):
+		f, err := os.Open(inputFile)
+		if err != nil {
+			log.Fatalf("could not open file: %v", err)
+		}
+		defer f.Close()
+		if err := m.FilterParallel(f, os.Stdout, jsonmatcher.OnDocumentError(func(err error) { log.Println(err) })); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		input, err := os.ReadFile(inputFile)
+		if err != nil {
+			log.Fatalf("could not open file: %v", err)
+		}
+		result, err := m.Match(input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(result)
+		statsOut = os.Stdout
 	}
-	fmt.Println(result)
 	if stats := m.Stats(); stats != nil {
 		statsB, err := json.MarshalIndent(stats, "", "  ")
 		if err != nil {
 			log.Fatalf("error marshalling stats: %s", err)
 		}
-		fmt.Println(string(statsB))
+		fmt.Fprintln(statsOut, string(statsB))
+	}
+	if fieldStats := m.FieldStats(); fieldStats != nil {
+		statsB, err := json.MarshalIndent(fieldStats, "", "  ")
+		if err != nil {
+			log.Fatalf("error marshalling field stats: %s", err)
+		}
+		fmt.Fprintln(statsOut, string(statsB))
 	}
 }
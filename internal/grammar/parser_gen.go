@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"math"
 	"os"
 	"sort"
@@ -34,7 +33,6 @@ import (
 //     displayed)
 //   - rename node types to line up AST more with convention (like expression,
 //     operation, etc)
-//   - attach position to all ast nodes
 //   - comments
 
 // ParseError is the exported error type for parsing errors with detailed information as to where they occurred
@@ -87,6 +85,31 @@ func (p *ParseError) Pos() ast.Pos {
 	return p.Position
 }
 
+// operationSpec is an operator along with its parameters, if any
+type operationSpec struct {
+	op       ast.Op
+	params   []string
+	paramPos []ast.Pos
+}
+
+// opParam is a single operation parameter and its position
+type opParam struct {
+	name string
+	pos  ast.Pos
+}
+
+// rangeSpec is the values and bounds of a range like [1 TO *}
+type rangeSpec struct {
+	vals   []ast.Val
+	bounds [2]ast.Bound
+}
+
+// fieldCall is a function applied to a field, like len(field)
+type fieldCall struct {
+	field ast.Path
+	fn    *ast.FuncVal
+}
+
 // helper to create an ast.Pos from c
 func getpos(c *current) ast.Pos {
 	return ast.Pos{
@@ -165,48 +188,27 @@ func toString(label any) (string, error) {
 	return sb.String(), nil
 }
 
-// helper methods to remove legacy star syntax
-func removeStars(ss []string) []string {
-	for {
-		i := findStarIdx(ss)
-		if i < 0 {
-			break
-		}
-		ss = append(ss[:i], ss[i+1:]...)
-	}
-	return ss
-}
-
-func findStarIdx(ss []string) int {
-	for i, s := range ss {
-		if s == "*" {
-			return i
-		}
-	}
-	return -1
-}
-
 var g = &grammar{
 	rules: []*rule{
 		{
 			name: "Start",
-			pos:  position{line: 183, col: 1, offset: 4285},
+			pos:  position{line: 187, col: 1, offset: 4488},
 			expr: &actionExpr{
-				pos: position{line: 183, col: 10, offset: 4294},
+				pos: position{line: 187, col: 10, offset: 4497},
 				run: (*parser).callonStart1,
 				expr: &seqExpr{
-					pos: position{line: 183, col: 10, offset: 4294},
-					exprs: []interface{}{
+					pos: position{line: 187, col: 10, offset: 4497},
+					exprs: []any{
 						&labeledExpr{
-							pos:   position{line: 183, col: 10, offset: 4294},
+							pos:   position{line: 187, col: 10, offset: 4497},
 							label: "query",
 							expr: &ruleRefExpr{
-								pos:  position{line: 183, col: 16, offset: 4300},
+								pos:  position{line: 187, col: 16, offset: 4503},
 								name: "Query",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 183, col: 22, offset: 4306},
+							pos:  position{line: 187, col: 22, offset: 4509},
 							name: "EOF",
 						},
 					},
@@ -215,27 +217,27 @@ var g = &grammar{
 		},
 		{
 			name: "Query",
-			pos:  position{line: 187, col: 1, offset: 4337},
+			pos:  position{line: 191, col: 1, offset: 4540},
 			expr: &actionExpr{
-				pos: position{line: 187, col: 10, offset: 4346},
+				pos: position{line: 191, col: 10, offset: 4549},
 				run: (*parser).callonQuery1,
 				expr: &seqExpr{
-					pos: position{line: 187, col: 10, offset: 4346},
-					exprs: []interface{}{
+					pos: position{line: 191, col: 10, offset: 4549},
+					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 187, col: 10, offset: 4346},
+							pos:  position{line: 191, col: 10, offset: 4549},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 187, col: 12, offset: 4348},
+							pos:   position{line: 191, col: 12, offset: 4551},
 							label: "clause",
 							expr: &ruleRefExpr{
-								pos:  position{line: 187, col: 19, offset: 4355},
+								pos:  position{line: 191, col: 19, offset: 4558},
 								name: "OrClause",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 187, col: 28, offset: 4364},
+							pos:  position{line: 191, col: 28, offset: 4567},
 							name: "_",
 						},
 					},
@@ -244,41 +246,41 @@ var g = &grammar{
 		},
 		{
 			name: "OrClause",
-			pos:  position{line: 195, col: 1, offset: 4414},
+			pos:  position{line: 199, col: 1, offset: 4617},
 			expr: &choiceExpr{
-				pos: position{line: 195, col: 13, offset: 4426},
-				alternatives: []interface{}{
+				pos: position{line: 199, col: 13, offset: 4629},
+				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 195, col: 13, offset: 4426},
+						pos: position{line: 199, col: 13, offset: 4629},
 						run: (*parser).callonOrClause2,
 						expr: &seqExpr{
-							pos: position{line: 195, col: 13, offset: 4426},
-							exprs: []interface{}{
+							pos: position{line: 199, col: 13, offset: 4629},
+							exprs: []any{
 								&labeledExpr{
-									pos:   position{line: 195, col: 13, offset: 4426},
+									pos:   position{line: 199, col: 13, offset: 4629},
 									label: "lhs",
 									expr: &ruleRefExpr{
-										pos:  position{line: 195, col: 17, offset: 4430},
+										pos:  position{line: 199, col: 17, offset: 4633},
 										name: "AndClause",
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 195, col: 27, offset: 4440},
+									pos:  position{line: 199, col: 27, offset: 4643},
 									name: "space",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 195, col: 33, offset: 4446},
+									pos:  position{line: 199, col: 33, offset: 4649},
 									name: "logicalOR",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 195, col: 43, offset: 4456},
+									pos:  position{line: 199, col: 43, offset: 4659},
 									name: "space",
 								},
 								&labeledExpr{
-									pos:   position{line: 195, col: 49, offset: 4462},
+									pos:   position{line: 199, col: 49, offset: 4665},
 									label: "rhs",
 									expr: &ruleRefExpr{
-										pos:  position{line: 195, col: 53, offset: 4466},
+										pos:  position{line: 199, col: 53, offset: 4669},
 										name: "OrClause",
 									},
 								},
@@ -286,7 +288,7 @@ var g = &grammar{
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 200, col: 5, offset: 4578},
+						pos:  position{line: 205, col: 5, offset: 4810},
 						name: "AndClause",
 					},
 				},
@@ -294,41 +296,41 @@ var g = &grammar{
 		},
 		{
 			name: "AndClause",
-			pos:  position{line: 202, col: 1, offset: 4589},
+			pos:  position{line: 207, col: 1, offset: 4821},
 			expr: &choiceExpr{
-				pos: position{line: 202, col: 14, offset: 4602},
-				alternatives: []interface{}{
+				pos: position{line: 207, col: 14, offset: 4834},
+				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 202, col: 14, offset: 4602},
+						pos: position{line: 207, col: 14, offset: 4834},
 						run: (*parser).callonAndClause2,
 						expr: &seqExpr{
-							pos: position{line: 202, col: 14, offset: 4602},
-							exprs: []interface{}{
+							pos: position{line: 207, col: 14, offset: 4834},
+							exprs: []any{
 								&labeledExpr{
-									pos:   position{line: 202, col: 14, offset: 4602},
+									pos:   position{line: 207, col: 14, offset: 4834},
 									label: "lhs",
 									expr: &ruleRefExpr{
-										pos:  position{line: 202, col: 18, offset: 4606},
+										pos:  position{line: 207, col: 18, offset: 4838},
 										name: "NotClause",
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 202, col: 28, offset: 4616},
+									pos:  position{line: 207, col: 28, offset: 4848},
 									name: "space",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 202, col: 34, offset: 4622},
+									pos:  position{line: 207, col: 34, offset: 4854},
 									name: "logicalAND",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 202, col: 45, offset: 4633},
+									pos:  position{line: 207, col: 45, offset: 4865},
 									name: "space",
 								},
 								&labeledExpr{
-									pos:   position{line: 202, col: 51, offset: 4639},
+									pos:   position{line: 207, col: 51, offset: 4871},
 									label: "rhs",
 									expr: &ruleRefExpr{
-										pos:  position{line: 202, col: 55, offset: 4643},
+										pos:  position{line: 207, col: 55, offset: 4875},
 										name: "AndClause",
 									},
 								},
@@ -336,7 +338,7 @@ var g = &grammar{
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 207, col: 5, offset: 4757},
+						pos:  position{line: 213, col: 5, offset: 5018},
 						name: "NotClause",
 					},
 				},
@@ -344,25 +346,25 @@ var g = &grammar{
 		},
 		{
 			name: "NotClause",
-			pos:  position{line: 209, col: 1, offset: 4768},
+			pos:  position{line: 215, col: 1, offset: 5029},
 			expr: &choiceExpr{
-				pos: position{line: 209, col: 14, offset: 4781},
-				alternatives: []interface{}{
+				pos: position{line: 215, col: 14, offset: 5042},
+				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 209, col: 14, offset: 4781},
+						pos: position{line: 215, col: 14, offset: 5042},
 						run: (*parser).callonNotClause2,
 						expr: &seqExpr{
-							pos: position{line: 209, col: 14, offset: 4781},
-							exprs: []interface{}{
+							pos: position{line: 215, col: 14, offset: 5042},
+							exprs: []any{
 								&ruleRefExpr{
-									pos:  position{line: 209, col: 14, offset: 4781},
+									pos:  position{line: 215, col: 14, offset: 5042},
 									name: "logicalNOT",
 								},
 								&labeledExpr{
-									pos:   position{line: 209, col: 25, offset: 4792},
+									pos:   position{line: 215, col: 25, offset: 5053},
 									label: "cmp",
 									expr: &ruleRefExpr{
-										pos:  position{line: 209, col: 29, offset: 4796},
+										pos:  position{line: 215, col: 29, offset: 5057},
 										name: "Comparison",
 									},
 								},
@@ -370,7 +372,7 @@ var g = &grammar{
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 213, col: 5, offset: 4880},
+						pos:  position{line: 220, col: 5, offset: 5170},
 						name: "Comparison",
 					},
 				},
@@ -378,40 +380,40 @@ var g = &grammar{
 		},
 		{
 			name: "Comparison",
-			pos:  position{line: 216, col: 1, offset: 4929},
+			pos:  position{line: 223, col: 1, offset: 5219},
 			expr: &choiceExpr{
-				pos: position{line: 216, col: 15, offset: 4943},
-				alternatives: []interface{}{
+				pos: position{line: 223, col: 15, offset: 5233},
+				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 216, col: 15, offset: 4943},
+						pos: position{line: 223, col: 15, offset: 5233},
 						run: (*parser).callonComparison2,
 						expr: &seqExpr{
-							pos: position{line: 216, col: 15, offset: 4943},
-							exprs: []interface{}{
+							pos: position{line: 223, col: 15, offset: 5233},
+							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 216, col: 15, offset: 4943},
+									pos:        position{line: 223, col: 15, offset: 5233},
 									val:        "(",
 									ignoreCase: false,
 									want:       "\"(\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 216, col: 19, offset: 4947},
+									pos:  position{line: 223, col: 19, offset: 5237},
 									name: "_",
 								},
 								&labeledExpr{
-									pos:   position{line: 216, col: 21, offset: 4949},
+									pos:   position{line: 223, col: 21, offset: 5239},
 									label: "query",
 									expr: &ruleRefExpr{
-										pos:  position{line: 216, col: 27, offset: 4955},
+										pos:  position{line: 223, col: 27, offset: 5245},
 										name: "OrClause",
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 216, col: 36, offset: 4964},
+									pos:  position{line: 223, col: 36, offset: 5254},
 									name: "_",
 								},
 								&litMatcher{
-									pos:        position{line: 216, col: 38, offset: 4966},
+									pos:        position{line: 223, col: 38, offset: 5256},
 									val:        ")",
 									ignoreCase: false,
 									want:       "\")\"",
@@ -420,38 +422,38 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 218, col: 5, offset: 4997},
+						pos: position{line: 225, col: 5, offset: 5287},
 						run: (*parser).callonComparison10,
 						expr: &seqExpr{
-							pos: position{line: 218, col: 5, offset: 4997},
-							exprs: []interface{}{
+							pos: position{line: 225, col: 5, offset: 5287},
+							exprs: []any{
 								&labeledExpr{
-									pos:   position{line: 218, col: 5, offset: 4997},
+									pos:   position{line: 225, col: 5, offset: 5287},
 									label: "field",
 									expr: &ruleRefExpr{
-										pos:  position{line: 218, col: 11, offset: 5003},
+										pos:  position{line: 225, col: 11, offset: 5293},
 										name: "Field",
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 218, col: 17, offset: 5009},
+									pos:  position{line: 225, col: 17, offset: 5299},
 									name: "_",
 								},
 								&litMatcher{
-									pos:        position{line: 218, col: 19, offset: 5011},
+									pos:        position{line: 225, col: 19, offset: 5301},
 									val:        ":",
 									ignoreCase: false,
 									want:       "\":\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 218, col: 23, offset: 5015},
+									pos:  position{line: 225, col: 23, offset: 5305},
 									name: "_",
 								},
 								&labeledExpr{
-									pos:   position{line: 218, col: 25, offset: 5017},
+									pos:   position{line: 225, col: 25, offset: 5307},
 									label: "operation",
 									expr: &ruleRefExpr{
-										pos:  position{line: 218, col: 35, offset: 5027},
+										pos:  position{line: 225, col: 35, offset: 5317},
 										name: "opNoArgs",
 									},
 								},
@@ -459,53 +461,234 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 233, col: 6, offset: 5352},
+						pos: position{line: 232, col: 5, offset: 5480},
 						run: (*parser).callonComparison19,
 						expr: &seqExpr{
-							pos: position{line: 233, col: 6, offset: 5352},
-							exprs: []interface{}{
+							pos: position{line: 232, col: 5, offset: 5480},
+							exprs: []any{
+								&labeledExpr{
+									pos:   position{line: 232, col: 5, offset: 5480},
+									label: "field",
+									expr: &ruleRefExpr{
+										pos:  position{line: 232, col: 11, offset: 5486},
+										name: "Field",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 232, col: 17, offset: 5492},
+									name: "_",
+								},
+								&litMatcher{
+									pos:        position{line: 232, col: 19, offset: 5494},
+									val:        "{",
+									ignoreCase: false,
+									want:       "\"{\"",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 232, col: 23, offset: 5498},
+									name: "_",
+								},
+								&labeledExpr{
+									pos:   position{line: 232, col: 25, offset: 5500},
+									label: "query",
+									expr: &ruleRefExpr{
+										pos:  position{line: 232, col: 31, offset: 5506},
+										name: "OrClause",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 232, col: 40, offset: 5515},
+									name: "_",
+								},
+								&litMatcher{
+									pos:        position{line: 232, col: 42, offset: 5517},
+									val:        "}",
+									ignoreCase: false,
+									want:       "\"}\"",
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 239, col: 5, offset: 5669},
+						run: (*parser).callonComparison30,
+						expr: &seqExpr{
+							pos: position{line: 239, col: 5, offset: 5669},
+							exprs: []any{
+								&labeledExpr{
+									pos:   position{line: 239, col: 5, offset: 5669},
+									label: "field",
+									expr: &ruleRefExpr{
+										pos:  position{line: 239, col: 11, offset: 5675},
+										name: "Field",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 239, col: 17, offset: 5681},
+									name: "_",
+								},
+								&litMatcher{
+									pos:        position{line: 239, col: 19, offset: 5683},
+									val:        ":",
+									ignoreCase: false,
+									want:       "\":\"",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 239, col: 23, offset: 5687},
+									name: "_",
+								},
+								&labeledExpr{
+									pos:   position{line: 239, col: 25, offset: 5689},
+									label: "rng",
+									expr: &ruleRefExpr{
+										pos:  position{line: 239, col: 29, offset: 5693},
+										name: "Range",
+									},
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 249, col: 6, offset: 5925},
+						run: (*parser).callonComparison39,
+						expr: &seqExpr{
+							pos: position{line: 249, col: 6, offset: 5925},
+							exprs: []any{
 								&labeledExpr{
-									pos:   position{line: 233, col: 6, offset: 5352},
+									pos:   position{line: 249, col: 6, offset: 5925},
 									label: "field",
 									expr: &ruleRefExpr{
-										pos:  position{line: 233, col: 12, offset: 5358},
+										pos:  position{line: 249, col: 12, offset: 5931},
 										name: "Field",
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 233, col: 18, offset: 5364},
+									pos:  position{line: 249, col: 18, offset: 5937},
+									name: "_",
+								},
+								&litMatcher{
+									pos:        position{line: 249, col: 20, offset: 5939},
+									val:        ":",
+									ignoreCase: false,
+									want:       "\":\"",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 249, col: 24, offset: 5943},
+									name: "_",
+								},
+								&labeledExpr{
+									pos:   position{line: 249, col: 26, offset: 5945},
+									label: "operation",
+									expr: &zeroOrOneExpr{
+										pos: position{line: 249, col: 36, offset: 5955},
+										expr: &ruleRefExpr{
+											pos:  position{line: 249, col: 36, offset: 5955},
+											name: "Operation",
+										},
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 249, col: 47, offset: 5966},
+									name: "_",
+								},
+								&labeledExpr{
+									pos:   position{line: 249, col: 49, offset: 5968},
+									label: "values",
+									expr: &ruleRefExpr{
+										pos:  position{line: 249, col: 56, offset: 5975},
+										name: "ValueList",
+									},
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 264, col: 5, offset: 6357},
+						run: (*parser).callonComparison52,
+						expr: &seqExpr{
+							pos: position{line: 264, col: 5, offset: 6357},
+							exprs: []any{
+								&labeledExpr{
+									pos:   position{line: 264, col: 5, offset: 6357},
+									label: "call",
+									expr: &ruleRefExpr{
+										pos:  position{line: 264, col: 10, offset: 6362},
+										name: "FieldCall",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 264, col: 20, offset: 6372},
+									name: "_",
+								},
+								&litMatcher{
+									pos:        position{line: 264, col: 22, offset: 6374},
+									val:        ":",
+									ignoreCase: false,
+									want:       "\":\"",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 264, col: 26, offset: 6378},
+									name: "_",
+								},
+								&labeledExpr{
+									pos:   position{line: 264, col: 28, offset: 6380},
+									label: "rng",
+									expr: &ruleRefExpr{
+										pos:  position{line: 264, col: 32, offset: 6384},
+										name: "Range",
+									},
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 276, col: 5, offset: 6659},
+						run: (*parser).callonComparison61,
+						expr: &seqExpr{
+							pos: position{line: 276, col: 5, offset: 6659},
+							exprs: []any{
+								&labeledExpr{
+									pos:   position{line: 276, col: 5, offset: 6659},
+									label: "call",
+									expr: &ruleRefExpr{
+										pos:  position{line: 276, col: 10, offset: 6664},
+										name: "FieldCall",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 276, col: 20, offset: 6674},
 									name: "_",
 								},
 								&litMatcher{
-									pos:        position{line: 233, col: 20, offset: 5366},
+									pos:        position{line: 276, col: 22, offset: 6676},
 									val:        ":",
 									ignoreCase: false,
 									want:       "\":\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 233, col: 24, offset: 5370},
+									pos:  position{line: 276, col: 26, offset: 6680},
 									name: "_",
 								},
 								&labeledExpr{
-									pos:   position{line: 233, col: 26, offset: 5372},
+									pos:   position{line: 276, col: 28, offset: 6682},
 									label: "operation",
 									expr: &zeroOrOneExpr{
-										pos: position{line: 233, col: 36, offset: 5382},
+										pos: position{line: 276, col: 38, offset: 6692},
 										expr: &ruleRefExpr{
-											pos:  position{line: 233, col: 36, offset: 5382},
-											name: "opComp",
+											pos:  position{line: 276, col: 38, offset: 6692},
+											name: "Operation",
 										},
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 233, col: 44, offset: 5390},
+									pos:  position{line: 276, col: 49, offset: 6703},
 									name: "_",
 								},
 								&labeledExpr{
-									pos:   position{line: 233, col: 46, offset: 5392},
+									pos:   position{line: 276, col: 51, offset: 6705},
 									label: "values",
 									expr: &ruleRefExpr{
-										pos:  position{line: 233, col: 53, offset: 5399},
+										pos:  position{line: 276, col: 58, offset: 6712},
 										name: "ValueList",
 									},
 								},
@@ -515,36 +698,123 @@ var g = &grammar{
 				},
 			},
 		},
+		{
+			name: "FieldCall",
+			pos:  position{line: 295, col: 1, offset: 7214},
+			expr: &actionExpr{
+				pos: position{line: 295, col: 14, offset: 7227},
+				run: (*parser).callonFieldCall1,
+				expr: &seqExpr{
+					pos: position{line: 295, col: 14, offset: 7227},
+					exprs: []any{
+						&labeledExpr{
+							pos:   position{line: 295, col: 14, offset: 7227},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:  position{line: 295, col: 19, offset: 7232},
+								name: "FuncName",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 295, col: 28, offset: 7241},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 295, col: 30, offset: 7243},
+							val:        "(",
+							ignoreCase: false,
+							want:       "\"(\"",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 295, col: 34, offset: 7247},
+							name: "_",
+						},
+						&labeledExpr{
+							pos:   position{line: 295, col: 36, offset: 7249},
+							label: "field",
+							expr: &ruleRefExpr{
+								pos:  position{line: 295, col: 42, offset: 7255},
+								name: "Field",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 295, col: 48, offset: 7261},
+							label: "args",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 295, col: 53, offset: 7266},
+								expr: &seqExpr{
+									pos: position{line: 295, col: 55, offset: 7268},
+									exprs: []any{
+										&ruleRefExpr{
+											pos:  position{line: 295, col: 55, offset: 7268},
+											name: "_",
+										},
+										&litMatcher{
+											pos:        position{line: 295, col: 57, offset: 7270},
+											val:        ",",
+											ignoreCase: false,
+											want:       "\",\"",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 295, col: 61, offset: 7274},
+											name: "_",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 295, col: 63, offset: 7276},
+											name: "Value",
+										},
+									},
+								},
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 295, col: 72, offset: 7285},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 295, col: 74, offset: 7287},
+							val:        ")",
+							ignoreCase: false,
+							want:       "\")\"",
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "Field",
-			pos:  position{line: 254, col: 1, offset: 5793},
+			pos:  position{line: 311, col: 1, offset: 7606},
 			expr: &actionExpr{
-				pos: position{line: 254, col: 10, offset: 5802},
+				pos: position{line: 311, col: 10, offset: 7615},
 				run: (*parser).callonField1,
-				expr: &labeledExpr{
-					pos:   position{line: 254, col: 10, offset: 5802},
-					label: "pieces",
-					expr: &seqExpr{
-						pos: position{line: 254, col: 18, offset: 5810},
-						exprs: []interface{}{
-							&ruleRefExpr{
-								pos:  position{line: 254, col: 18, offset: 5810},
-								name: "FieldPiece",
+				expr: &seqExpr{
+					pos: position{line: 311, col: 10, offset: 7615},
+					exprs: []any{
+						&labeledExpr{
+							pos:   position{line: 311, col: 10, offset: 7615},
+							label: "first",
+							expr: &ruleRefExpr{
+								pos:  position{line: 311, col: 16, offset: 7621},
+								name: "FieldElement",
 							},
-							&zeroOrMoreExpr{
-								pos: position{line: 254, col: 29, offset: 5821},
+						},
+						&labeledExpr{
+							pos:   position{line: 311, col: 29, offset: 7634},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 311, col: 34, offset: 7639},
 								expr: &seqExpr{
-									pos: position{line: 254, col: 30, offset: 5822},
-									exprs: []interface{}{
+									pos: position{line: 311, col: 35, offset: 7640},
+									exprs: []any{
 										&litMatcher{
-											pos:        position{line: 254, col: 30, offset: 5822},
+											pos:        position{line: 311, col: 35, offset: 7640},
 											val:        ".",
 											ignoreCase: false,
 											want:       "\".\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 254, col: 34, offset: 5826},
-											name: "FieldPiece",
+											pos:  position{line: 311, col: 39, offset: 7644},
+											name: "FieldElement",
 										},
 									},
 								},
@@ -554,22 +824,54 @@ var g = &grammar{
 				},
 			},
 		},
+		{
+			name: "FieldElement",
+			pos:  position{line: 324, col: 1, offset: 8000},
+			expr: &actionExpr{
+				pos: position{line: 324, col: 17, offset: 8016},
+				run: (*parser).callonFieldElement1,
+				expr: &seqExpr{
+					pos: position{line: 324, col: 17, offset: 8016},
+					exprs: []any{
+						&labeledExpr{
+							pos:   position{line: 324, col: 17, offset: 8016},
+							label: "piece",
+							expr: &ruleRefExpr{
+								pos:  position{line: 324, col: 23, offset: 8022},
+								name: "FieldPiece",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 324, col: 34, offset: 8033},
+							label: "selectors",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 324, col: 44, offset: 8043},
+								expr: &ruleRefExpr{
+									pos:  position{line: 324, col: 44, offset: 8043},
+									name: "ArraySelector",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "FieldPiece",
-			pos:  position{line: 270, col: 1, offset: 6239},
+			pos:  position{line: 335, col: 1, offset: 8300},
 			expr: &choiceExpr{
-				pos: position{line: 270, col: 15, offset: 6253},
-				alternatives: []interface{}{
+				pos: position{line: 335, col: 15, offset: 8314},
+				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 270, col: 15, offset: 6253},
+						pos:  position{line: 335, col: 15, offset: 8314},
 						name: "QuotedFieldPiece",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 270, col: 34, offset: 6272},
+						pos:  position{line: 335, col: 34, offset: 8333},
 						name: "UnquotedFieldPiece",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 270, col: 55, offset: 6293},
+						pos:  position{line: 335, col: 55, offset: 8354},
 						name: "Star",
 					},
 				},
@@ -577,14 +879,14 @@ var g = &grammar{
 		},
 		{
 			name: "UnquotedFieldPiece",
-			pos:  position{line: 272, col: 1, offset: 6299},
+			pos:  position{line: 337, col: 1, offset: 8360},
 			expr: &actionExpr{
-				pos: position{line: 272, col: 23, offset: 6321},
+				pos: position{line: 337, col: 23, offset: 8382},
 				run: (*parser).callonUnquotedFieldPiece1,
 				expr: &oneOrMoreExpr{
-					pos: position{line: 272, col: 23, offset: 6321},
+					pos: position{line: 337, col: 23, offset: 8382},
 					expr: &charClassMatcher{
-						pos:        position{line: 272, col: 23, offset: 6321},
+						pos:        position{line: 337, col: 23, offset: 8382},
 						val:        "[a-z0-9_-]i",
 						chars:      []rune{'_', '-'},
 						ranges:     []rune{'a', 'z', '0', '9'},
@@ -596,15 +898,15 @@ var g = &grammar{
 		},
 		{
 			name: "QuotedFieldPiece",
-			pos:  position{line: 276, col: 1, offset: 6370},
+			pos:  position{line: 341, col: 1, offset: 8431},
 			expr: &actionExpr{
-				pos: position{line: 276, col: 21, offset: 6390},
+				pos: position{line: 341, col: 21, offset: 8451},
 				run: (*parser).callonQuotedFieldPiece1,
 				expr: &labeledExpr{
-					pos:   position{line: 276, col: 21, offset: 6390},
+					pos:   position{line: 341, col: 21, offset: 8451},
 					label: "qv",
 					expr: &ruleRefExpr{
-						pos:  position{line: 276, col: 24, offset: 6393},
+						pos:  position{line: 341, col: 24, offset: 8454},
 						name: "QuotedValue",
 					},
 				},
@@ -612,12 +914,12 @@ var g = &grammar{
 		},
 		{
 			name: "Star",
-			pos:  position{line: 282, col: 1, offset: 6551},
+			pos:  position{line: 347, col: 1, offset: 8612},
 			expr: &actionExpr{
-				pos: position{line: 282, col: 9, offset: 6559},
+				pos: position{line: 347, col: 9, offset: 8620},
 				run: (*parser).callonStar1,
 				expr: &litMatcher{
-					pos:        position{line: 282, col: 9, offset: 6559},
+					pos:        position{line: 347, col: 9, offset: 8620},
 					val:        "*",
 					ignoreCase: false,
 					want:       "\"*\"",
@@ -625,59 +927,320 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "ValueList",
-			pos:  position{line: 291, col: 1, offset: 6677},
-			expr: &choiceExpr{
-				pos: position{line: 291, col: 14, offset: 6690},
-				alternatives: []interface{}{
-					&actionExpr{
-						pos: position{line: 291, col: 14, offset: 6690},
-						run: (*parser).callonValueList2,
-						expr: &seqExpr{
-							pos: position{line: 291, col: 14, offset: 6690},
-							exprs: []interface{}{
-								&litMatcher{
-									pos:        position{line: 291, col: 14, offset: 6690},
-									val:        "(",
-									ignoreCase: false,
-									want:       "\"(\"",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 291, col: 17, offset: 6693},
-									name: "_",
-								},
-								&labeledExpr{
-									pos:   position{line: 291, col: 19, offset: 6695},
-									label: "first",
-									expr: &ruleRefExpr{
-										pos:  position{line: 291, col: 25, offset: 6701},
-										name: "Value",
+			name: "ArraySelector",
+			pos:  position{line: 351, col: 1, offset: 8649},
+			expr: &actionExpr{
+				pos: position{line: 351, col: 18, offset: 8666},
+				run: (*parser).callonArraySelector1,
+				expr: &seqExpr{
+					pos: position{line: 351, col: 18, offset: 8666},
+					exprs: []any{
+						&litMatcher{
+							pos:        position{line: 351, col: 18, offset: 8666},
+							val:        "[",
+							ignoreCase: false,
+							want:       "\"[\"",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 351, col: 22, offset: 8670},
+							name: "_",
+						},
+						&labeledExpr{
+							pos:   position{line: 351, col: 24, offset: 8672},
+							label: "sel",
+							expr: &choiceExpr{
+								pos: position{line: 351, col: 29, offset: 8677},
+								alternatives: []any{
+									&ruleRefExpr{
+										pos:  position{line: 351, col: 29, offset: 8677},
+										name: "SelectAll",
+									},
+									&ruleRefExpr{
+										pos:  position{line: 351, col: 41, offset: 8689},
+										name: "SelectSlice",
+									},
+									&ruleRefExpr{
+										pos:  position{line: 351, col: 55, offset: 8703},
+										name: "SelectIndex",
 									},
 								},
-								&labeledExpr{
-									pos:   position{line: 291, col: 31, offset: 6707},
-									label: "rest",
-									expr: &zeroOrMoreExpr{
-										pos: position{line: 291, col: 36, offset: 6712},
-										expr: &seqExpr{
-											pos: position{line: 291, col: 38, offset: 6714},
-											exprs: []interface{}{
-												&ruleRefExpr{
-													pos:  position{line: 291, col: 38, offset: 6714},
-													name: "_",
-												},
-												&litMatcher{
-													pos:        position{line: 291, col: 40, offset: 6716},
-													val:        ",",
-													ignoreCase: false,
-													want:       "\",\"",
-												},
-												&ruleRefExpr{
-													pos:  position{line: 291, col: 44, offset: 6720},
-													name: "_",
-												},
-												&ruleRefExpr{
-													pos:  position{line: 291, col: 46, offset: 6722},
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 351, col: 68, offset: 8716},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 351, col: 70, offset: 8718},
+							val:        "]",
+							ignoreCase: false,
+							want:       "\"]\"",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SelectAll",
+			pos:  position{line: 355, col: 1, offset: 8747},
+			expr: &actionExpr{
+				pos: position{line: 355, col: 14, offset: 8760},
+				run: (*parser).callonSelectAll1,
+				expr: &litMatcher{
+					pos:        position{line: 355, col: 14, offset: 8760},
+					val:        "*",
+					ignoreCase: false,
+					want:       "\"*\"",
+				},
+			},
+		},
+		{
+			name: "SelectSlice",
+			pos:  position{line: 361, col: 1, offset: 8838},
+			expr: &actionExpr{
+				pos: position{line: 361, col: 16, offset: 8853},
+				run: (*parser).callonSelectSlice1,
+				expr: &seqExpr{
+					pos: position{line: 361, col: 16, offset: 8853},
+					exprs: []any{
+						&labeledExpr{
+							pos:   position{line: 361, col: 16, offset: 8853},
+							label: "start",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 361, col: 22, offset: 8859},
+								expr: &ruleRefExpr{
+									pos:  position{line: 361, col: 22, offset: 8859},
+									name: "ArrayIndex",
+								},
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 361, col: 34, offset: 8871},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 361, col: 36, offset: 8873},
+							val:        ":",
+							ignoreCase: false,
+							want:       "\":\"",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 361, col: 40, offset: 8877},
+							name: "_",
+						},
+						&labeledExpr{
+							pos:   position{line: 361, col: 42, offset: 8879},
+							label: "end",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 361, col: 46, offset: 8883},
+								expr: &ruleRefExpr{
+									pos:  position{line: 361, col: 46, offset: 8883},
+									name: "ArrayIndex",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SelectIndex",
+			pos:  position{line: 374, col: 1, offset: 9138},
+			expr: &actionExpr{
+				pos: position{line: 374, col: 16, offset: 9153},
+				run: (*parser).callonSelectIndex1,
+				expr: &labeledExpr{
+					pos:   position{line: 374, col: 16, offset: 9153},
+					label: "idx",
+					expr: &ruleRefExpr{
+						pos:  position{line: 374, col: 20, offset: 9157},
+						name: "ArrayIndex",
+					},
+				},
+			},
+		},
+		{
+			name: "ArrayIndex",
+			pos:  position{line: 381, col: 1, offset: 9271},
+			expr: &actionExpr{
+				pos: position{line: 381, col: 15, offset: 9285},
+				run: (*parser).callonArrayIndex1,
+				expr: &seqExpr{
+					pos: position{line: 381, col: 15, offset: 9285},
+					exprs: []any{
+						&zeroOrOneExpr{
+							pos: position{line: 381, col: 15, offset: 9285},
+							expr: &litMatcher{
+								pos:        position{line: 381, col: 15, offset: 9285},
+								val:        "-",
+								ignoreCase: false,
+								want:       "\"-\"",
+							},
+						},
+						&oneOrMoreExpr{
+							pos: position{line: 381, col: 20, offset: 9290},
+							expr: &charClassMatcher{
+								pos:        position{line: 381, col: 20, offset: 9290},
+								val:        "[0-9]",
+								ranges:     []rune{'0', '9'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Range",
+			pos:  position{line: 396, col: 1, offset: 9638},
+			expr: &actionExpr{
+				pos: position{line: 396, col: 10, offset: 9647},
+				run: (*parser).callonRange1,
+				expr: &seqExpr{
+					pos: position{line: 396, col: 10, offset: 9647},
+					exprs: []any{
+						&labeledExpr{
+							pos:   position{line: 396, col: 10, offset: 9647},
+							label: "lo",
+							expr: &charClassMatcher{
+								pos:        position{line: 396, col: 13, offset: 9650},
+								val:        "[[{]",
+								chars:      []rune{'[', '{'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 396, col: 18, offset: 9655},
+							name: "_",
+						},
+						&labeledExpr{
+							pos:   position{line: 396, col: 20, offset: 9657},
+							label: "from",
+							expr: &ruleRefExpr{
+								pos:  position{line: 396, col: 25, offset: 9662},
+								name: "RangeEnd",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 396, col: 34, offset: 9671},
+							name: "space",
+						},
+						&litMatcher{
+							pos:        position{line: 396, col: 40, offset: 9677},
+							val:        "TO",
+							ignoreCase: false,
+							want:       "\"TO\"",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 396, col: 45, offset: 9682},
+							name: "space",
+						},
+						&labeledExpr{
+							pos:   position{line: 396, col: 51, offset: 9688},
+							label: "to",
+							expr: &ruleRefExpr{
+								pos:  position{line: 396, col: 54, offset: 9691},
+								name: "RangeEnd",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 396, col: 63, offset: 9700},
+							name: "_",
+						},
+						&labeledExpr{
+							pos:   position{line: 396, col: 65, offset: 9702},
+							label: "hi",
+							expr: &charClassMatcher{
+								pos:        position{line: 396, col: 68, offset: 9705},
+								val:        "[\\]}]",
+								chars:      []rune{']', '}'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RangeEnd",
+			pos:  position{line: 421, col: 1, offset: 10374},
+			expr: &choiceExpr{
+				pos: position{line: 421, col: 13, offset: 10386},
+				alternatives: []any{
+					&actionExpr{
+						pos: position{line: 421, col: 13, offset: 10386},
+						run: (*parser).callonRangeEnd2,
+						expr: &litMatcher{
+							pos:        position{line: 421, col: 13, offset: 10386},
+							val:        "*",
+							ignoreCase: false,
+							want:       "\"*\"",
+						},
+					},
+					&ruleRefExpr{
+						pos:  position{line: 423, col: 5, offset: 10416},
+						name: "Value",
+					},
+				},
+			},
+		},
+		{
+			name: "ValueList",
+			pos:  position{line: 425, col: 1, offset: 10423},
+			expr: &choiceExpr{
+				pos: position{line: 425, col: 14, offset: 10436},
+				alternatives: []any{
+					&actionExpr{
+						pos: position{line: 425, col: 14, offset: 10436},
+						run: (*parser).callonValueList2,
+						expr: &seqExpr{
+							pos: position{line: 425, col: 14, offset: 10436},
+							exprs: []any{
+								&litMatcher{
+									pos:        position{line: 425, col: 14, offset: 10436},
+									val:        "(",
+									ignoreCase: false,
+									want:       "\"(\"",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 425, col: 17, offset: 10439},
+									name: "_",
+								},
+								&labeledExpr{
+									pos:   position{line: 425, col: 19, offset: 10441},
+									label: "first",
+									expr: &ruleRefExpr{
+										pos:  position{line: 425, col: 25, offset: 10447},
+										name: "Value",
+									},
+								},
+								&labeledExpr{
+									pos:   position{line: 425, col: 31, offset: 10453},
+									label: "rest",
+									expr: &zeroOrMoreExpr{
+										pos: position{line: 425, col: 36, offset: 10458},
+										expr: &seqExpr{
+											pos: position{line: 425, col: 38, offset: 10460},
+											exprs: []any{
+												&ruleRefExpr{
+													pos:  position{line: 425, col: 38, offset: 10460},
+													name: "_",
+												},
+												&litMatcher{
+													pos:        position{line: 425, col: 40, offset: 10462},
+													val:        ",",
+													ignoreCase: false,
+													want:       "\",\"",
+												},
+												&ruleRefExpr{
+													pos:  position{line: 425, col: 44, offset: 10466},
+													name: "_",
+												},
+												&ruleRefExpr{
+													pos:  position{line: 425, col: 46, offset: 10468},
 													name: "Value",
 												},
 											},
@@ -685,11 +1248,11 @@ var g = &grammar{
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 291, col: 55, offset: 6731},
+									pos:  position{line: 425, col: 55, offset: 10477},
 									name: "_",
 								},
 								&litMatcher{
-									pos:        position{line: 291, col: 57, offset: 6733},
+									pos:        position{line: 425, col: 57, offset: 10479},
 									val:        ")",
 									ignoreCase: false,
 									want:       "\")\"",
@@ -698,13 +1261,13 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 302, col: 5, offset: 7036},
+						pos: position{line: 436, col: 5, offset: 10782},
 						run: (*parser).callonValueList17,
 						expr: &labeledExpr{
-							pos:   position{line: 302, col: 5, offset: 7036},
+							pos:   position{line: 436, col: 5, offset: 10782},
 							label: "value",
 							expr: &ruleRefExpr{
-								pos:  position{line: 302, col: 11, offset: 7042},
+								pos:  position{line: 436, col: 11, offset: 10788},
 								name: "Value",
 							},
 						},
@@ -714,29 +1277,33 @@ var g = &grammar{
 		},
 		{
 			name: "Value",
-			pos:  position{line: 306, col: 1, offset: 7096},
+			pos:  position{line: 440, col: 1, offset: 10842},
 			expr: &choiceExpr{
-				pos: position{line: 306, col: 10, offset: 7105},
-				alternatives: []interface{}{
+				pos: position{line: 440, col: 10, offset: 10851},
+				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 306, col: 10, offset: 7105},
+						pos: position{line: 440, col: 10, offset: 10851},
 						run: (*parser).callonValue2,
 						expr: &labeledExpr{
-							pos:   position{line: 306, col: 10, offset: 7105},
+							pos:   position{line: 440, col: 10, offset: 10851},
 							label: "val",
 							expr: &choiceExpr{
-								pos: position{line: 306, col: 15, offset: 7110},
-								alternatives: []interface{}{
+								pos: position{line: 440, col: 15, offset: 10856},
+								alternatives: []any{
 									&ruleRefExpr{
-										pos:  position{line: 306, col: 15, offset: 7110},
-										name: "QuotedValue",
+										pos:  position{line: 440, col: 15, offset: 10856},
+										name: "StringValue",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 306, col: 29, offset: 7124},
+										pos:  position{line: 440, col: 29, offset: 10870},
 										name: "RegexValue",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 306, col: 42, offset: 7137},
+										pos:  position{line: 440, col: 42, offset: 10883},
+										name: "FuncValue",
+									},
+									&ruleRefExpr{
+										pos:  position{line: 440, col: 54, offset: 10895},
 										name: "BareValue",
 									},
 								},
@@ -744,12 +1311,12 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 308, col: 5, offset: 7184},
-						run: (*parser).callonValue8,
+						pos: position{line: 442, col: 5, offset: 10942},
+						run: (*parser).callonValue9,
 						expr: &oneOrMoreExpr{
-							pos: position{line: 308, col: 5, offset: 7184},
+							pos: position{line: 442, col: 5, offset: 10942},
 							expr: &charClassMatcher{
-								pos:        position{line: 308, col: 5, offset: 7184},
+								pos:        position{line: 442, col: 5, offset: 10942},
 								val:        "[^ \\n\\t\\r]",
 								chars:      []rune{' ', '\n', '\t', '\r'},
 								ignoreCase: false,
@@ -762,52 +1329,52 @@ var g = &grammar{
 		},
 		{
 			name: "QuotedValue",
-			pos:  position{line: 319, col: 1, offset: 7446},
+			pos:  position{line: 453, col: 1, offset: 11204},
 			expr: &recoveryExpr{
-				pos: position{line: 319, col: 16, offset: 7461},
+				pos: position{line: 453, col: 16, offset: 11219},
 				expr: &actionExpr{
-					pos: position{line: 319, col: 16, offset: 7461},
+					pos: position{line: 453, col: 16, offset: 11219},
 					run: (*parser).callonQuotedValue2,
 					expr: &seqExpr{
-						pos: position{line: 319, col: 16, offset: 7461},
-						exprs: []interface{}{
+						pos: position{line: 453, col: 16, offset: 11219},
+						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 319, col: 16, offset: 7461},
+								pos:        position{line: 453, col: 16, offset: 11219},
 								val:        "\"",
 								ignoreCase: false,
 								want:       "\"\\\"\"",
 							},
 							&zeroOrMoreExpr{
-								pos: position{line: 319, col: 20, offset: 7465},
+								pos: position{line: 453, col: 20, offset: 11223},
 								expr: &choiceExpr{
-									pos: position{line: 319, col: 22, offset: 7467},
-									alternatives: []interface{}{
+									pos: position{line: 453, col: 22, offset: 11225},
+									alternatives: []any{
 										&seqExpr{
-											pos: position{line: 319, col: 22, offset: 7467},
-											exprs: []interface{}{
+											pos: position{line: 453, col: 22, offset: 11225},
+											exprs: []any{
 												&notExpr{
-													pos: position{line: 319, col: 22, offset: 7467},
+													pos: position{line: 453, col: 22, offset: 11225},
 													expr: &ruleRefExpr{
-														pos:  position{line: 319, col: 23, offset: 7468},
+														pos:  position{line: 453, col: 23, offset: 11226},
 														name: "EscapedChar",
 													},
 												},
 												&anyMatcher{
-													line: 319, col: 35, offset: 7480,
+													line: 453, col: 35, offset: 11238,
 												},
 											},
 										},
 										&seqExpr{
-											pos: position{line: 319, col: 39, offset: 7484},
-											exprs: []interface{}{
+											pos: position{line: 453, col: 39, offset: 11242},
+											exprs: []any{
 												&litMatcher{
-													pos:        position{line: 319, col: 39, offset: 7484},
+													pos:        position{line: 453, col: 39, offset: 11242},
 													val:        "\\",
 													ignoreCase: false,
 													want:       "\"\\\\\"",
 												},
 												&ruleRefExpr{
-													pos:  position{line: 319, col: 44, offset: 7489},
+													pos:  position{line: 453, col: 44, offset: 11247},
 													name: "EscapeSequence",
 												},
 											},
@@ -816,14 +1383,14 @@ var g = &grammar{
 								},
 							},
 							&ruleRefExpr{
-								pos:  position{line: 319, col: 62, offset: 7507},
+								pos:  position{line: 453, col: 62, offset: 11265},
 								name: "EndingQuote",
 							},
 						},
 					},
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 326, col: 20, offset: 7737},
+					pos:  position{line: 460, col: 20, offset: 11495},
 					name: "ErrUntermStr",
 				},
 				failureLabel: []string{
@@ -833,28 +1400,103 @@ var g = &grammar{
 		},
 		{
 			name: "EndingQuote",
-			pos:  position{line: 328, col: 1, offset: 7751},
+			pos:  position{line: 462, col: 1, offset: 11509},
 			expr: &choiceExpr{
-				pos: position{line: 328, col: 16, offset: 7766},
-				alternatives: []interface{}{
+				pos: position{line: 462, col: 16, offset: 11524},
+				alternatives: []any{
 					&litMatcher{
-						pos:        position{line: 328, col: 16, offset: 7766},
+						pos:        position{line: 462, col: 16, offset: 11524},
 						val:        "\"",
 						ignoreCase: false,
 						want:       "\"\\\"\"",
 					},
 					&throwExpr{
-						pos:   position{line: 328, col: 22, offset: 7772},
+						pos:   position{line: 462, col: 22, offset: 11530},
 						label: "errUntermStr",
 					},
 				},
 			},
 		},
+		{
+			name: "StringValue",
+			pos:  position{line: 466, col: 1, offset: 11676},
+			expr: &actionExpr{
+				pos: position{line: 466, col: 16, offset: 11691},
+				run: (*parser).callonStringValue1,
+				expr: &seqExpr{
+					pos: position{line: 466, col: 16, offset: 11691},
+					exprs: []any{
+						&labeledExpr{
+							pos:   position{line: 466, col: 16, offset: 11691},
+							label: "qv",
+							expr: &ruleRefExpr{
+								pos:  position{line: 466, col: 19, offset: 11694},
+								name: "QuotedValue",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 466, col: 31, offset: 11706},
+							label: "caseMod",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 466, col: 39, offset: 11714},
+								expr: &charClassMatcher{
+									pos:        position{line: 466, col: 39, offset: 11714},
+									val:        "[ci]",
+									chars:      []rune{'c', 'i'},
+									ignoreCase: false,
+									inverted:   false,
+								},
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 466, col: 45, offset: 11720},
+							label: "fuzzy",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 466, col: 51, offset: 11726},
+								expr: &ruleRefExpr{
+									pos:  position{line: 466, col: 51, offset: 11726},
+									name: "Fuzziness",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Fuzziness",
+			pos:  position{line: 482, col: 1, offset: 12060},
+			expr: &actionExpr{
+				pos: position{line: 482, col: 14, offset: 12073},
+				run: (*parser).callonFuzziness1,
+				expr: &seqExpr{
+					pos: position{line: 482, col: 14, offset: 12073},
+					exprs: []any{
+						&litMatcher{
+							pos:        position{line: 482, col: 14, offset: 12073},
+							val:        "~",
+							ignoreCase: false,
+							want:       "\"~\"",
+						},
+						&zeroOrMoreExpr{
+							pos: position{line: 482, col: 18, offset: 12077},
+							expr: &charClassMatcher{
+								pos:        position{line: 482, col: 18, offset: 12077},
+								val:        "[0-9]",
+								ranges:     []rune{'0', '9'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "EscapedChar",
-			pos:  position{line: 330, col: 1, offset: 7789},
+			pos:  position{line: 494, col: 1, offset: 12348},
 			expr: &charClassMatcher{
-				pos:        position{line: 330, col: 16, offset: 7804},
+				pos:        position{line: 494, col: 16, offset: 12363},
 				val:        "[\\x00-\\x1f\"\\\\]",
 				chars:      []rune{'"', '\\'},
 				ranges:     []rune{'\x00', '\x1f'},
@@ -864,16 +1506,16 @@ var g = &grammar{
 		},
 		{
 			name: "EscapeSequence",
-			pos:  position{line: 332, col: 1, offset: 7820},
+			pos:  position{line: 496, col: 1, offset: 12379},
 			expr: &choiceExpr{
-				pos: position{line: 332, col: 19, offset: 7838},
-				alternatives: []interface{}{
+				pos: position{line: 496, col: 19, offset: 12397},
+				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 332, col: 19, offset: 7838},
+						pos:  position{line: 496, col: 19, offset: 12397},
 						name: "SingleCharEscape",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 332, col: 38, offset: 7857},
+						pos:  position{line: 496, col: 38, offset: 12416},
 						name: "UnicodeEscape",
 					},
 				},
@@ -881,9 +1523,9 @@ var g = &grammar{
 		},
 		{
 			name: "SingleCharEscape",
-			pos:  position{line: 334, col: 1, offset: 7872},
+			pos:  position{line: 498, col: 1, offset: 12431},
 			expr: &charClassMatcher{
-				pos:        position{line: 334, col: 21, offset: 7892},
+				pos:        position{line: 498, col: 21, offset: 12451},
 				val:        "[\"\\\\bfnrt]",
 				chars:      []rune{'"', '\\', 'b', 'f', 'n', 'r', 't'},
 				ignoreCase: false,
@@ -892,30 +1534,30 @@ var g = &grammar{
 		},
 		{
 			name: "UnicodeEscape",
-			pos:  position{line: 336, col: 1, offset: 7904},
+			pos:  position{line: 500, col: 1, offset: 12463},
 			expr: &seqExpr{
-				pos: position{line: 336, col: 18, offset: 7921},
-				exprs: []interface{}{
+				pos: position{line: 500, col: 18, offset: 12480},
+				exprs: []any{
 					&litMatcher{
-						pos:        position{line: 336, col: 18, offset: 7921},
+						pos:        position{line: 500, col: 18, offset: 12480},
 						val:        "u",
 						ignoreCase: false,
 						want:       "\"u\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 336, col: 22, offset: 7925},
+						pos:  position{line: 500, col: 22, offset: 12484},
 						name: "HexDigit",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 336, col: 31, offset: 7934},
+						pos:  position{line: 500, col: 31, offset: 12493},
 						name: "HexDigit",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 336, col: 40, offset: 7943},
+						pos:  position{line: 500, col: 40, offset: 12502},
 						name: "HexDigit",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 336, col: 49, offset: 7952},
+						pos:  position{line: 500, col: 49, offset: 12511},
 						name: "HexDigit",
 					},
 				},
@@ -923,9 +1565,9 @@ var g = &grammar{
 		},
 		{
 			name: "HexDigit",
-			pos:  position{line: 338, col: 1, offset: 7962},
+			pos:  position{line: 502, col: 1, offset: 12521},
 			expr: &charClassMatcher{
-				pos:        position{line: 338, col: 13, offset: 7974},
+				pos:        position{line: 502, col: 13, offset: 12533},
 				val:        "[0-9a-f]i",
 				ranges:     []rune{'0', '9', 'a', 'f'},
 				ignoreCase: true,
@@ -934,9 +1576,9 @@ var g = &grammar{
 		},
 		{
 			name: "ValueChars",
-			pos:  position{line: 340, col: 1, offset: 7985},
+			pos:  position{line: 504, col: 1, offset: 12544},
 			expr: &charClassMatcher{
-				pos:        position{line: 340, col: 15, offset: 7999},
+				pos:        position{line: 504, col: 15, offset: 12558},
 				val:        "[a-zA-Z0-9 !]",
 				chars:      []rune{' ', '!'},
 				ranges:     []rune{'a', 'z', 'A', 'Z', '0', '9'},
@@ -946,37 +1588,37 @@ var g = &grammar{
 		},
 		{
 			name: "RegexValue",
-			pos:  position{line: 342, col: 1, offset: 8014},
+			pos:  position{line: 506, col: 1, offset: 12573},
 			expr: &recoveryExpr{
-				pos: position{line: 342, col: 15, offset: 8028},
+				pos: position{line: 506, col: 15, offset: 12587},
 				expr: &actionExpr{
-					pos: position{line: 342, col: 15, offset: 8028},
+					pos: position{line: 506, col: 15, offset: 12587},
 					run: (*parser).callonRegexValue2,
 					expr: &seqExpr{
-						pos: position{line: 342, col: 15, offset: 8028},
-						exprs: []interface{}{
+						pos: position{line: 506, col: 15, offset: 12587},
+						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 342, col: 15, offset: 8028},
+								pos:        position{line: 506, col: 15, offset: 12587},
 								val:        "/",
 								ignoreCase: false,
 								want:       "\"/\"",
 							},
 							&zeroOrMoreExpr{
-								pos: position{line: 342, col: 19, offset: 8032},
+								pos: position{line: 506, col: 19, offset: 12591},
 								expr: &ruleRefExpr{
-									pos:  position{line: 342, col: 19, offset: 8032},
+									pos:  position{line: 506, col: 19, offset: 12591},
 									name: "RegexChar",
 								},
 							},
 							&ruleRefExpr{
-								pos:  position{line: 342, col: 30, offset: 8043},
+								pos:  position{line: 506, col: 30, offset: 12602},
 								name: "EndingSlash",
 							},
 						},
 					},
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 350, col: 22, offset: 8294},
+					pos:  position{line: 514, col: 22, offset: 12853},
 					name: "ErrUntermRegex",
 				},
 				failureLabel: []string{
@@ -986,53 +1628,53 @@ var g = &grammar{
 		},
 		{
 			name: "RegexChar",
-			pos:  position{line: 351, col: 1, offset: 8309},
+			pos:  position{line: 515, col: 1, offset: 12868},
 			expr: &choiceExpr{
-				pos: position{line: 351, col: 14, offset: 8322},
-				alternatives: []interface{}{
+				pos: position{line: 515, col: 14, offset: 12881},
+				alternatives: []any{
 					&seqExpr{
-						pos: position{line: 351, col: 14, offset: 8322},
-						exprs: []interface{}{
+						pos: position{line: 515, col: 14, offset: 12881},
+						exprs: []any{
 							&notExpr{
-								pos: position{line: 351, col: 14, offset: 8322},
+								pos: position{line: 515, col: 14, offset: 12881},
 								expr: &choiceExpr{
-									pos: position{line: 351, col: 17, offset: 8325},
-									alternatives: []interface{}{
+									pos: position{line: 515, col: 17, offset: 12884},
+									alternatives: []any{
 										&litMatcher{
-											pos:        position{line: 351, col: 17, offset: 8325},
+											pos:        position{line: 515, col: 17, offset: 12884},
 											val:        "/",
 											ignoreCase: false,
 											want:       "\"/\"",
 										},
 										&litMatcher{
-											pos:        position{line: 351, col: 23, offset: 8331},
+											pos:        position{line: 515, col: 23, offset: 12890},
 											val:        "\\",
 											ignoreCase: false,
 											want:       "\"\\\\\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 351, col: 30, offset: 8338},
+											pos:  position{line: 515, col: 30, offset: 12897},
 											name: "EOL",
 										},
 									},
 								},
 							},
 							&anyMatcher{
-								line: 351, col: 35, offset: 8343,
+								line: 515, col: 35, offset: 12902,
 							},
 						},
 					},
 					&seqExpr{
-						pos: position{line: 351, col: 39, offset: 8347},
-						exprs: []interface{}{
+						pos: position{line: 515, col: 39, offset: 12906},
+						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 351, col: 39, offset: 8347},
+								pos:        position{line: 515, col: 39, offset: 12906},
 								val:        "\\",
 								ignoreCase: false,
 								want:       "\"\\\\\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 351, col: 44, offset: 8352},
+								pos:  position{line: 515, col: 44, offset: 12911},
 								name: "RegexEscape",
 							},
 						},
@@ -1042,75 +1684,253 @@ var g = &grammar{
 		},
 		{
 			name: "RegexEscape",
-			pos:  position{line: 352, col: 1, offset: 8364},
+			pos:  position{line: 516, col: 1, offset: 12923},
 			expr: &seqExpr{
-				pos: position{line: 352, col: 16, offset: 8379},
-				exprs: []interface{}{
+				pos: position{line: 516, col: 16, offset: 12938},
+				exprs: []any{
 					&notExpr{
-						pos: position{line: 352, col: 16, offset: 8379},
+						pos: position{line: 516, col: 16, offset: 12938},
 						expr: &choiceExpr{
-							pos: position{line: 352, col: 18, offset: 8381},
-							alternatives: []interface{}{
+							pos: position{line: 516, col: 18, offset: 12940},
+							alternatives: []any{
 								&ruleRefExpr{
-									pos:  position{line: 352, col: 18, offset: 8381},
+									pos:  position{line: 516, col: 18, offset: 12940},
 									name: "EOL",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 352, col: 24, offset: 8387},
+									pos:  position{line: 516, col: 24, offset: 12946},
 									name: "EOF",
 								},
 							},
 						},
 					},
 					&anyMatcher{
-						line: 352, col: 30, offset: 8393,
+						line: 516, col: 30, offset: 12952,
 					},
 				},
 			},
 		},
 		{
 			name: "EndingSlash",
-			pos:  position{line: 354, col: 1, offset: 8396},
+			pos:  position{line: 518, col: 1, offset: 12955},
 			expr: &choiceExpr{
-				pos: position{line: 354, col: 16, offset: 8411},
-				alternatives: []interface{}{
+				pos: position{line: 518, col: 16, offset: 12970},
+				alternatives: []any{
 					&litMatcher{
-						pos:        position{line: 354, col: 16, offset: 8411},
+						pos:        position{line: 518, col: 16, offset: 12970},
 						val:        "/",
 						ignoreCase: false,
 						want:       "\"/\"",
 					},
 					&throwExpr{
-						pos:   position{line: 354, col: 22, offset: 8417},
+						pos:   position{line: 518, col: 22, offset: 12976},
 						label: "errUntermRegex",
 					},
 				},
 			},
 		},
+		{
+			name: "FuncValue",
+			pos:  position{line: 521, col: 1, offset: 13050},
+			expr: &choiceExpr{
+				pos: position{line: 521, col: 14, offset: 13063},
+				alternatives: []any{
+					&actionExpr{
+						pos: position{line: 521, col: 14, offset: 13063},
+						run: (*parser).callonFuncValue2,
+						expr: &seqExpr{
+							pos: position{line: 521, col: 14, offset: 13063},
+							exprs: []any{
+								&labeledExpr{
+									pos:   position{line: 521, col: 14, offset: 13063},
+									label: "name",
+									expr: &ruleRefExpr{
+										pos:  position{line: 521, col: 19, offset: 13068},
+										name: "FuncName",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 521, col: 28, offset: 13077},
+									name: "_",
+								},
+								&litMatcher{
+									pos:        position{line: 521, col: 30, offset: 13079},
+									val:        "(",
+									ignoreCase: false,
+									want:       "\"(\"",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 521, col: 34, offset: 13083},
+									name: "_",
+								},
+								&litMatcher{
+									pos:        position{line: 521, col: 36, offset: 13085},
+									val:        ")",
+									ignoreCase: false,
+									want:       "\")\"",
+								},
+							},
+						},
+					},
+					&actionExpr{
+						pos: position{line: 523, col: 5, offset: 13152},
+						run: (*parser).callonFuncValue10,
+						expr: &seqExpr{
+							pos: position{line: 523, col: 5, offset: 13152},
+							exprs: []any{
+								&labeledExpr{
+									pos:   position{line: 523, col: 5, offset: 13152},
+									label: "name",
+									expr: &ruleRefExpr{
+										pos:  position{line: 523, col: 10, offset: 13157},
+										name: "FuncName",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 523, col: 19, offset: 13166},
+									name: "_",
+								},
+								&litMatcher{
+									pos:        position{line: 523, col: 21, offset: 13168},
+									val:        "(",
+									ignoreCase: false,
+									want:       "\"(\"",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 523, col: 25, offset: 13172},
+									name: "_",
+								},
+								&labeledExpr{
+									pos:   position{line: 523, col: 27, offset: 13174},
+									label: "args",
+									expr: &ruleRefExpr{
+										pos:  position{line: 523, col: 32, offset: 13179},
+										name: "FuncArgs",
+									},
+								},
+								&ruleRefExpr{
+									pos:  position{line: 523, col: 41, offset: 13188},
+									name: "_",
+								},
+								&litMatcher{
+									pos:        position{line: 523, col: 43, offset: 13190},
+									val:        ")",
+									ignoreCase: false,
+									want:       "\")\"",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "FuncName",
+			pos:  position{line: 527, col: 1, offset: 13269},
+			expr: &actionExpr{
+				pos: position{line: 527, col: 13, offset: 13281},
+				run: (*parser).callonFuncName1,
+				expr: &seqExpr{
+					pos: position{line: 527, col: 13, offset: 13281},
+					exprs: []any{
+						&charClassMatcher{
+							pos:        position{line: 527, col: 13, offset: 13281},
+							val:        "[a-z_]i",
+							chars:      []rune{'_'},
+							ranges:     []rune{'a', 'z'},
+							ignoreCase: true,
+							inverted:   false,
+						},
+						&zeroOrMoreExpr{
+							pos: position{line: 527, col: 21, offset: 13289},
+							expr: &charClassMatcher{
+								pos:        position{line: 527, col: 21, offset: 13289},
+								val:        "[a-z0-9_]i",
+								chars:      []rune{'_'},
+								ranges:     []rune{'a', 'z', '0', '9'},
+								ignoreCase: true,
+								inverted:   false,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "FuncArgs",
+			pos:  position{line: 531, col: 1, offset: 13337},
+			expr: &actionExpr{
+				pos: position{line: 531, col: 13, offset: 13349},
+				run: (*parser).callonFuncArgs1,
+				expr: &seqExpr{
+					pos: position{line: 531, col: 13, offset: 13349},
+					exprs: []any{
+						&labeledExpr{
+							pos:   position{line: 531, col: 13, offset: 13349},
+							label: "first",
+							expr: &ruleRefExpr{
+								pos:  position{line: 531, col: 19, offset: 13355},
+								name: "Value",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 531, col: 25, offset: 13361},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 531, col: 30, offset: 13366},
+								expr: &seqExpr{
+									pos: position{line: 531, col: 32, offset: 13368},
+									exprs: []any{
+										&ruleRefExpr{
+											pos:  position{line: 531, col: 32, offset: 13368},
+											name: "_",
+										},
+										&litMatcher{
+											pos:        position{line: 531, col: 34, offset: 13370},
+											val:        ",",
+											ignoreCase: false,
+											want:       "\",\"",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 531, col: 38, offset: 13374},
+											name: "_",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 531, col: 40, offset: 13376},
+											name: "Value",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "BareValue",
-			pos:  position{line: 358, col: 1, offset: 8535},
+			pos:  position{line: 541, col: 1, offset: 13638},
 			expr: &choiceExpr{
-				pos: position{line: 358, col: 15, offset: 8549},
-				alternatives: []interface{}{
+				pos: position{line: 541, col: 15, offset: 13652},
+				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 358, col: 15, offset: 8549},
+						pos:  position{line: 541, col: 15, offset: 13652},
 						name: "Timestamp",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 359, col: 15, offset: 8573},
+						pos:  position{line: 542, col: 15, offset: 13676},
 						name: "IPValue",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 360, col: 15, offset: 8595},
+						pos:  position{line: 543, col: 15, offset: 13698},
 						name: "FloatValue",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 361, col: 15, offset: 8620},
+						pos:  position{line: 544, col: 15, offset: 13723},
 						name: "IntValue",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 362, col: 15, offset: 8643},
+						pos:  position{line: 545, col: 15, offset: 13746},
 						name: "BoolValue",
 					},
 				},
@@ -1118,21 +1938,21 @@ var g = &grammar{
 		},
 		{
 			name: "BoolValue",
-			pos:  position{line: 365, col: 1, offset: 8655},
+			pos:  position{line: 548, col: 1, offset: 13758},
 			expr: &actionExpr{
-				pos: position{line: 365, col: 14, offset: 8668},
+				pos: position{line: 548, col: 14, offset: 13771},
 				run: (*parser).callonBoolValue1,
 				expr: &choiceExpr{
-					pos: position{line: 365, col: 15, offset: 8669},
-					alternatives: []interface{}{
+					pos: position{line: 548, col: 15, offset: 13772},
+					alternatives: []any{
 						&litMatcher{
-							pos:        position{line: 365, col: 15, offset: 8669},
+							pos:        position{line: 548, col: 15, offset: 13772},
 							val:        "false",
 							ignoreCase: false,
 							want:       "\"false\"",
 						},
 						&litMatcher{
-							pos:        position{line: 365, col: 25, offset: 8679},
+							pos:        position{line: 548, col: 25, offset: 13782},
 							val:        "true",
 							ignoreCase: false,
 							want:       "\"true\"",
@@ -1143,26 +1963,26 @@ var g = &grammar{
 		},
 		{
 			name: "FloatValue",
-			pos:  position{line: 369, col: 1, offset: 8736},
+			pos:  position{line: 552, col: 1, offset: 13839},
 			expr: &actionExpr{
-				pos: position{line: 369, col: 15, offset: 8750},
+				pos: position{line: 552, col: 15, offset: 13853},
 				run: (*parser).callonFloatValue1,
 				expr: &seqExpr{
-					pos: position{line: 369, col: 15, offset: 8750},
-					exprs: []interface{}{
+					pos: position{line: 552, col: 15, offset: 13853},
+					exprs: []any{
 						&zeroOrOneExpr{
-							pos: position{line: 369, col: 15, offset: 8750},
+							pos: position{line: 552, col: 15, offset: 13853},
 							expr: &litMatcher{
-								pos:        position{line: 369, col: 15, offset: 8750},
+								pos:        position{line: 552, col: 15, offset: 13853},
 								val:        "-",
 								ignoreCase: false,
 								want:       "\"-\"",
 							},
 						},
 						&oneOrMoreExpr{
-							pos: position{line: 369, col: 20, offset: 8755},
+							pos: position{line: 552, col: 20, offset: 13858},
 							expr: &charClassMatcher{
-								pos:        position{line: 369, col: 20, offset: 8755},
+								pos:        position{line: 552, col: 20, offset: 13858},
 								val:        "[0-9]",
 								ranges:     []rune{'0', '9'},
 								ignoreCase: false,
@@ -1170,51 +1990,286 @@ var g = &grammar{
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 369, col: 27, offset: 8762},
+							pos:        position{line: 552, col: 27, offset: 13865},
 							val:        ".",
 							ignoreCase: false,
-							want:       "\".\"",
+							want:       "\".\"",
+						},
+						&oneOrMoreExpr{
+							pos: position{line: 552, col: 31, offset: 13869},
+							expr: &charClassMatcher{
+								pos:        position{line: 552, col: 31, offset: 13869},
+								val:        "[0-9]",
+								ranges:     []rune{'0', '9'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "IntValue",
+			pos:  position{line: 561, col: 1, offset: 14037},
+			expr: &actionExpr{
+				pos: position{line: 561, col: 13, offset: 14049},
+				run: (*parser).callonIntValue1,
+				expr: &seqExpr{
+					pos: position{line: 561, col: 13, offset: 14049},
+					exprs: []any{
+						&zeroOrOneExpr{
+							pos: position{line: 561, col: 13, offset: 14049},
+							expr: &litMatcher{
+								pos:        position{line: 561, col: 13, offset: 14049},
+								val:        "-",
+								ignoreCase: false,
+								want:       "\"-\"",
+							},
+						},
+						&oneOrMoreExpr{
+							pos: position{line: 561, col: 18, offset: 14054},
+							expr: &charClassMatcher{
+								pos:        position{line: 561, col: 18, offset: 14054},
+								val:        "[0-9]",
+								ranges:     []rune{'0', '9'},
+								ignoreCase: false,
+								inverted:   false,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "IPValue",
+			pos:  position{line: 566, col: 1, offset: 14111},
+			expr: &actionExpr{
+				pos: position{line: 566, col: 12, offset: 14122},
+				run: (*parser).callonIPValue1,
+				expr: &choiceExpr{
+					pos: position{line: 566, col: 14, offset: 14124},
+					alternatives: []any{
+						&seqExpr{
+							pos: position{line: 566, col: 14, offset: 14124},
+							exprs: []any{
+								&litMatcher{
+									pos:        position{line: 566, col: 14, offset: 14124},
+									val:        "[",
+									ignoreCase: false,
+									want:       "\"[\"",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 566, col: 18, offset: 14128},
+									name: "IPv6",
+								},
+								&zeroOrOneExpr{
+									pos: position{line: 566, col: 23, offset: 14133},
+									expr: &ruleRefExpr{
+										pos:  position{line: 566, col: 23, offset: 14133},
+										name: "CIDRBlock",
+									},
+								},
+								&litMatcher{
+									pos:        position{line: 566, col: 34, offset: 14144},
+									val:        "]",
+									ignoreCase: false,
+									want:       "\"]\"",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 566, col: 38, offset: 14148},
+									name: "Port",
+								},
+							},
+						},
+						&seqExpr{
+							pos: position{line: 566, col: 45, offset: 14155},
+							exprs: []any{
+								&ruleRefExpr{
+									pos:  position{line: 566, col: 45, offset: 14155},
+									name: "IPv6",
+								},
+								&zeroOrOneExpr{
+									pos: position{line: 566, col: 50, offset: 14160},
+									expr: &ruleRefExpr{
+										pos:  position{line: 566, col: 50, offset: 14160},
+										name: "CIDRBlock",
+									},
+								},
+							},
+						},
+						&seqExpr{
+							pos: position{line: 566, col: 63, offset: 14173},
+							exprs: []any{
+								&ruleRefExpr{
+									pos:  position{line: 566, col: 63, offset: 14173},
+									name: "IPv4",
+								},
+								&zeroOrOneExpr{
+									pos: position{line: 566, col: 68, offset: 14178},
+									expr: &ruleRefExpr{
+										pos:  position{line: 566, col: 68, offset: 14178},
+										name: "CIDRBlock",
+									},
+								},
+								&zeroOrOneExpr{
+									pos: position{line: 566, col: 79, offset: 14189},
+									expr: &ruleRefExpr{
+										pos:  position{line: 566, col: 79, offset: 14189},
+										name: "Port",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "IPv4",
+			pos:  position{line: 575, col: 1, offset: 14348},
+			expr: &seqExpr{
+				pos: position{line: 575, col: 9, offset: 14356},
+				exprs: []any{
+					&ruleRefExpr{
+						pos:  position{line: 575, col: 9, offset: 14356},
+						name: "Octet",
+					},
+					&litMatcher{
+						pos:        position{line: 575, col: 15, offset: 14362},
+						val:        ".",
+						ignoreCase: false,
+						want:       "\".\"",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 575, col: 19, offset: 14366},
+						name: "Octet",
+					},
+					&litMatcher{
+						pos:        position{line: 575, col: 25, offset: 14372},
+						val:        ".",
+						ignoreCase: false,
+						want:       "\".\"",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 575, col: 29, offset: 14376},
+						name: "Octet",
+					},
+					&litMatcher{
+						pos:        position{line: 575, col: 35, offset: 14382},
+						val:        ".",
+						ignoreCase: false,
+						want:       "\".\"",
+					},
+					&ruleRefExpr{
+						pos:  position{line: 575, col: 39, offset: 14386},
+						name: "Octet",
+					},
+				},
+			},
+		},
+		{
+			name: "Octet",
+			pos:  position{line: 577, col: 1, offset: 14393},
+			expr: &seqExpr{
+				pos: position{line: 577, col: 10, offset: 14402},
+				exprs: []any{
+					&charClassMatcher{
+						pos:        position{line: 577, col: 10, offset: 14402},
+						val:        "[0-9]",
+						ranges:     []rune{'0', '9'},
+						ignoreCase: false,
+						inverted:   false,
+					},
+					&zeroOrOneExpr{
+						pos: position{line: 577, col: 15, offset: 14407},
+						expr: &charClassMatcher{
+							pos:        position{line: 577, col: 15, offset: 14407},
+							val:        "[0-9]",
+							ranges:     []rune{'0', '9'},
+							ignoreCase: false,
+							inverted:   false,
 						},
-						&oneOrMoreExpr{
-							pos: position{line: 369, col: 31, offset: 8766},
-							expr: &charClassMatcher{
-								pos:        position{line: 369, col: 31, offset: 8766},
-								val:        "[0-9]",
-								ranges:     []rune{'0', '9'},
-								ignoreCase: false,
-								inverted:   false,
-							},
+					},
+					&zeroOrOneExpr{
+						pos: position{line: 577, col: 21, offset: 14413},
+						expr: &charClassMatcher{
+							pos:        position{line: 577, col: 21, offset: 14413},
+							val:        "[0-9]",
+							ranges:     []rune{'0', '9'},
+							ignoreCase: false,
+							inverted:   false,
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "IntValue",
-			pos:  position{line: 378, col: 1, offset: 8934},
-			expr: &actionExpr{
-				pos: position{line: 378, col: 13, offset: 8946},
-				run: (*parser).callonIntValue1,
-				expr: &seqExpr{
-					pos: position{line: 378, col: 13, offset: 8946},
-					exprs: []interface{}{
-						&zeroOrOneExpr{
-							pos: position{line: 378, col: 13, offset: 8946},
-							expr: &litMatcher{
-								pos:        position{line: 378, col: 13, offset: 8946},
-								val:        "-",
-								ignoreCase: false,
-								want:       "\"-\"",
+			name: "IPv6",
+			pos:  position{line: 581, col: 1, offset: 14548},
+			expr: &seqExpr{
+				pos: position{line: 581, col: 9, offset: 14556},
+				exprs: []any{
+					&zeroOrOneExpr{
+						pos: position{line: 581, col: 9, offset: 14556},
+						expr: &ruleRefExpr{
+							pos:  position{line: 581, col: 9, offset: 14556},
+							name: "Hextet",
+						},
+					},
+					&litMatcher{
+						pos:        position{line: 581, col: 17, offset: 14564},
+						val:        ":",
+						ignoreCase: false,
+						want:       "\":\"",
+					},
+					&zeroOrOneExpr{
+						pos: position{line: 581, col: 21, offset: 14568},
+						expr: &ruleRefExpr{
+							pos:  position{line: 581, col: 21, offset: 14568},
+							name: "Hextet",
+						},
+					},
+					&litMatcher{
+						pos:        position{line: 581, col: 29, offset: 14576},
+						val:        ":",
+						ignoreCase: false,
+						want:       "\":\"",
+					},
+					&zeroOrMoreExpr{
+						pos: position{line: 581, col: 33, offset: 14580},
+						expr: &seqExpr{
+							pos: position{line: 581, col: 35, offset: 14582},
+							exprs: []any{
+								&zeroOrOneExpr{
+									pos: position{line: 581, col: 35, offset: 14582},
+									expr: &ruleRefExpr{
+										pos:  position{line: 581, col: 35, offset: 14582},
+										name: "Hextet",
+									},
+								},
+								&litMatcher{
+									pos:        position{line: 581, col: 43, offset: 14590},
+									val:        ":",
+									ignoreCase: false,
+									want:       "\":\"",
+								},
 							},
 						},
-						&oneOrMoreExpr{
-							pos: position{line: 378, col: 18, offset: 8951},
-							expr: &charClassMatcher{
-								pos:        position{line: 378, col: 18, offset: 8951},
-								val:        "[0-9]",
-								ranges:     []rune{'0', '9'},
-								ignoreCase: false,
-								inverted:   false,
+					},
+					&zeroOrOneExpr{
+						pos: position{line: 581, col: 50, offset: 14597},
+						expr: &choiceExpr{
+							pos: position{line: 581, col: 52, offset: 14599},
+							alternatives: []any{
+								&ruleRefExpr{
+									pos:  position{line: 581, col: 52, offset: 14599},
+									name: "IPv4",
+								},
+								&ruleRefExpr{
+									pos:  position{line: 581, col: 59, offset: 14606},
+									name: "Hextet",
+								},
 							},
 						},
 					},
@@ -1222,76 +2277,74 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "IPValue",
-			pos:  position{line: 383, col: 1, offset: 9008},
-			expr: &actionExpr{
-				pos: position{line: 383, col: 12, offset: 9019},
-				run: (*parser).callonIPValue1,
-				expr: &seqExpr{
-					pos: position{line: 383, col: 12, offset: 9019},
-					exprs: []interface{}{
-						&ruleRefExpr{
-							pos:  position{line: 383, col: 12, offset: 9019},
-							name: "Octet",
-						},
-						&litMatcher{
-							pos:        position{line: 383, col: 18, offset: 9025},
-							val:        ".",
-							ignoreCase: false,
-							want:       "\".\"",
-						},
-						&ruleRefExpr{
-							pos:  position{line: 383, col: 22, offset: 9029},
-							name: "Octet",
-						},
-						&litMatcher{
-							pos:        position{line: 383, col: 28, offset: 9035},
-							val:        ".",
-							ignoreCase: false,
-							want:       "\".\"",
-						},
-						&ruleRefExpr{
-							pos:  position{line: 383, col: 32, offset: 9039},
-							name: "Octet",
-						},
-						&litMatcher{
-							pos:        position{line: 383, col: 38, offset: 9045},
-							val:        ".",
-							ignoreCase: false,
-							want:       "\".\"",
+			name: "Hextet",
+			pos:  position{line: 583, col: 1, offset: 14617},
+			expr: &seqExpr{
+				pos: position{line: 583, col: 11, offset: 14627},
+				exprs: []any{
+					&charClassMatcher{
+						pos:        position{line: 583, col: 11, offset: 14627},
+						val:        "[0-9a-f]i",
+						ranges:     []rune{'0', '9', 'a', 'f'},
+						ignoreCase: true,
+						inverted:   false,
+					},
+					&zeroOrOneExpr{
+						pos: position{line: 583, col: 21, offset: 14637},
+						expr: &charClassMatcher{
+							pos:        position{line: 583, col: 21, offset: 14637},
+							val:        "[0-9a-f]i",
+							ranges:     []rune{'0', '9', 'a', 'f'},
+							ignoreCase: true,
+							inverted:   false,
 						},
-						&ruleRefExpr{
-							pos:  position{line: 383, col: 42, offset: 9049},
-							name: "Octet",
+					},
+					&zeroOrOneExpr{
+						pos: position{line: 583, col: 32, offset: 14648},
+						expr: &charClassMatcher{
+							pos:        position{line: 583, col: 32, offset: 14648},
+							val:        "[0-9a-f]i",
+							ranges:     []rune{'0', '9', 'a', 'f'},
+							ignoreCase: true,
+							inverted:   false,
 						},
-						&zeroOrOneExpr{
-							pos: position{line: 383, col: 48, offset: 9055},
-							expr: &ruleRefExpr{
-								pos:  position{line: 383, col: 48, offset: 9055},
-								name: "CIDRBlock",
-							},
+					},
+					&zeroOrOneExpr{
+						pos: position{line: 583, col: 43, offset: 14659},
+						expr: &charClassMatcher{
+							pos:        position{line: 583, col: 43, offset: 14659},
+							val:        "[0-9a-f]i",
+							ranges:     []rune{'0', '9', 'a', 'f'},
+							ignoreCase: true,
+							inverted:   false,
 						},
 					},
 				},
 			},
 		},
 		{
-			name: "Octet",
-			pos:  position{line: 392, col: 1, offset: 9217},
+			name: "CIDRBlock",
+			pos:  position{line: 585, col: 1, offset: 14671},
 			expr: &seqExpr{
-				pos: position{line: 392, col: 10, offset: 9226},
-				exprs: []interface{}{
+				pos: position{line: 585, col: 14, offset: 14684},
+				exprs: []any{
+					&litMatcher{
+						pos:        position{line: 585, col: 14, offset: 14684},
+						val:        "/",
+						ignoreCase: false,
+						want:       "\"/\"",
+					},
 					&charClassMatcher{
-						pos:        position{line: 392, col: 10, offset: 9226},
+						pos:        position{line: 585, col: 18, offset: 14688},
 						val:        "[0-9]",
 						ranges:     []rune{'0', '9'},
 						ignoreCase: false,
 						inverted:   false,
 					},
 					&zeroOrOneExpr{
-						pos: position{line: 392, col: 15, offset: 9231},
+						pos: position{line: 585, col: 23, offset: 14693},
 						expr: &charClassMatcher{
-							pos:        position{line: 392, col: 15, offset: 9231},
+							pos:        position{line: 585, col: 23, offset: 14693},
 							val:        "[0-9]",
 							ranges:     []rune{'0', '9'},
 							ignoreCase: false,
@@ -1299,9 +2352,9 @@ var g = &grammar{
 						},
 					},
 					&zeroOrOneExpr{
-						pos: position{line: 392, col: 21, offset: 9237},
+						pos: position{line: 585, col: 29, offset: 14699},
 						expr: &charClassMatcher{
-							pos:        position{line: 392, col: 21, offset: 9237},
+							pos:        position{line: 585, col: 29, offset: 14699},
 							val:        "[0-9]",
 							ranges:     []rune{'0', '9'},
 							ignoreCase: false,
@@ -1312,53 +2365,202 @@ var g = &grammar{
 			},
 		},
 		{
-			name: "CIDRBlock",
-			pos:  position{line: 394, col: 1, offset: 9245},
+			name: "Port",
+			pos:  position{line: 588, col: 1, offset: 14763},
 			expr: &seqExpr{
-				pos: position{line: 394, col: 14, offset: 9258},
-				exprs: []interface{}{
+				pos: position{line: 588, col: 9, offset: 14771},
+				exprs: []any{
 					&litMatcher{
-						pos:        position{line: 394, col: 14, offset: 9258},
-						val:        "/",
-						ignoreCase: false,
-						want:       "\"/\"",
-					},
-					&charClassMatcher{
-						pos:        position{line: 394, col: 18, offset: 9262},
-						val:        "[0-9]",
-						ranges:     []rune{'0', '9'},
+						pos:        position{line: 588, col: 9, offset: 14771},
+						val:        ":",
 						ignoreCase: false,
-						inverted:   false,
+						want:       "\":\"",
 					},
-					&zeroOrOneExpr{
-						pos: position{line: 394, col: 23, offset: 9267},
+					&oneOrMoreExpr{
+						pos: position{line: 588, col: 13, offset: 14775},
 						expr: &charClassMatcher{
-							pos:        position{line: 394, col: 23, offset: 9267},
+							pos:        position{line: 588, col: 13, offset: 14775},
 							val:        "[0-9]",
 							ranges:     []rune{'0', '9'},
 							ignoreCase: false,
 							inverted:   false,
 						},
 					},
+					&zeroOrOneExpr{
+						pos: position{line: 588, col: 20, offset: 14782},
+						expr: &seqExpr{
+							pos: position{line: 588, col: 22, offset: 14784},
+							exprs: []any{
+								&litMatcher{
+									pos:        position{line: 588, col: 22, offset: 14784},
+									val:        "-",
+									ignoreCase: false,
+									want:       "\"-\"",
+								},
+								&oneOrMoreExpr{
+									pos: position{line: 588, col: 26, offset: 14788},
+									expr: &charClassMatcher{
+										pos:        position{line: 588, col: 26, offset: 14788},
+										val:        "[0-9]",
+										ranges:     []rune{'0', '9'},
+										ignoreCase: false,
+										inverted:   false,
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
 		{
 			name: "Timestamp",
-			pos:  position{line: 397, col: 1, offset: 9287},
+			pos:  position{line: 591, col: 1, offset: 14844},
+			expr: &choiceExpr{
+				pos: position{line: 591, col: 14, offset: 14857},
+				alternatives: []any{
+					&ruleRefExpr{
+						pos:  position{line: 591, col: 14, offset: 14857},
+						name: "RelativeTime",
+					},
+					&actionExpr{
+						pos: position{line: 591, col: 29, offset: 14872},
+						run: (*parser).callonTimestamp3,
+						expr: &choiceExpr{
+							pos: position{line: 591, col: 30, offset: 14873},
+							alternatives: []any{
+								&ruleRefExpr{
+									pos:  position{line: 591, col: 30, offset: 14873},
+									name: "dateTime",
+								},
+								&seqExpr{
+									pos: position{line: 591, col: 41, offset: 14884},
+									exprs: []any{
+										&ruleRefExpr{
+											pos:  position{line: 591, col: 41, offset: 14884},
+											name: "fullDate",
+										},
+										&zeroOrOneExpr{
+											pos: position{line: 591, col: 50, offset: 14893},
+											expr: &ruleRefExpr{
+												pos:  position{line: 591, col: 50, offset: 14893},
+												name: "TimeZone",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RelativeTime",
+			pos:  position{line: 601, col: 1, offset: 15099},
 			expr: &actionExpr{
-				pos: position{line: 397, col: 14, offset: 9300},
-				run: (*parser).callonTimestamp1,
-				expr: &choiceExpr{
-					pos: position{line: 397, col: 15, offset: 9301},
-					alternatives: []interface{}{
-						&ruleRefExpr{
-							pos:  position{line: 397, col: 15, offset: 9301},
-							name: "dateTime",
+				pos: position{line: 601, col: 17, offset: 15115},
+				run: (*parser).callonRelativeTime1,
+				expr: &seqExpr{
+					pos: position{line: 601, col: 17, offset: 15115},
+					exprs: []any{
+						&litMatcher{
+							pos:        position{line: 601, col: 17, offset: 15115},
+							val:        "now",
+							ignoreCase: false,
+							want:       "\"now\"",
 						},
-						&ruleRefExpr{
-							pos:  position{line: 397, col: 26, offset: 9312},
-							name: "fullDate",
+						&zeroOrMoreExpr{
+							pos: position{line: 601, col: 23, offset: 15121},
+							expr: &choiceExpr{
+								pos: position{line: 601, col: 25, offset: 15123},
+								alternatives: []any{
+									&seqExpr{
+										pos: position{line: 601, col: 25, offset: 15123},
+										exprs: []any{
+											&charClassMatcher{
+												pos:        position{line: 601, col: 25, offset: 15123},
+												val:        "[+-]",
+												chars:      []rune{'+', '-'},
+												ignoreCase: false,
+												inverted:   false,
+											},
+											&oneOrMoreExpr{
+												pos: position{line: 601, col: 30, offset: 15128},
+												expr: &charClassMatcher{
+													pos:        position{line: 601, col: 30, offset: 15128},
+													val:        "[0-9]",
+													ranges:     []rune{'0', '9'},
+													ignoreCase: false,
+													inverted:   false,
+												},
+											},
+											&charClassMatcher{
+												pos:        position{line: 601, col: 37, offset: 15135},
+												val:        "[a-z]i",
+												ranges:     []rune{'a', 'z'},
+												ignoreCase: true,
+												inverted:   false,
+											},
+										},
+									},
+									&seqExpr{
+										pos: position{line: 601, col: 46, offset: 15144},
+										exprs: []any{
+											&litMatcher{
+												pos:        position{line: 601, col: 46, offset: 15144},
+												val:        "/",
+												ignoreCase: false,
+												want:       "\"/\"",
+											},
+											&charClassMatcher{
+												pos:        position{line: 601, col: 50, offset: 15148},
+												val:        "[a-z]i",
+												ranges:     []rune{'a', 'z'},
+												ignoreCase: true,
+												inverted:   false,
+											},
+										},
+									},
+								},
+							},
+						},
+						&notExpr{
+							pos: position{line: 601, col: 60, offset: 15158},
+							expr: &charClassMatcher{
+								pos:        position{line: 601, col: 61, offset: 15159},
+								val:        "[a-z0-9_]i",
+								chars:      []rune{'_'},
+								ranges:     []rune{'a', 'z', '0', '9'},
+								ignoreCase: true,
+								inverted:   false,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "TimeZone",
+			pos:  position{line: 611, col: 1, offset: 15410},
+			expr: &seqExpr{
+				pos: position{line: 611, col: 13, offset: 15422},
+				exprs: []any{
+					&litMatcher{
+						pos:        position{line: 611, col: 13, offset: 15422},
+						val:        "@",
+						ignoreCase: false,
+						want:       "\"@\"",
+					},
+					&oneOrMoreExpr{
+						pos: position{line: 611, col: 17, offset: 15426},
+						expr: &charClassMatcher{
+							pos:        position{line: 611, col: 17, offset: 15426},
+							val:        "[a-z0-9_+/-]i",
+							chars:      []rune{'_', '+', '/', '-'},
+							ranges:     []rune{'a', 'z', '0', '9'},
+							ignoreCase: true,
+							inverted:   false,
 						},
 					},
 				},
@@ -1366,25 +2568,25 @@ var g = &grammar{
 		},
 		{
 			name: "dateTime",
-			pos:  position{line: 407, col: 1, offset: 9493},
+			pos:  position{line: 613, col: 1, offset: 15442},
 			expr: &seqExpr{
-				pos: position{line: 407, col: 13, offset: 9505},
-				exprs: []interface{}{
+				pos: position{line: 613, col: 13, offset: 15454},
+				exprs: []any{
 					&ruleRefExpr{
-						pos:  position{line: 407, col: 13, offset: 9505},
+						pos:  position{line: 613, col: 13, offset: 15454},
 						name: "fullDate",
 					},
 					&choiceExpr{
-						pos: position{line: 407, col: 23, offset: 9515},
-						alternatives: []interface{}{
+						pos: position{line: 613, col: 23, offset: 15464},
+						alternatives: []any{
 							&litMatcher{
-								pos:        position{line: 407, col: 23, offset: 9515},
+								pos:        position{line: 613, col: 23, offset: 15464},
 								val:        "t",
 								ignoreCase: true,
 								want:       "\"T\"i",
 							},
 							&litMatcher{
-								pos:        position{line: 407, col: 30, offset: 9522},
+								pos:        position{line: 613, col: 30, offset: 15471},
 								val:        " ",
 								ignoreCase: false,
 								want:       "\" \"",
@@ -1392,7 +2594,7 @@ var g = &grammar{
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 407, col: 35, offset: 9527},
+						pos:  position{line: 613, col: 35, offset: 15476},
 						name: "fullTime",
 					},
 				},
@@ -1400,32 +2602,32 @@ var g = &grammar{
 		},
 		{
 			name: "fullDate",
-			pos:  position{line: 408, col: 1, offset: 9536},
+			pos:  position{line: 614, col: 1, offset: 15485},
 			expr: &seqExpr{
-				pos: position{line: 408, col: 13, offset: 9548},
-				exprs: []interface{}{
+				pos: position{line: 614, col: 13, offset: 15497},
+				exprs: []any{
 					&ruleRefExpr{
-						pos:  position{line: 408, col: 13, offset: 9548},
+						pos:  position{line: 614, col: 13, offset: 15497},
 						name: "dateFullyear",
 					},
 					&litMatcher{
-						pos:        position{line: 408, col: 26, offset: 9561},
+						pos:        position{line: 614, col: 26, offset: 15510},
 						val:        "-",
 						ignoreCase: false,
 						want:       "\"-\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 408, col: 30, offset: 9565},
+						pos:  position{line: 614, col: 30, offset: 15514},
 						name: "dateMonth",
 					},
 					&litMatcher{
-						pos:        position{line: 408, col: 40, offset: 9575},
+						pos:        position{line: 614, col: 40, offset: 15524},
 						val:        "-",
 						ignoreCase: false,
 						want:       "\"-\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 408, col: 44, offset: 9579},
+						pos:  position{line: 614, col: 44, offset: 15528},
 						name: "dateMday",
 					},
 				},
@@ -1433,68 +2635,68 @@ var g = &grammar{
 		},
 		{
 			name: "dateFullyear",
-			pos:  position{line: 410, col: 1, offset: 9589},
+			pos:  position{line: 616, col: 1, offset: 15538},
 			expr: &ruleRefExpr{
-				pos:  position{line: 410, col: 17, offset: 9605},
+				pos:  position{line: 616, col: 17, offset: 15554},
 				name: "Digit4",
 			},
 		},
 		{
 			name: "dateMonth",
-			pos:  position{line: 411, col: 1, offset: 9612},
+			pos:  position{line: 617, col: 1, offset: 15561},
 			expr: &ruleRefExpr{
-				pos:  position{line: 411, col: 14, offset: 9625},
+				pos:  position{line: 617, col: 14, offset: 15574},
 				name: "Digit2",
 			},
 		},
 		{
 			name: "dateMday",
-			pos:  position{line: 412, col: 1, offset: 9632},
+			pos:  position{line: 618, col: 1, offset: 15581},
 			expr: &ruleRefExpr{
-				pos:  position{line: 412, col: 13, offset: 9644},
+				pos:  position{line: 618, col: 13, offset: 15593},
 				name: "Digit2",
 			},
 		},
 		{
 			name: "timeHour",
-			pos:  position{line: 413, col: 1, offset: 9651},
+			pos:  position{line: 619, col: 1, offset: 15600},
 			expr: &ruleRefExpr{
-				pos:  position{line: 413, col: 13, offset: 9663},
+				pos:  position{line: 619, col: 13, offset: 15612},
 				name: "Digit2",
 			},
 		},
 		{
 			name: "timeMinute",
-			pos:  position{line: 414, col: 1, offset: 9670},
+			pos:  position{line: 620, col: 1, offset: 15619},
 			expr: &ruleRefExpr{
-				pos:  position{line: 414, col: 15, offset: 9684},
+				pos:  position{line: 620, col: 15, offset: 15633},
 				name: "Digit2",
 			},
 		},
 		{
 			name: "timeSecond",
-			pos:  position{line: 415, col: 1, offset: 9691},
+			pos:  position{line: 621, col: 1, offset: 15640},
 			expr: &ruleRefExpr{
-				pos:  position{line: 415, col: 15, offset: 9705},
+				pos:  position{line: 621, col: 15, offset: 15654},
 				name: "Digit2",
 			},
 		},
 		{
 			name: "timeSecfrac",
-			pos:  position{line: 416, col: 1, offset: 9712},
+			pos:  position{line: 622, col: 1, offset: 15661},
 			expr: &seqExpr{
-				pos: position{line: 416, col: 16, offset: 9727},
-				exprs: []interface{}{
+				pos: position{line: 622, col: 16, offset: 15676},
+				exprs: []any{
 					&litMatcher{
-						pos:        position{line: 416, col: 16, offset: 9727},
+						pos:        position{line: 622, col: 16, offset: 15676},
 						val:        ".",
 						ignoreCase: false,
 						want:       "\".\"",
 					},
 					&oneOrMoreExpr{
-						pos: position{line: 416, col: 20, offset: 9731},
+						pos: position{line: 622, col: 20, offset: 15680},
 						expr: &charClassMatcher{
-							pos:        position{line: 416, col: 20, offset: 9731},
+							pos:        position{line: 622, col: 20, offset: 15680},
 							val:        "[0-9]",
 							ranges:     []rune{'0', '9'},
 							ignoreCase: false,
@@ -1506,21 +2708,21 @@ var g = &grammar{
 		},
 		{
 			name: "timeNumoffset",
-			pos:  position{line: 417, col: 1, offset: 9738},
+			pos:  position{line: 623, col: 1, offset: 15687},
 			expr: &seqExpr{
-				pos: position{line: 417, col: 18, offset: 9755},
-				exprs: []interface{}{
+				pos: position{line: 623, col: 18, offset: 15704},
+				exprs: []any{
 					&choiceExpr{
-						pos: position{line: 417, col: 19, offset: 9756},
-						alternatives: []interface{}{
+						pos: position{line: 623, col: 19, offset: 15705},
+						alternatives: []any{
 							&litMatcher{
-								pos:        position{line: 417, col: 19, offset: 9756},
+								pos:        position{line: 623, col: 19, offset: 15705},
 								val:        "+",
 								ignoreCase: false,
 								want:       "\"+\"",
 							},
 							&litMatcher{
-								pos:        position{line: 417, col: 25, offset: 9762},
+								pos:        position{line: 623, col: 25, offset: 15711},
 								val:        "-",
 								ignoreCase: false,
 								want:       "\"-\"",
@@ -1528,17 +2730,17 @@ var g = &grammar{
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 417, col: 30, offset: 9767},
+						pos:  position{line: 623, col: 30, offset: 15716},
 						name: "timeHour",
 					},
 					&litMatcher{
-						pos:        position{line: 417, col: 39, offset: 9776},
+						pos:        position{line: 623, col: 39, offset: 15725},
 						val:        ":",
 						ignoreCase: false,
 						want:       "\":\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 417, col: 43, offset: 9780},
+						pos:  position{line: 623, col: 43, offset: 15729},
 						name: "timeMinute",
 					},
 				},
@@ -1546,18 +2748,18 @@ var g = &grammar{
 		},
 		{
 			name: "timeOffset",
-			pos:  position{line: 418, col: 1, offset: 9791},
+			pos:  position{line: 624, col: 1, offset: 15740},
 			expr: &choiceExpr{
-				pos: position{line: 418, col: 15, offset: 9805},
-				alternatives: []interface{}{
+				pos: position{line: 624, col: 15, offset: 15754},
+				alternatives: []any{
 					&litMatcher{
-						pos:        position{line: 418, col: 15, offset: 9805},
+						pos:        position{line: 624, col: 15, offset: 15754},
 						val:        "z",
 						ignoreCase: true,
 						want:       "\"Z\"i",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 418, col: 22, offset: 9812},
+						pos:  position{line: 624, col: 22, offset: 15761},
 						name: "timeNumoffset",
 					},
 				},
@@ -1565,38 +2767,38 @@ var g = &grammar{
 		},
 		{
 			name: "partialTime",
-			pos:  position{line: 419, col: 1, offset: 9826},
+			pos:  position{line: 625, col: 1, offset: 15775},
 			expr: &seqExpr{
-				pos: position{line: 419, col: 16, offset: 9841},
-				exprs: []interface{}{
+				pos: position{line: 625, col: 16, offset: 15790},
+				exprs: []any{
 					&ruleRefExpr{
-						pos:  position{line: 419, col: 16, offset: 9841},
+						pos:  position{line: 625, col: 16, offset: 15790},
 						name: "timeHour",
 					},
 					&litMatcher{
-						pos:        position{line: 419, col: 25, offset: 9850},
+						pos:        position{line: 625, col: 25, offset: 15799},
 						val:        ":",
 						ignoreCase: false,
 						want:       "\":\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 419, col: 29, offset: 9854},
+						pos:  position{line: 625, col: 29, offset: 15803},
 						name: "timeMinute",
 					},
 					&litMatcher{
-						pos:        position{line: 419, col: 40, offset: 9865},
+						pos:        position{line: 625, col: 40, offset: 15814},
 						val:        ":",
 						ignoreCase: false,
 						want:       "\":\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 419, col: 44, offset: 9869},
+						pos:  position{line: 625, col: 44, offset: 15818},
 						name: "timeSecond",
 					},
 					&zeroOrOneExpr{
-						pos: position{line: 419, col: 55, offset: 9880},
+						pos: position{line: 625, col: 55, offset: 15829},
 						expr: &ruleRefExpr{
-							pos:  position{line: 419, col: 55, offset: 9880},
+							pos:  position{line: 625, col: 55, offset: 15829},
 							name: "timeSecfrac",
 						},
 					},
@@ -1605,16 +2807,16 @@ var g = &grammar{
 		},
 		{
 			name: "fullTime",
-			pos:  position{line: 420, col: 1, offset: 9893},
+			pos:  position{line: 626, col: 1, offset: 15842},
 			expr: &seqExpr{
-				pos: position{line: 420, col: 13, offset: 9905},
-				exprs: []interface{}{
+				pos: position{line: 626, col: 13, offset: 15854},
+				exprs: []any{
 					&ruleRefExpr{
-						pos:  position{line: 420, col: 13, offset: 9905},
+						pos:  position{line: 626, col: 13, offset: 15854},
 						name: "partialTime",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 420, col: 25, offset: 9917},
+						pos:  position{line: 626, col: 25, offset: 15866},
 						name: "timeOffset",
 					},
 				},
@@ -1622,33 +2824,33 @@ var g = &grammar{
 		},
 		{
 			name: "Digit4",
-			pos:  position{line: 421, col: 1, offset: 9928},
+			pos:  position{line: 627, col: 1, offset: 15877},
 			expr: &seqExpr{
-				pos: position{line: 421, col: 11, offset: 9938},
-				exprs: []interface{}{
+				pos: position{line: 627, col: 11, offset: 15887},
+				exprs: []any{
 					&charClassMatcher{
-						pos:        position{line: 421, col: 11, offset: 9938},
+						pos:        position{line: 627, col: 11, offset: 15887},
 						val:        "[0-9]",
 						ranges:     []rune{'0', '9'},
 						ignoreCase: false,
 						inverted:   false,
 					},
 					&charClassMatcher{
-						pos:        position{line: 421, col: 16, offset: 9943},
+						pos:        position{line: 627, col: 16, offset: 15892},
 						val:        "[0-9]",
 						ranges:     []rune{'0', '9'},
 						ignoreCase: false,
 						inverted:   false,
 					},
 					&charClassMatcher{
-						pos:        position{line: 421, col: 21, offset: 9948},
+						pos:        position{line: 627, col: 21, offset: 15897},
 						val:        "[0-9]",
 						ranges:     []rune{'0', '9'},
 						ignoreCase: false,
 						inverted:   false,
 					},
 					&charClassMatcher{
-						pos:        position{line: 421, col: 26, offset: 9953},
+						pos:        position{line: 627, col: 26, offset: 15902},
 						val:        "[0-9]",
 						ranges:     []rune{'0', '9'},
 						ignoreCase: false,
@@ -1659,19 +2861,19 @@ var g = &grammar{
 		},
 		{
 			name: "Digit2",
-			pos:  position{line: 422, col: 1, offset: 9959},
+			pos:  position{line: 628, col: 1, offset: 15908},
 			expr: &seqExpr{
-				pos: position{line: 422, col: 11, offset: 9969},
-				exprs: []interface{}{
+				pos: position{line: 628, col: 11, offset: 15918},
+				exprs: []any{
 					&charClassMatcher{
-						pos:        position{line: 422, col: 11, offset: 9969},
+						pos:        position{line: 628, col: 11, offset: 15918},
 						val:        "[0-9]",
 						ranges:     []rune{'0', '9'},
 						ignoreCase: false,
 						inverted:   false,
 					},
 					&charClassMatcher{
-						pos:        position{line: 422, col: 16, offset: 9974},
+						pos:        position{line: 628, col: 16, offset: 15923},
 						val:        "[0-9]",
 						ranges:     []rune{'0', '9'},
 						ignoreCase: false,
@@ -1682,9 +2884,9 @@ var g = &grammar{
 		},
 		{
 			name: "logicalOR",
-			pos:  position{line: 428, col: 1, offset: 10037},
+			pos:  position{line: 634, col: 1, offset: 15986},
 			expr: &litMatcher{
-				pos:        position{line: 428, col: 14, offset: 10050},
+				pos:        position{line: 634, col: 14, offset: 15999},
 				val:        "OR",
 				ignoreCase: false,
 				want:       "\"OR\"",
@@ -1692,9 +2894,9 @@ var g = &grammar{
 		},
 		{
 			name: "logicalAND",
-			pos:  position{line: 430, col: 1, offset: 10056},
+			pos:  position{line: 636, col: 1, offset: 16005},
 			expr: &litMatcher{
-				pos:        position{line: 430, col: 15, offset: 10070},
+				pos:        position{line: 636, col: 15, offset: 16019},
 				val:        "AND",
 				ignoreCase: false,
 				want:       "\"AND\"",
@@ -1702,38 +2904,38 @@ var g = &grammar{
 		},
 		{
 			name: "logicalNOT",
-			pos:  position{line: 432, col: 1, offset: 10077},
+			pos:  position{line: 638, col: 1, offset: 16026},
 			expr: &choiceExpr{
-				pos: position{line: 432, col: 15, offset: 10091},
-				alternatives: []interface{}{
+				pos: position{line: 638, col: 15, offset: 16040},
+				alternatives: []any{
 					&seqExpr{
-						pos: position{line: 432, col: 15, offset: 10091},
-						exprs: []interface{}{
+						pos: position{line: 638, col: 15, offset: 16040},
+						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 432, col: 15, offset: 10091},
+								pos:        position{line: 638, col: 15, offset: 16040},
 								val:        "NOT",
 								ignoreCase: false,
 								want:       "\"NOT\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 432, col: 21, offset: 10097},
+								pos:  position{line: 638, col: 21, offset: 16046},
 								name: "space",
 							},
 						},
 					},
 					&seqExpr{
-						pos: position{line: 432, col: 29, offset: 10105},
-						exprs: []interface{}{
+						pos: position{line: 638, col: 29, offset: 16054},
+						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 432, col: 29, offset: 10105},
+								pos:        position{line: 638, col: 29, offset: 16054},
 								val:        "!",
 								ignoreCase: false,
 								want:       "\"!\"",
 							},
 							&zeroOrOneExpr{
-								pos: position{line: 432, col: 33, offset: 10109},
+								pos: position{line: 638, col: 33, offset: 16058},
 								expr: &ruleRefExpr{
-									pos:  position{line: 432, col: 33, offset: 10109},
+									pos:  position{line: 638, col: 33, offset: 16058},
 									name: "space",
 								},
 							},
@@ -1744,64 +2946,198 @@ var g = &grammar{
 		},
 		{
 			name: "opNoArgs",
-			pos:  position{line: 438, col: 1, offset: 10182},
+			pos:  position{line: 644, col: 1, offset: 16131},
 			expr: &actionExpr{
-				pos: position{line: 438, col: 13, offset: 10194},
+				pos: position{line: 644, col: 13, offset: 16143},
 				run: (*parser).callonopNoArgs1,
 				expr: &choiceExpr{
-					pos: position{line: 438, col: 14, offset: 10195},
-					alternatives: []interface{}{
+					pos: position{line: 644, col: 14, offset: 16144},
+					alternatives: []any{
 						&litMatcher{
-							pos:        position{line: 438, col: 14, offset: 10195},
+							pos:        position{line: 644, col: 14, offset: 16144},
 							val:        "exists",
 							ignoreCase: false,
 							want:       "\"exists\"",
 						},
 						&litMatcher{
-							pos:        position{line: 438, col: 25, offset: 10206},
-							val:        "null",
+							pos:        position{line: 644, col: 25, offset: 16155},
+							val:        "null",
+							ignoreCase: false,
+							want:       "\"null\"",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Operation",
+			pos:  position{line: 655, col: 1, offset: 16328},
+			expr: &actionExpr{
+				pos: position{line: 655, col: 14, offset: 16341},
+				run: (*parser).callonOperation1,
+				expr: &seqExpr{
+					pos: position{line: 655, col: 14, offset: 16341},
+					exprs: []any{
+						&labeledExpr{
+							pos:   position{line: 655, col: 14, offset: 16341},
+							label: "op",
+							expr: &ruleRefExpr{
+								pos:  position{line: 655, col: 17, offset: 16344},
+								name: "opComp",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 655, col: 24, offset: 16351},
+							label: "params",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 655, col: 31, offset: 16358},
+								expr: &ruleRefExpr{
+									pos:  position{line: 655, col: 31, offset: 16358},
+									name: "OpParams",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "OpParams",
+			pos:  position{line: 668, col: 1, offset: 16738},
+			expr: &actionExpr{
+				pos: position{line: 668, col: 13, offset: 16750},
+				run: (*parser).callonOpParams1,
+				expr: &seqExpr{
+					pos: position{line: 668, col: 13, offset: 16750},
+					exprs: []any{
+						&litMatcher{
+							pos:        position{line: 668, col: 13, offset: 16750},
+							val:        "[",
+							ignoreCase: false,
+							want:       "\"[\"",
+						},
+						&ruleRefExpr{
+							pos:  position{line: 668, col: 17, offset: 16754},
+							name: "_",
+						},
+						&labeledExpr{
+							pos:   position{line: 668, col: 19, offset: 16756},
+							label: "first",
+							expr: &ruleRefExpr{
+								pos:  position{line: 668, col: 25, offset: 16762},
+								name: "OpParam",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 668, col: 33, offset: 16770},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 668, col: 38, offset: 16775},
+								expr: &seqExpr{
+									pos: position{line: 668, col: 40, offset: 16777},
+									exprs: []any{
+										&ruleRefExpr{
+											pos:  position{line: 668, col: 40, offset: 16777},
+											name: "_",
+										},
+										&litMatcher{
+											pos:        position{line: 668, col: 42, offset: 16779},
+											val:        ",",
+											ignoreCase: false,
+											want:       "\",\"",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 668, col: 46, offset: 16783},
+											name: "_",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 668, col: 48, offset: 16785},
+											name: "OpParam",
+										},
+									},
+								},
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 668, col: 59, offset: 16796},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 668, col: 61, offset: 16798},
+							val:        "]",
 							ignoreCase: false,
-							want:       "\"null\"",
+							want:       "\"]\"",
 						},
 					},
 				},
 			},
 		},
+		{
+			name: "OpParam",
+			pos:  position{line: 676, col: 1, offset: 16956},
+			expr: &actionExpr{
+				pos: position{line: 676, col: 12, offset: 16967},
+				run: (*parser).callonOpParam1,
+				expr: &oneOrMoreExpr{
+					pos: position{line: 676, col: 12, offset: 16967},
+					expr: &charClassMatcher{
+						pos:        position{line: 676, col: 12, offset: 16967},
+						val:        "[a-z0-9_-]i",
+						chars:      []rune{'_', '-'},
+						ranges:     []rune{'a', 'z', '0', '9'},
+						ignoreCase: true,
+						inverted:   false,
+					},
+				},
+			},
+		},
 		{
 			name: "opComp",
-			pos:  position{line: 449, col: 1, offset: 10379},
+			pos:  position{line: 680, col: 1, offset: 17047},
 			expr: &actionExpr{
-				pos: position{line: 449, col: 11, offset: 10389},
+				pos: position{line: 680, col: 11, offset: 17057},
 				run: (*parser).callonopComp1,
 				expr: &choiceExpr{
-					pos: position{line: 449, col: 12, offset: 10390},
-					alternatives: []interface{}{
+					pos: position{line: 680, col: 12, offset: 17058},
+					alternatives: []any{
 						&litMatcher{
-							pos:        position{line: 449, col: 12, offset: 10390},
+							pos:        position{line: 680, col: 12, offset: 17058},
 							val:        "><",
 							ignoreCase: false,
 							want:       "\"><\"",
 						},
 						&litMatcher{
-							pos:        position{line: 449, col: 19, offset: 10397},
+							pos:        position{line: 680, col: 19, offset: 17065},
+							val:        "~=",
+							ignoreCase: false,
+							want:       "\"~=\"",
+						},
+						&litMatcher{
+							pos:        position{line: 680, col: 26, offset: 17072},
 							val:        "~",
 							ignoreCase: false,
 							want:       "\"~\"",
 						},
+						&litMatcher{
+							pos:        position{line: 680, col: 32, offset: 17078},
+							val:        "=",
+							ignoreCase: false,
+							want:       "\"=\"",
+						},
 						&seqExpr{
-							pos: position{line: 449, col: 25, offset: 10403},
-							exprs: []interface{}{
+							pos: position{line: 680, col: 38, offset: 17084},
+							exprs: []any{
 								&charClassMatcher{
-									pos:        position{line: 449, col: 25, offset: 10403},
+									pos:        position{line: 680, col: 38, offset: 17084},
 									val:        "[<>]",
 									chars:      []rune{'<', '>'},
 									ignoreCase: false,
 									inverted:   false,
 								},
 								&zeroOrOneExpr{
-									pos: position{line: 449, col: 30, offset: 10408},
+									pos: position{line: 680, col: 43, offset: 17089},
 									expr: &litMatcher{
-										pos:        position{line: 449, col: 30, offset: 10408},
+										pos:        position{line: 680, col: 43, offset: 17089},
 										val:        "=",
 										ignoreCase: false,
 										want:       "\"=\"",
@@ -1816,11 +3152,11 @@ var g = &grammar{
 		{
 			name:        "_",
 			displayName: "\"whitespace\"",
-			pos:         position{line: 477, col: 1, offset: 10921},
+			pos:         position{line: 708, col: 1, offset: 17557},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 477, col: 19, offset: 10939},
+				pos: position{line: 708, col: 19, offset: 17575},
 				expr: &charClassMatcher{
-					pos:        position{line: 477, col: 19, offset: 10939},
+					pos:        position{line: 708, col: 19, offset: 17575},
 					val:        "[ \\n\\t\\r]",
 					chars:      []rune{' ', '\n', '\t', '\r'},
 					ignoreCase: false,
@@ -1830,11 +3166,11 @@ var g = &grammar{
 		},
 		{
 			name: "space",
-			pos:  position{line: 479, col: 1, offset: 10951},
+			pos:  position{line: 710, col: 1, offset: 17587},
 			expr: &oneOrMoreExpr{
-				pos: position{line: 479, col: 10, offset: 10960},
+				pos: position{line: 710, col: 10, offset: 17596},
 				expr: &charClassMatcher{
-					pos:        position{line: 479, col: 10, offset: 10960},
+					pos:        position{line: 710, col: 10, offset: 17596},
 					val:        "[ \\n\\t\\r]",
 					chars:      []rune{' ', '\n', '\t', '\r'},
 					ignoreCase: false,
@@ -1844,9 +3180,9 @@ var g = &grammar{
 		},
 		{
 			name: "EOL",
-			pos:  position{line: 481, col: 1, offset: 10972},
+			pos:  position{line: 712, col: 1, offset: 17608},
 			expr: &litMatcher{
-				pos:        position{line: 481, col: 8, offset: 10979},
+				pos:        position{line: 712, col: 8, offset: 17615},
 				val:        "\n",
 				ignoreCase: false,
 				want:       "\"\\n\"",
@@ -1854,191 +3190,409 @@ var g = &grammar{
 		},
 		{
 			name: "EOF",
-			pos:  position{line: 483, col: 1, offset: 10985},
+			pos:  position{line: 714, col: 1, offset: 17621},
 			expr: &notExpr{
-				pos: position{line: 483, col: 7, offset: 10991},
+				pos: position{line: 714, col: 7, offset: 17627},
 				expr: &anyMatcher{
-					line: 483, col: 8, offset: 10992,
+					line: 714, col: 8, offset: 17628,
 				},
 			},
 		},
 		{
 			name: "ErrUntermStr",
-			pos:  position{line: 489, col: 1, offset: 11090},
+			pos:  position{line: 720, col: 1, offset: 17726},
 			expr: &stateCodeExpr{
-				pos: position{line: 489, col: 17, offset: 11106},
+				pos: position{line: 720, col: 17, offset: 17742},
 				run: (*parser).callonErrUntermStr1,
 			},
 		},
 		{
 			name: "ErrUntermRegex",
-			pos:  position{line: 493, col: 1, offset: 11205},
+			pos:  position{line: 724, col: 1, offset: 17841},
 			expr: &stateCodeExpr{
-				pos: position{line: 493, col: 19, offset: 11223},
+				pos: position{line: 724, col: 19, offset: 17859},
 				run: (*parser).callonErrUntermRegex1,
 			},
 		},
 	},
 }
 
-func (c *current) onStart1(query interface{}) (interface{}, error) {
+func (c *current) onStart1(query any) (any, error) {
 	return query, nil
 }
 
-func (p *parser) callonStart1() (interface{}, error) {
+func (p *parser) callonStart1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onStart1(stack["query"])
 }
 
-func (c *current) onQuery1(clause interface{}) (interface{}, error) {
+func (c *current) onQuery1(clause any) (any, error) {
 	return clause, nil
 }
 
-func (p *parser) callonQuery1() (interface{}, error) {
+func (p *parser) callonQuery1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onQuery1(stack["clause"])
 }
 
-func (c *current) onOrClause2(lhs, rhs interface{}) (interface{}, error) {
+func (c *current) onOrClause2(lhs, rhs any) (any, error) {
 	return &ast.OrNode{
-		Left:  lhs.(ast.Node),
-		Right: rhs.(ast.Node),
+		Left:     lhs.(ast.Node),
+		Right:    rhs.(ast.Node),
+		Position: getpos(c),
 	}, nil
 }
 
-func (p *parser) callonOrClause2() (interface{}, error) {
+func (p *parser) callonOrClause2() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onOrClause2(stack["lhs"], stack["rhs"])
 }
 
-func (c *current) onAndClause2(lhs, rhs interface{}) (interface{}, error) {
+func (c *current) onAndClause2(lhs, rhs any) (any, error) {
 	return &ast.AndNode{
-		Left:  lhs.(ast.Node),
-		Right: rhs.(ast.Node),
+		Left:     lhs.(ast.Node),
+		Right:    rhs.(ast.Node),
+		Position: getpos(c),
 	}, nil
 }
 
-func (p *parser) callonAndClause2() (interface{}, error) {
+func (p *parser) callonAndClause2() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onAndClause2(stack["lhs"], stack["rhs"])
 }
 
-func (c *current) onNotClause2(cmp interface{}) (interface{}, error) {
+func (c *current) onNotClause2(cmp any) (any, error) {
 	return &ast.NotNode{
-		Expr: cmp.(ast.Node),
+		Expr:     cmp.(ast.Node),
+		Position: getpos(c),
 	}, nil
 }
 
-func (p *parser) callonNotClause2() (interface{}, error) {
+func (p *parser) callonNotClause2() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onNotClause2(stack["cmp"])
 }
 
-func (c *current) onComparison2(query interface{}) (interface{}, error) {
+func (c *current) onComparison2(query any) (any, error) {
 	return query, nil
 }
 
-func (p *parser) callonComparison2() (interface{}, error) {
+func (p *parser) callonComparison2() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onComparison2(stack["query"])
 }
 
-func (c *current) onComparison10(field, operation interface{}) (interface{}, error) {
+func (c *current) onComparison10(field, operation any) (any, error) {
 	return &ast.ExprNode{
 		Op:       operation.(ast.Op),
-		Field:    field.([]string),
+		Field:    field.(ast.Path),
 		Position: getpos(c),
 	}, nil
 }
 
-func (p *parser) callonComparison10() (interface{}, error) {
+func (p *parser) callonComparison10() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onComparison10(stack["field"], stack["operation"])
 }
 
-func (c *current) onComparison19(field, operation, values interface{}) (interface{}, error) {
-	var opOut ast.Op
-	if operation == nil {
-		opOut = ast.EQ
-	} else {
-		opOut = operation.(ast.Op)
+func (c *current) onComparison19(field, query any) (any, error) {
+	return &ast.SubdocNode{
+		Field:    field.(ast.Path),
+		Expr:     query.(ast.Node),
+		Position: getpos(c),
+	}, nil
+}
+
+func (p *parser) callonComparison19() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onComparison19(stack["field"], stack["query"])
+}
+
+func (c *current) onComparison30(field, rng any) (any, error) {
+	spec := rng.(rangeSpec)
+	return &ast.ExprNode{
+		Op:       ast.BET,
+		Bounds:   spec.bounds,
+		Field:    field.(ast.Path),
+		RVals:    spec.vals,
+		Position: getpos(c),
+	}, nil
+}
+
+func (p *parser) callonComparison30() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onComparison30(stack["field"], stack["rng"])
+}
+
+func (c *current) onComparison39(field, operation, values any) (any, error) {
+	opOut := operationSpec{op: ast.EQ}
+	if operation != nil {
+		opOut = operation.(operationSpec)
 	}
 	node := &ast.ExprNode{
-		Op:       opOut,
-		Field:    field.([]string),
+		Op:       opOut.op,
+		Params:   opOut.params,
+		ParamPos: opOut.paramPos,
+		Field:    field.(ast.Path),
 		RVals:    values.([]ast.Val),
 		Position: getpos(c),
 	}
 	return node, nil
 }
 
-func (p *parser) callonComparison19() (interface{}, error) {
+func (p *parser) callonComparison39() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onComparison19(stack["field"], stack["operation"], stack["values"])
+	return p.cur.onComparison39(stack["field"], stack["operation"], stack["values"])
 }
 
-func (c *current) onField1(pieces interface{}) (interface{}, error) {
-	piecesSl := toAny(pieces)
-	if len(piecesSl) == 0 {
-		return nil, fmt.Errorf("empty field")
+func (c *current) onComparison52(call, rng any) (any, error) {
+	fc := call.(fieldCall)
+	spec := rng.(rangeSpec)
+	return &ast.ExprNode{
+		Op:       ast.BET,
+		Bounds:   spec.bounds,
+		Field:    fc.field,
+		Func:     fc.fn,
+		RVals:    spec.vals,
+		Position: getpos(c),
+	}, nil
+}
+
+func (p *parser) callonComparison52() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onComparison52(stack["call"], stack["rng"])
+}
+
+func (c *current) onComparison61(call, operation, values any) (any, error) {
+	fc := call.(fieldCall)
+	opOut := operationSpec{op: ast.EQ}
+	if operation != nil {
+		opOut = operation.(operationSpec)
 	}
-	// remove stars for now, they are redundant legacy syntax
-	field := []string{piecesSl[0].(string)}
-	restSl := toAny(piecesSl[1])
-	for _, v := range restSl {
-		vSl := toAny(v)
-		field = append(field, vSl[1].(string))
+	return &ast.ExprNode{
+		Op:       opOut.op,
+		Params:   opOut.params,
+		ParamPos: opOut.paramPos,
+		Field:    fc.field,
+		Func:     fc.fn,
+		RVals:    values.([]ast.Val),
+		Position: getpos(c),
+	}, nil
+}
+
+func (p *parser) callonComparison61() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onComparison61(stack["call"], stack["operation"], stack["values"])
+}
+
+func (c *current) onFieldCall1(name, field, args any) (any, error) {
+	var vals []ast.Val
+	for _, v := range toAny(args) {
+		vals = append(vals, toAny(v)[3].(ast.Val))
+	}
+	fn, err := ast.NewFuncVal(name.(string), vals, getpos(c))
+	if err != nil {
+		return nil, err
+	}
+	return fieldCall{field: field.(ast.Path), fn: fn}, nil
+}
+
+func (p *parser) callonFieldCall1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onFieldCall1(stack["name"], stack["field"], stack["args"])
+}
+
+func (c *current) onField1(first, rest any) (any, error) {
+	// stars are removed for now, they are redundant legacy syntax
+	field := ast.Path{}
+	field = append(field, first.(ast.Path)...)
+	for _, v := range toAny(rest) {
+		field = append(field, toAny(v)[1].(ast.Path)...)
+	}
+	if len(field) == 0 {
+		return nil, fmt.Errorf("empty field")
 	}
+	return field, nil
+}
+
+func (p *parser) callonField1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onField1(stack["first"], stack["rest"])
+}
 
-	return removeStars(field), nil
+func (c *current) onFieldElement1(piece, selectors any) (any, error) {
+	var elem ast.Path
+	if key := piece.(string); key != "*" {
+		elem = append(elem, ast.KeySegment(key))
+	}
+	for _, s := range toAny(selectors) {
+		elem = append(elem, s.(ast.PathSegment))
+	}
+	return elem, nil
 }
 
-func (p *parser) callonField1() (interface{}, error) {
+func (p *parser) callonFieldElement1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onField1(stack["pieces"])
+	return p.cur.onFieldElement1(stack["piece"], stack["selectors"])
 }
 
-func (c *current) onUnquotedFieldPiece1() (interface{}, error) {
+func (c *current) onUnquotedFieldPiece1() (any, error) {
 	return string(c.text), nil
 }
 
-func (p *parser) callonUnquotedFieldPiece1() (interface{}, error) {
+func (p *parser) callonUnquotedFieldPiece1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onUnquotedFieldPiece1()
 }
 
-func (c *current) onQuotedFieldPiece1(qv interface{}) (interface{}, error) {
+func (c *current) onQuotedFieldPiece1(qv any) (any, error) {
 	// intercept the ast node and just use it as a string for field pieces
 	return (qv.(*ast.StringVal).Value()), nil
 }
 
-func (p *parser) callonQuotedFieldPiece1() (interface{}, error) {
+func (p *parser) callonQuotedFieldPiece1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onQuotedFieldPiece1(stack["qv"])
 }
 
-func (c *current) onStar1() (interface{}, error) {
+func (c *current) onStar1() (any, error) {
 	return "*", nil
 }
 
-func (p *parser) callonStar1() (interface{}, error) {
+func (p *parser) callonStar1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onStar1()
 }
 
-func (c *current) onValueList2(first, rest interface{}) (interface{}, error) {
+func (c *current) onArraySelector1(sel any) (any, error) {
+	return sel, nil
+}
+
+func (p *parser) callonArraySelector1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onArraySelector1(stack["sel"])
+}
+
+func (c *current) onSelectAll1() (any, error) {
+	return ast.PathSegment{
+		Type: ast.SegmentAll,
+	}, nil
+}
+
+func (p *parser) callonSelectAll1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onSelectAll1()
+}
+
+func (c *current) onSelectSlice1(start, end any) (any, error) {
+	seg := ast.PathSegment{
+		Type: ast.SegmentSlice,
+	}
+	if start != nil {
+		seg.Start, seg.HasStart = start.(int), true
+	}
+	if end != nil {
+		seg.End, seg.HasEnd = end.(int), true
+	}
+	return seg, nil
+}
+
+func (p *parser) callonSelectSlice1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onSelectSlice1(stack["start"], stack["end"])
+}
+
+func (c *current) onSelectIndex1(idx any) (any, error) {
+	return ast.PathSegment{
+		Type:  ast.SegmentIndex,
+		Index: idx.(int),
+	}, nil
+}
+
+func (p *parser) callonSelectIndex1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onSelectIndex1(stack["idx"])
+}
+
+func (c *current) onArrayIndex1() (any, error) {
+	pos := getpos(c)
+	idx, err := strconv.Atoi(string(c.text))
+	if err != nil {
+		return nil, tokErrf(pos, "invalid array index [%s]", c.text)
+	}
+	return idx, nil
+}
+
+func (p *parser) callonArrayIndex1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onArrayIndex1()
+}
+
+func (c *current) onRange1(lo, from, to, hi any) (any, error) {
+	var spec rangeSpec
+	ends := []struct {
+		val       any
+		exclusive bool
+	}{
+		{from, string(lo.([]byte)) == "{"},
+		{to, string(hi.([]byte)) == "}"},
+	}
+	for i, end := range ends {
+		switch {
+		case end.val == nil:
+			spec.bounds[i] = ast.BoundUnbounded
+			continue
+		case end.exclusive:
+			spec.bounds[i] = ast.BoundExclusive
+		}
+		spec.vals = append(spec.vals, end.val.(ast.Val))
+	}
+	if spec.bounds == [2]ast.Bound{ast.BoundUnbounded, ast.BoundUnbounded} {
+		return nil, fmt.Errorf("range must have at least one bound")
+	}
+	return spec, nil
+}
+
+func (p *parser) callonRange1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRange1(stack["lo"], stack["from"], stack["to"], stack["hi"])
+}
+
+func (c *current) onRangeEnd2() (any, error) {
+	return nil, nil
+}
+
+func (p *parser) callonRangeEnd2() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRangeEnd2()
+}
+
+func (c *current) onValueList2(first, rest any) (any, error) {
 	out := []ast.Val{first.(ast.Val)}
 	restSl := toAny(rest)
 	if len(restSl) == 0 {
@@ -2051,46 +3605,46 @@ func (c *current) onValueList2(first, rest interface{}) (interface{}, error) {
 	return out, nil
 }
 
-func (p *parser) callonValueList2() (interface{}, error) {
+func (p *parser) callonValueList2() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onValueList2(stack["first"], stack["rest"])
 }
 
-func (c *current) onValueList17(value interface{}) (interface{}, error) {
+func (c *current) onValueList17(value any) (any, error) {
 	return []ast.Val{value.(ast.Val)}, nil
 }
 
-func (p *parser) callonValueList17() (interface{}, error) {
+func (p *parser) callonValueList17() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onValueList17(stack["value"])
 }
 
-func (c *current) onValue2(val interface{}) (interface{}, error) {
+func (c *current) onValue2(val any) (any, error) {
 	return val.(ast.Val), nil
 }
 
-func (p *parser) callonValue2() (interface{}, error) {
+func (p *parser) callonValue2() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onValue2(stack["val"])
 }
 
-func (c *current) onValue8() (interface{}, error) {
+func (c *current) onValue9() (any, error) {
 	if c.text[0] == ')' {
 		return nil, fmt.Errorf("unexpected closing parenthesis, expecting values")
 	}
 	return nil, fmt.Errorf("unknown type of value [%s] -- did you mean %q?", c.text, c.text)
 }
 
-func (p *parser) callonValue8() (interface{}, error) {
+func (p *parser) callonValue9() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onValue8()
+	return p.cur.onValue9()
 }
 
-func (c *current) onQuotedValue2() (interface{}, error) {
+func (c *current) onQuotedValue2() (any, error) {
 	pos := getpos(c)
 	s, err := strconv.Unquote(string(c.text))
 	if err != nil {
@@ -2099,13 +3653,53 @@ func (c *current) onQuotedValue2() (interface{}, error) {
 	return ast.NewStringVal([]byte(s), pos)
 }
 
-func (p *parser) callonQuotedValue2() (interface{}, error) {
+func (p *parser) callonQuotedValue2() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onQuotedValue2()
 }
 
-func (c *current) onRegexValue2() (interface{}, error) {
+func (c *current) onStringValue1(qv, caseMod, fuzzy any) (any, error) {
+	sv := qv.(*ast.StringVal)
+	if caseMod != nil {
+		switch string(caseMod.([]byte)) {
+		case "c":
+			sv.SetCase(ast.CaseSensitive)
+		case "i":
+			sv.SetCase(ast.CaseInsensitive)
+		}
+	}
+	if fuzzy != nil {
+		sv.SetFuzziness(fuzzy.(int))
+	}
+	return sv, nil
+}
+
+func (p *parser) callonStringValue1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onStringValue1(stack["qv"], stack["caseMod"], stack["fuzzy"])
+}
+
+func (c *current) onFuzziness1() (any, error) {
+	if len(c.text) == 1 {
+		return ast.DefaultMaxEdits, nil
+	}
+	pos := getpos(c)
+	n, err := strconv.Atoi(string(c.text[1:]))
+	if err != nil {
+		return nil, tokErrf(pos, "invalid edit distance [%s]", c.text[1:])
+	}
+	return n, nil
+}
+
+func (p *parser) callonFuzziness1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onFuzziness1()
+}
+
+func (c *current) onRegexValue2() (any, error) {
 	pos := getpos(c)
 	c.text = bytes.Replace(c.text, []byte(`\/`), []byte(`/`), -1)
 	val, err := ast.NewRegexpVal(c.text, pos)
@@ -2115,23 +3709,67 @@ func (c *current) onRegexValue2() (interface{}, error) {
 	return val, nil
 }
 
-func (p *parser) callonRegexValue2() (interface{}, error) {
+func (p *parser) callonRegexValue2() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onRegexValue2()
 }
 
-func (c *current) onBoolValue1() (interface{}, error) {
+func (c *current) onFuncValue2(name any) (any, error) {
+	return ast.NewFuncVal(name.(string), nil, getpos(c))
+}
+
+func (p *parser) callonFuncValue2() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onFuncValue2(stack["name"])
+}
+
+func (c *current) onFuncValue10(name, args any) (any, error) {
+	return ast.NewFuncVal(name.(string), args.([]ast.Val), getpos(c))
+}
+
+func (p *parser) callonFuncValue10() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onFuncValue10(stack["name"], stack["args"])
+}
+
+func (c *current) onFuncName1() (any, error) {
+	return string(c.text), nil
+}
+
+func (p *parser) callonFuncName1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onFuncName1()
+}
+
+func (c *current) onFuncArgs1(first, rest any) (any, error) {
+	out := []ast.Val{first.(ast.Val)}
+	for _, v := range toAny(rest) {
+		out = append(out, toAny(v)[3].(ast.Val))
+	}
+	return out, nil
+}
+
+func (p *parser) callonFuncArgs1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onFuncArgs1(stack["first"], stack["rest"])
+}
+
+func (c *current) onBoolValue1() (any, error) {
 	return ast.NewBoolVal(c.text, getpos(c))
 }
 
-func (p *parser) callonBoolValue1() (interface{}, error) {
+func (p *parser) callonBoolValue1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onBoolValue1()
 }
 
-func (c *current) onFloatValue1() (interface{}, error) {
+func (c *current) onFloatValue1() (any, error) {
 	pos := getpos(c)
 	val, err := ast.NewFloatVal(c.text, getpos(c))
 	if err != nil {
@@ -2140,23 +3778,23 @@ func (c *current) onFloatValue1() (interface{}, error) {
 	return val, nil
 }
 
-func (p *parser) callonFloatValue1() (interface{}, error) {
+func (p *parser) callonFloatValue1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onFloatValue1()
 }
 
-func (c *current) onIntValue1() (interface{}, error) {
+func (c *current) onIntValue1() (any, error) {
 	return ast.NewIntVal(c.text, getpos(c))
 }
 
-func (p *parser) callonIntValue1() (interface{}, error) {
+func (p *parser) callonIntValue1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onIntValue1()
 }
 
-func (c *current) onIPValue1() (interface{}, error) {
+func (c *current) onIPValue1() (any, error) {
 	pos := getpos(c)
 	val, err := ast.NewNetVal(c.text, pos)
 	if err != nil {
@@ -2165,13 +3803,13 @@ func (c *current) onIPValue1() (interface{}, error) {
 	return val, nil
 }
 
-func (p *parser) callonIPValue1() (interface{}, error) {
+func (p *parser) callonIPValue1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onIPValue1()
 }
 
-func (c *current) onTimestamp1() (interface{}, error) {
+func (c *current) onTimestamp3() (any, error) {
 	pos := getpos(c)
 	val, err := ast.NewTimeVal(c.text, pos)
 	if err != nil {
@@ -2180,13 +3818,28 @@ func (c *current) onTimestamp1() (interface{}, error) {
 	return val, nil
 }
 
-func (p *parser) callonTimestamp1() (interface{}, error) {
+func (p *parser) callonTimestamp3() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onTimestamp3()
+}
+
+func (c *current) onRelativeTime1() (any, error) {
+	pos := getpos(c)
+	val, err := ast.NewRelativeTimeVal(c.text, pos)
+	if err != nil {
+		return nil, tokErr(pos, err)
+	}
+	return val, nil
+}
+
+func (p *parser) callonRelativeTime1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onTimestamp1()
+	return p.cur.onRelativeTime1()
 }
 
-func (c *current) onopNoArgs1() (interface{}, error) {
+func (c *current) onopNoArgs1() (any, error) {
 	var opOut ast.Op
 	switch string(c.text) {
 	case "exists":
@@ -2197,19 +3850,64 @@ func (c *current) onopNoArgs1() (interface{}, error) {
 	return opOut, nil
 }
 
-func (p *parser) callonopNoArgs1() (interface{}, error) {
+func (p *parser) callonopNoArgs1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onopNoArgs1()
 }
 
-func (c *current) onopComp1() (interface{}, error) {
+func (c *current) onOperation1(op, params any) (any, error) {
+	spec := operationSpec{op: op.(ast.Op)}
+	if params != nil {
+		for _, p := range params.([]opParam) {
+			spec.params = append(spec.params, p.name)
+			spec.paramPos = append(spec.paramPos, p.pos)
+		}
+	}
+	return spec, nil
+}
+
+func (p *parser) callonOperation1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onOperation1(stack["op"], stack["params"])
+}
+
+func (c *current) onOpParams1(first, rest any) (any, error) {
+	out := []opParam{first.(opParam)}
+	for _, v := range toAny(rest) {
+		out = append(out, toAny(v)[3].(opParam))
+	}
+	return out, nil
+}
+
+func (p *parser) callonOpParams1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onOpParams1(stack["first"], stack["rest"])
+}
+
+func (c *current) onOpParam1() (any, error) {
+	return opParam{name: string(c.text), pos: getpos(c)}, nil
+}
+
+func (p *parser) callonOpParam1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onOpParam1()
+}
+
+func (c *current) onopComp1() (any, error) {
 	var opOut ast.Op
 	switch string(c.text) {
 	case "><":
 		opOut = ast.BET
+	case "~=":
+		opOut = ast.EXI
 	case "~":
 		opOut = ast.SIM
+	case "=":
+		opOut = ast.EXA
 	case "<":
 		opOut = ast.LT
 	case "<=":
@@ -2222,7 +3920,7 @@ func (c *current) onopComp1() (interface{}, error) {
 	return opOut, nil
 }
 
-func (p *parser) callonopComp1() (interface{}, error) {
+func (p *parser) callonopComp1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
 	return p.cur.onopComp1()
@@ -2306,18 +4004,17 @@ func Entrypoint(ruleName string) Option {
 //
 // Example usage:
 //
-//     input := "input"
-//     stats := Stats{}
-//     _, err := Parse("input-file", []byte(input), Statistics(&stats, "no match"))
-//     if err != nil {
-//         log.Panicln(err)
-//     }
-//     b, err := json.MarshalIndent(stats.ChoiceAltCnt, "", "  ")
-//     if err != nil {
-//         log.Panicln(err)
-//     }
-//     fmt.Println(string(b))
-//
+//	input := "input"
+//	stats := Stats{}
+//	_, err := Parse("input-file", []byte(input), Statistics(&stats, "no match"))
+//	if err != nil {
+//	    log.Panicln(err)
+//	}
+//	b, err := json.MarshalIndent(stats.ChoiceAltCnt, "", "  ")
+//	if err != nil {
+//	    log.Panicln(err)
+//	}
+//	fmt.Println(string(b))
 func Statistics(stats *Stats, choiceNoMatch string) Option {
 	return func(p *parser) Option {
 		oldStats := p.Stats
@@ -2387,7 +4084,7 @@ func Recover(b bool) Option {
 
 // GlobalStore creates an Option to set a key to a certain value in
 // the globalStore.
-func GlobalStore(key string, value interface{}) Option {
+func GlobalStore(key string, value any) Option {
 	return func(p *parser) Option {
 		old := p.cur.globalStore[key]
 		p.cur.globalStore[key] = value
@@ -2397,7 +4094,7 @@ func GlobalStore(key string, value interface{}) Option {
 
 // InitState creates an Option to set a key to a certain value in
 // the global "state" store.
-func InitState(key string, value interface{}) Option {
+func InitState(key string, value any) Option {
 	return func(p *parser) Option {
 		old := p.cur.state[key]
 		p.cur.state[key] = value
@@ -2406,7 +4103,7 @@ func InitState(key string, value interface{}) Option {
 }
 
 // ParseFile parses the file identified by filename.
-func ParseFile(filename string, opts ...Option) (i interface{}, err error) {
+func ParseFile(filename string, opts ...Option) (i any, err error) {
 	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
@@ -2421,8 +4118,8 @@ func ParseFile(filename string, opts ...Option) (i interface{}, err error) {
 
 // ParseReader parses the data from r using filename as information in the
 // error messages.
-func ParseReader(filename string, r io.Reader, opts ...Option) (interface{}, error) {
-	b, err := ioutil.ReadAll(r)
+func ParseReader(filename string, r io.Reader, opts ...Option) (any, error) {
+	b, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
@@ -2432,7 +4129,7 @@ func ParseReader(filename string, r io.Reader, opts ...Option) (interface{}, err
 
 // Parse parses the data from b using filename as information in the
 // error messages.
-func Parse(filename string, b []byte, opts ...Option) (interface{}, error) {
+func Parse(filename string, b []byte, opts ...Option) (any, error) {
 	return newParser(filename, b, opts...).parse(g)
 }
 
@@ -2470,7 +4167,7 @@ type current struct {
 	globalStore storeDict
 }
 
-type storeDict map[string]interface{}
+type storeDict map[string]any
 
 // the AST types...
 
@@ -2483,30 +4180,30 @@ type rule struct {
 	pos         position
 	name        string
 	displayName string
-	expr        interface{}
+	expr        any
 }
 
 type choiceExpr struct {
 	pos          position
-	alternatives []interface{}
+	alternatives []any
 }
 
 type actionExpr struct {
 	pos  position
-	expr interface{}
-	run  func(*parser) (interface{}, error)
+	expr any
+	run  func(*parser) (any, error)
 }
 
 type recoveryExpr struct {
 	pos          position
-	expr         interface{}
-	recoverExpr  interface{}
+	expr         any
+	recoverExpr  any
 	failureLabel []string
 }
 
 type seqExpr struct {
 	pos   position
-	exprs []interface{}
+	exprs []any
 }
 
 type throwExpr struct {
@@ -2517,19 +4214,21 @@ type throwExpr struct {
 type labeledExpr struct {
 	pos   position
 	label string
-	expr  interface{}
+	expr  any
 }
 
 type expr struct {
 	pos  position
-	expr interface{}
+	expr any
 }
 
-type andExpr expr
-type notExpr expr
-type zeroOrOneExpr expr
-type zeroOrMoreExpr expr
-type oneOrMoreExpr expr
+type (
+	andExpr        expr
+	notExpr        expr
+	zeroOrOneExpr  expr
+	zeroOrMoreExpr expr
+	oneOrMoreExpr  expr
+)
 
 type ruleRefExpr struct {
 	pos  position
@@ -2670,7 +4369,7 @@ func (p *parser) setOptions(opts []Option) {
 }
 
 type resultTuple struct {
-	v   interface{}
+	v   any
 	b   bool
 	end savepoint
 }
@@ -2714,12 +4413,12 @@ type parser struct {
 	memoize bool
 	// memoization table for the packrat algorithm:
 	// map[offset in source] map[expression or rule] {value, match}
-	memo map[int]map[interface{}]resultTuple
+	memo map[int]map[any]resultTuple
 
 	// rules table, maps the rule identifier to the rule node
 	rules map[string]*rule
 	// variables stack, map of label to value
-	vstack []map[string]interface{}
+	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
 	rstack []*rule
 
@@ -2739,7 +4438,7 @@ type parser struct {
 
 	choiceNoMatch string
 	// recovery expression stack, keeps track of the currently available recovery expression, these are traversed in reverse
-	recoveryStack []map[string]interface{}
+	recoveryStack []map[string]any
 }
 
 // push a variable set on the vstack.
@@ -2759,7 +4458,7 @@ func (p *parser) pushV() {
 		return
 	}
 
-	m = make(map[string]interface{})
+	m = make(map[string]any)
 	p.vstack[len(p.vstack)-1] = m
 }
 
@@ -2775,7 +4474,7 @@ func (p *parser) popV() {
 }
 
 // push a recovery expression with its labels to the recoveryStack
-func (p *parser) pushRecovery(labels []string, expr interface{}) {
+func (p *parser) pushRecovery(labels []string, expr any) {
 	if cap(p.recoveryStack) == len(p.recoveryStack) {
 		// create new empty slot in the stack
 		p.recoveryStack = append(p.recoveryStack, nil)
@@ -2784,7 +4483,7 @@ func (p *parser) pushRecovery(labels []string, expr interface{}) {
 		p.recoveryStack = p.recoveryStack[:len(p.recoveryStack)+1]
 	}
 
-	m := make(map[string]interface{}, len(labels))
+	m := make(map[string]any, len(labels))
 	for _, fl := range labels {
 		m[fl] = expr
 	}
@@ -2809,14 +4508,19 @@ func (p *parser) print(prefix, s string) string {
 	return s
 }
 
+func (p *parser) printIndent(mark string, s string) string {
+	return p.print(strings.Repeat(" ", p.depth)+mark, s)
+}
+
 func (p *parser) in(s string) string {
+	res := p.printIndent(">", s)
 	p.depth++
-	return p.print(strings.Repeat(" ", p.depth)+">", s)
+	return res
 }
 
 func (p *parser) out(s string) string {
 	p.depth--
-	return p.print(strings.Repeat(" ", p.depth)+"<", s)
+	return p.printIndent("<", s)
 }
 
 func (p *parser) addErr(err error) {
@@ -2904,11 +4608,11 @@ func (p *parser) restore(pt savepoint) {
 // copies of the state to allow the parser to properly restore the state in
 // the case of backtracking.
 type Cloner interface {
-	Clone() interface{}
+	Clone() any
 }
 
 var statePool = &sync.Pool{
-	New: func() interface{} { return make(storeDict) },
+	New: func() any { return make(storeDict) },
 }
 
 func (sd storeDict) Discard() {
@@ -2950,7 +4654,7 @@ func (p *parser) sliceFrom(start savepoint) []byte {
 	return p.data[start.position.offset:p.pt.position.offset]
 }
 
-func (p *parser) getMemoized(node interface{}) (resultTuple, bool) {
+func (p *parser) getMemoized(node any) (resultTuple, bool) {
 	if len(p.memo) == 0 {
 		return resultTuple{}, false
 	}
@@ -2962,13 +4666,13 @@ func (p *parser) getMemoized(node interface{}) (resultTuple, bool) {
 	return res, ok
 }
 
-func (p *parser) setMemoized(pt savepoint, node interface{}, tuple resultTuple) {
+func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	if p.memo == nil {
-		p.memo = make(map[int]map[interface{}]resultTuple)
+		p.memo = make(map[int]map[any]resultTuple)
 	}
 	m := p.memo[pt.offset]
 	if m == nil {
-		m = make(map[interface{}]resultTuple)
+		m = make(map[any]resultTuple)
 		p.memo[pt.offset] = m
 	}
 	m[node] = tuple
@@ -2981,7 +4685,7 @@ func (p *parser) buildRulesTable(g *grammar) {
 	}
 }
 
-func (p *parser) parse(g *grammar) (val interface{}, err error) {
+func (p *parser) parse(g *grammar) (val any, err error) {
 	if len(g.rules) == 0 {
 		p.addErr(errNoRule)
 		return nil, p.errs.err()
@@ -3017,7 +4721,7 @@ func (p *parser) parse(g *grammar) (val interface{}, err error) {
 	}
 
 	p.read() // advance to first rune
-	val, ok = p.parseRule(startRule)
+	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
@@ -3058,36 +4762,52 @@ func listJoin(list []string, sep string, lastSep string) string {
 	}
 }
 
-func (p *parser) parseRule(rule *rule) (interface{}, bool) {
+func (p *parser) parseRuleMemoize(rule *rule) (any, bool) {
+	res, ok := p.getMemoized(rule)
+	if ok {
+		p.restore(res.end)
+		return res.v, res.b
+	}
+
+	startMark := p.pt
+	val, ok := p.parseRule(rule)
+	p.setMemoized(startMark, rule, resultTuple{val, ok, p.pt})
+
+	return val, ok
+}
+
+func (p *parser) parseRuleWrap(rule *rule) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseRule " + rule.name))
 	}
+	var (
+		val       any
+		ok        bool
+		startMark = p.pt
+	)
 
 	if p.memoize {
-		res, ok := p.getMemoized(rule)
-		if ok {
-			p.restore(res.end)
-			return res.v, res.b
-		}
+		val, ok = p.parseRuleMemoize(rule)
+	} else {
+		val, ok = p.parseRule(rule)
 	}
 
-	start := p.pt
+	if ok && p.debug {
+		p.printIndent("MATCH", string(p.sliceFrom(startMark)))
+	}
+	return val, ok
+}
+
+func (p *parser) parseRule(rule *rule) (any, bool) {
 	p.rstack = append(p.rstack, rule)
 	p.pushV()
-	val, ok := p.parseExpr(rule.expr)
+	val, ok := p.parseExprWrap(rule.expr)
 	p.popV()
 	p.rstack = p.rstack[:len(p.rstack)-1]
-	if ok && p.debug {
-		p.print(strings.Repeat(" ", p.depth)+"MATCH", string(p.sliceFrom(start)))
-	}
-
-	if p.memoize {
-		p.setMemoized(start, rule, resultTuple{val, ok, p.pt})
-	}
 	return val, ok
 }
 
-func (p *parser) parseExpr(expr interface{}) (interface{}, bool) {
+func (p *parser) parseExprWrap(expr any) (any, bool) {
 	var pt savepoint
 
 	if p.memoize {
@@ -3099,12 +4819,21 @@ func (p *parser) parseExpr(expr interface{}) (interface{}, bool) {
 		pt = p.pt
 	}
 
+	val, ok := p.parseExpr(expr)
+
+	if p.memoize {
+		p.setMemoized(pt, expr, resultTuple{val, ok, p.pt})
+	}
+	return val, ok
+}
+
+func (p *parser) parseExpr(expr any) (any, bool) {
 	p.ExprCnt++
 	if p.ExprCnt > p.maxExprCnt {
 		panic(errMaxExprCnt)
 	}
 
-	var val interface{}
+	var val any
 	var ok bool
 	switch expr := expr.(type) {
 	case *actionExpr:
@@ -3146,19 +4875,16 @@ func (p *parser) parseExpr(expr interface{}) (interface{}, bool) {
 	default:
 		panic(fmt.Sprintf("unknown expression type %T", expr))
 	}
-	if p.memoize {
-		p.setMemoized(pt, expr, resultTuple{val, ok, p.pt})
-	}
 	return val, ok
 }
 
-func (p *parser) parseActionExpr(act *actionExpr) (interface{}, bool) {
+func (p *parser) parseActionExpr(act *actionExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseActionExpr"))
 	}
 
 	start := p.pt
-	val, ok := p.parseExpr(act.expr)
+	val, ok := p.parseExprWrap(act.expr)
 	if ok {
 		p.cur.pos = start.position
 		p.cur.text = p.sliceFrom(start)
@@ -3172,12 +4898,12 @@ func (p *parser) parseActionExpr(act *actionExpr) (interface{}, bool) {
 		val = actVal
 	}
 	if ok && p.debug {
-		p.print(strings.Repeat(" ", p.depth)+"MATCH", string(p.sliceFrom(start)))
+		p.printIndent("MATCH", string(p.sliceFrom(start)))
 	}
 	return val, ok
 }
 
-func (p *parser) parseAndCodeExpr(and *andCodeExpr) (interface{}, bool) {
+func (p *parser) parseAndCodeExpr(and *andCodeExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseAndCodeExpr"))
 	}
@@ -3193,7 +4919,7 @@ func (p *parser) parseAndCodeExpr(and *andCodeExpr) (interface{}, bool) {
 	return nil, ok
 }
 
-func (p *parser) parseAndExpr(and *andExpr) (interface{}, bool) {
+func (p *parser) parseAndExpr(and *andExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseAndExpr"))
 	}
@@ -3201,7 +4927,7 @@ func (p *parser) parseAndExpr(and *andExpr) (interface{}, bool) {
 	pt := p.pt
 	state := p.cloneState()
 	p.pushV()
-	_, ok := p.parseExpr(and.expr)
+	_, ok := p.parseExprWrap(and.expr)
 	p.popV()
 	p.restoreState(state)
 	p.restore(pt)
@@ -3209,7 +4935,7 @@ func (p *parser) parseAndExpr(and *andExpr) (interface{}, bool) {
 	return nil, ok
 }
 
-func (p *parser) parseAnyMatcher(any *anyMatcher) (interface{}, bool) {
+func (p *parser) parseAnyMatcher(any *anyMatcher) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseAnyMatcher"))
 	}
@@ -3225,7 +4951,7 @@ func (p *parser) parseAnyMatcher(any *anyMatcher) (interface{}, bool) {
 	return p.sliceFrom(start), true
 }
 
-func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (interface{}, bool) {
+func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseCharClassMatcher"))
 	}
@@ -3306,7 +5032,7 @@ func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
 	m[alt]++
 }
 
-func (p *parser) parseChoiceExpr(ch *choiceExpr) (interface{}, bool) {
+func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseChoiceExpr"))
 	}
@@ -3318,7 +5044,7 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (interface{}, bool) {
 		state := p.cloneState()
 
 		p.pushV()
-		val, ok := p.parseExpr(alt)
+		val, ok := p.parseExprWrap(alt)
 		p.popV()
 		if ok {
 			p.incChoiceAltCnt(ch, altI)
@@ -3330,13 +5056,13 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (interface{}, bool) {
 	return nil, false
 }
 
-func (p *parser) parseLabeledExpr(lab *labeledExpr) (interface{}, bool) {
+func (p *parser) parseLabeledExpr(lab *labeledExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseLabeledExpr"))
 	}
 
 	p.pushV()
-	val, ok := p.parseExpr(lab.expr)
+	val, ok := p.parseExprWrap(lab.expr)
 	p.popV()
 	if ok && lab.label != "" {
 		m := p.vstack[len(p.vstack)-1]
@@ -3345,7 +5071,7 @@ func (p *parser) parseLabeledExpr(lab *labeledExpr) (interface{}, bool) {
 	return val, ok
 }
 
-func (p *parser) parseLitMatcher(lit *litMatcher) (interface{}, bool) {
+func (p *parser) parseLitMatcher(lit *litMatcher) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseLitMatcher"))
 	}
@@ -3367,7 +5093,7 @@ func (p *parser) parseLitMatcher(lit *litMatcher) (interface{}, bool) {
 	return p.sliceFrom(start), true
 }
 
-func (p *parser) parseNotCodeExpr(not *notCodeExpr) (interface{}, bool) {
+func (p *parser) parseNotCodeExpr(not *notCodeExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseNotCodeExpr"))
 	}
@@ -3383,7 +5109,7 @@ func (p *parser) parseNotCodeExpr(not *notCodeExpr) (interface{}, bool) {
 	return nil, !ok
 }
 
-func (p *parser) parseNotExpr(not *notExpr) (interface{}, bool) {
+func (p *parser) parseNotExpr(not *notExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseNotExpr"))
 	}
@@ -3392,7 +5118,7 @@ func (p *parser) parseNotExpr(not *notExpr) (interface{}, bool) {
 	state := p.cloneState()
 	p.pushV()
 	p.maxFailInvertExpected = !p.maxFailInvertExpected
-	_, ok := p.parseExpr(not.expr)
+	_, ok := p.parseExprWrap(not.expr)
 	p.maxFailInvertExpected = !p.maxFailInvertExpected
 	p.popV()
 	p.restoreState(state)
@@ -3401,16 +5127,16 @@ func (p *parser) parseNotExpr(not *notExpr) (interface{}, bool) {
 	return nil, !ok
 }
 
-func (p *parser) parseOneOrMoreExpr(expr *oneOrMoreExpr) (interface{}, bool) {
+func (p *parser) parseOneOrMoreExpr(expr *oneOrMoreExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseOneOrMoreExpr"))
 	}
 
-	var vals []interface{}
+	var vals []any
 
 	for {
 		p.pushV()
-		val, ok := p.parseExpr(expr.expr)
+		val, ok := p.parseExprWrap(expr.expr)
 		p.popV()
 		if !ok {
 			if len(vals) == 0 {
@@ -3423,19 +5149,19 @@ func (p *parser) parseOneOrMoreExpr(expr *oneOrMoreExpr) (interface{}, bool) {
 	}
 }
 
-func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (interface{}, bool) {
+func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseRecoveryExpr (" + strings.Join(recover.failureLabel, ",") + ")"))
 	}
 
 	p.pushRecovery(recover.failureLabel, recover.recoverExpr)
-	val, ok := p.parseExpr(recover.expr)
+	val, ok := p.parseExprWrap(recover.expr)
 	p.popRecovery()
 
 	return val, ok
 }
 
-func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (interface{}, bool) {
+func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseRuleRefExpr " + ref.name))
 	}
@@ -3449,20 +5175,20 @@ func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (interface{}, bool) {
 		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
 		return nil, false
 	}
-	return p.parseRule(rule)
+	return p.parseRuleWrap(rule)
 }
 
-func (p *parser) parseSeqExpr(seq *seqExpr) (interface{}, bool) {
+func (p *parser) parseSeqExpr(seq *seqExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseSeqExpr"))
 	}
 
-	vals := make([]interface{}, 0, len(seq.exprs))
+	vals := make([]any, 0, len(seq.exprs))
 
 	pt := p.pt
 	state := p.cloneState()
 	for _, expr := range seq.exprs {
-		val, ok := p.parseExpr(expr)
+		val, ok := p.parseExprWrap(expr)
 		if !ok {
 			p.restoreState(state)
 			p.restore(pt)
@@ -3473,7 +5199,7 @@ func (p *parser) parseSeqExpr(seq *seqExpr) (interface{}, bool) {
 	return vals, true
 }
 
-func (p *parser) parseStateCodeExpr(state *stateCodeExpr) (interface{}, bool) {
+func (p *parser) parseStateCodeExpr(state *stateCodeExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseStateCodeExpr"))
 	}
@@ -3485,14 +5211,14 @@ func (p *parser) parseStateCodeExpr(state *stateCodeExpr) (interface{}, bool) {
 	return nil, true
 }
 
-func (p *parser) parseThrowExpr(expr *throwExpr) (interface{}, bool) {
+func (p *parser) parseThrowExpr(expr *throwExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseThrowExpr"))
 	}
 
 	for i := len(p.recoveryStack) - 1; i >= 0; i-- {
 		if recoverExpr, ok := p.recoveryStack[i][expr.label]; ok {
-			if val, ok := p.parseExpr(recoverExpr); ok {
+			if val, ok := p.parseExprWrap(recoverExpr); ok {
 				return val, ok
 			}
 		}
@@ -3501,16 +5227,16 @@ func (p *parser) parseThrowExpr(expr *throwExpr) (interface{}, bool) {
 	return nil, false
 }
 
-func (p *parser) parseZeroOrMoreExpr(expr *zeroOrMoreExpr) (interface{}, bool) {
+func (p *parser) parseZeroOrMoreExpr(expr *zeroOrMoreExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseZeroOrMoreExpr"))
 	}
 
-	var vals []interface{}
+	var vals []any
 
 	for {
 		p.pushV()
-		val, ok := p.parseExpr(expr.expr)
+		val, ok := p.parseExprWrap(expr.expr)
 		p.popV()
 		if !ok {
 			return vals, true
@@ -3519,13 +5245,13 @@ func (p *parser) parseZeroOrMoreExpr(expr *zeroOrMoreExpr) (interface{}, bool) {
 	}
 }
 
-func (p *parser) parseZeroOrOneExpr(expr *zeroOrOneExpr) (interface{}, bool) {
+func (p *parser) parseZeroOrOneExpr(expr *zeroOrOneExpr) (any, bool) {
 	if p.debug {
 		defer p.out(p.in("parseZeroOrOneExpr"))
 	}
 
 	p.pushV()
-	val, _ := p.parseExpr(expr.expr)
+	val, _ := p.parseExprWrap(expr.expr)
 	p.popV()
 	// whether it matched or not, consider it a match
 	return val, true
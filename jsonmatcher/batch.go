@@ -0,0 +1,166 @@
+package jsonmatcher
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"runtime"
+	"sync"
+
+	"go.uber.org/atomic"
+)
+
+// batchChunkSize is the number of documents a MatchBatch worker claims at a
+// time, to limit contention on the shared index.
+const batchChunkSize = 64
+
+// parallelBatchSize is the number of documents FilterParallel reads before
+// handing them off to a worker.
+const parallelBatchSize = 256
+
+// Result is the result of matching a single document.
+type Result struct {
+	Matched bool
+	Err     error
+}
+
+// MatchBatch matches each document in docs, spread over GOMAXPROCS workers, and
+// returns the results in the same order. Statistics, if enabled, are
+// aggregated just as they are for [Matcher.Match].
+func (m *Matcher) MatchBatch(docs [][]byte) []Result {
+	results := make([]Result, len(docs))
+	workers := runtime.GOMAXPROCS(0)
+	if maxWorkers := (len(docs) + batchChunkSize - 1) / batchChunkSize; workers > maxWorkers {
+		workers = maxWorkers
+	}
+	var (
+		next atomic.Int64
+		wg   sync.WaitGroup
+	)
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				end := int(next.Add(batchChunkSize))
+				start := end - batchChunkSize
+				if start >= len(docs) {
+					return
+				}
+				if end > len(docs) {
+					end = len(docs)
+				}
+				for i := start; i < end; i++ {
+					results[i].Matched, results[i].Err = m.Match(docs[i])
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// streamBatch is a batch of documents read by FilterParallel. The documents are
+// stored contiguously in buf to avoid an allocation for each one.
+type streamBatch struct {
+	buf  []byte
+	docs []streamDoc
+	done chan struct{}
+}
+
+type streamDoc struct {
+	start, end int
+	line       int
+	matched    bool
+	err        error
+}
+
+// FilterParallel is like [Matcher.Filter], but matches documents over
+// GOMAXPROCS workers. Matching documents and errors are still written and
+// reported in the order they were read, and memory use is bounded by a small
+// number of batches of documents per worker.
+func (m *Matcher) FilterParallel(r io.Reader, w io.Writer, options ...FilterOption) error {
+	onError := newFilterOpts(options).onError
+	s := m.Stream(r)
+	workers := runtime.GOMAXPROCS(0)
+	var (
+		// batches are sent to ordered and work together. ordered preserves
+		// the input order for the writer, and its capacity bounds the number
+		// of batches in flight.
+		ordered = make(chan *streamBatch, 2*workers)
+		work    = make(chan *streamBatch, workers)
+		stop    = make(chan struct{})
+		readErr error
+	)
+	defer close(stop)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for b := range work {
+				for i := range b.docs {
+					d := &b.docs[i]
+					if d.err == nil {
+						d.matched, d.err = m.Match(b.buf[d.start:d.end])
+					}
+				}
+				close(b.done)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(work)
+		defer close(ordered)
+		for {
+			b := &streamBatch{done: make(chan struct{})}
+			for len(b.docs) < parallelBatchSize {
+				doc, line, docErr, err := s.readDoc()
+				if err != nil {
+					if err != io.EOF {
+						readErr = err
+					}
+					break
+				}
+				start := len(b.buf)
+				b.buf = append(b.buf, doc...)
+				b.docs = append(b.docs, streamDoc{start: start, end: len(b.buf), line: line, err: docErr})
+			}
+			if len(b.docs) == 0 {
+				return
+			}
+			select {
+			case ordered <- b:
+			case <-stop:
+				return
+			}
+			work <- b
+			if len(b.docs) < parallelBatchSize {
+				return
+			}
+		}
+	}()
+
+	bw := bufio.NewWriter(w)
+	var compacted bytes.Buffer
+	for b := range ordered {
+		<-b.done
+		for _, d := range b.docs {
+			if d.err != nil {
+				if onError != nil {
+					onError(&DocumentError{Line: d.line, Err: d.err})
+				}
+				continue
+			}
+			if !d.matched {
+				continue
+			}
+			if err := writeDoc(bw, b.buf[d.start:d.end], &compacted); err != nil {
+				return err
+			}
+		}
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return bw.Flush()
+}
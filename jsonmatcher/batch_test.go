@@ -0,0 +1,99 @@
+package jsonmatcher
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func batchTestDocs(n int) [][]byte {
+	docs := make([][]byte, n)
+	for i := range docs {
+		switch {
+		case i%97 == 0:
+			docs[i] = []byte(`{"n":`)
+		case i%13 == 0:
+			docs[i] = []byte(fmt.Sprintf("{\n  \"n\": %d,\n  \"even\": %t\n}", i, i%2 == 0))
+		default:
+			docs[i] = []byte(fmt.Sprintf(`{"n":%d,"even":%t}`, i, i%2 == 0))
+		}
+	}
+	return docs
+}
+
+func TestMatchBatch(t *testing.T) {
+	const query = `even:true AND n:<5000`
+	seq, err := NewMatcher(query, TrackQueryStats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := NewMatcher(query, TrackQueryStats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 1, 63, 64, 65, 10000} {
+		docs := batchTestDocs(n)
+		want := make([]Result, len(docs))
+		for i, doc := range docs {
+			want[i].Matched, want[i].Err = seq.Match(doc)
+		}
+		got := batch.MatchBatch(docs)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("MatchBatch of %d documents does not match sequential results", n)
+		}
+	}
+	seqStats, batchStats := seq.Stats(), batch.Stats()
+	if batchStats.TimesChecked != seqStats.TimesChecked || batchStats.TimesMatched != seqStats.TimesMatched {
+		t.Errorf("expected batch stats %d/%d to equal sequential stats %d/%d",
+			batchStats.TimesMatched, batchStats.TimesChecked,
+			seqStats.TimesMatched, seqStats.TimesChecked)
+	}
+}
+
+func TestFilterParallel(t *testing.T) {
+	m, err := NewMatcher(`even:true`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := batchTestDocs(5000)
+	input := string(bytes.Join(docs, []byte("\n")))
+
+	var wantOut bytes.Buffer
+	var wantErrs []string
+	if err := m.Filter(strings.NewReader(input), &wantOut, OnDocumentError(func(err error) {
+		wantErrs = append(wantErrs, err.Error())
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotOut bytes.Buffer
+	var gotErrs []string
+	if err := m.FilterParallel(strings.NewReader(input), &gotOut, OnDocumentError(func(err error) {
+		gotErrs = append(gotErrs, err.Error())
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if gotOut.String() != wantOut.String() {
+		t.Errorf("FilterParallel output differs from Filter")
+	}
+	if !reflect.DeepEqual(gotErrs, wantErrs) {
+		t.Errorf("FilterParallel errors differ from Filter:\n%q\n%q", gotErrs, wantErrs)
+	}
+	if len(gotErrs) == 0 {
+		t.Errorf("expected document errors")
+	}
+}
+
+func TestFilterParallelWriteError(t *testing.T) {
+	m, err := NewMatcher(`a:1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := strings.Repeat(`{"a":1}`+"\n", 100000)
+	err = m.FilterParallel(strings.NewReader(input), failingWriter{})
+	if err == nil || err.Error() != "write failed" {
+		t.Errorf("expected write error, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package jsonmatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// benchEvent builds a log event similar to those the matcher is typically run
+// against, with nItems entries in its request items array to vary its size.
+func benchEvent(i, nItems int) []byte {
+	type item struct {
+		SKU      string   `json:"sku"`
+		Name     string   `json:"name"`
+		Price    float64  `json:"price"`
+		Quantity int      `json:"quantity"`
+		Tags     []string `json:"tags"`
+	}
+	items := make([]item, nItems)
+	for j := range items {
+		items[j] = item{
+			SKU:      fmt.Sprintf("SKU-%06d", i*nItems+j),
+			Name:     fmt.Sprintf("Widget model %d with a reasonably long description", j),
+			Price:    float64(j%100) + 0.99,
+			Quantity: j % 7,
+			Tags:     []string{"hardware", "widgets", fmt.Sprintf("batch-%d", j%10)},
+		}
+	}
+	event := map[string]any{
+		"timestamp": "2023-03-10T14:15:09.123Z",
+		"level":     []string{"info", "warn", "error"}[i%3],
+		"message":   fmt.Sprintf("request %d completed after processing the order for the customer", i),
+		"service":   "checkout",
+		"host": map[string]any{
+			"name":    fmt.Sprintf("web-%02d.example.com", i%16),
+			"ip":      fmt.Sprintf("10.0.%d.%d", i%4, i%250),
+			"region":  "us-east-1",
+			"tags":    []string{"prod", "web", "checkout"},
+			"enabled": true,
+		},
+		"user": map[string]any{
+			"id":    i,
+			"name":  "Andy",
+			"email": "andy@example.com",
+			"roles": []string{"customer"},
+		},
+		"request": map[string]any{
+			"method":      "POST",
+			"path":        "/api/v2/orders",
+			"status":      []int{200, 201, 404, 500}[i%4],
+			"duration_ms": float64(i%1000) / 3,
+			"headers": map[string]string{
+				"user-agent":   "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36",
+				"content-type": "application/json",
+			},
+			"items": items,
+		},
+	}
+	b, err := json.Marshal(event)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+var benchQueries = []struct {
+	name  string
+	query string
+}{
+	{"field", `level:"error"`},
+	{"nested", `request.status:>=500 AND host.region:"us-east-1"`},
+	{"wildcard", `message:"*customer"`},
+	{"network", `host.ip:10.0.0.0/16`},
+	{"array", `request.items.sku:"SKU-000003"`},
+	// every field is checked, since none match
+	{"many", `level:"debug" OR service:"auth" OR host.name:"db-*" OR host.region:"eu-west-1" OR ` +
+		`host.enabled:false OR user.id:<0 OR user.name:"bob" OR user.email:"*@corp.example.com" OR ` +
+		`request.method:"GET" OR request.path:"/health" OR request.status:>=600 OR timestamp:<2000-01-01`},
+}
+
+var benchSizes = []struct {
+	name   string
+	nItems int
+}{
+	{"small", 2},
+	{"medium", 50},
+	{"large", 2000},
+}
+
+func BenchmarkMatch(b *testing.B) {
+	for _, size := range benchSizes {
+		doc := benchEvent(3, size.nItems)
+		for _, q := range benchQueries {
+			for _, mode := range validationModes {
+				name := fmt.Sprintf("%s/%s/%s", size.name, q.name, mode.name)
+				b.Run(name, func(b *testing.B) {
+					m, err := NewMatcher(q.query, mode.option)
+					if err != nil {
+						b.Fatal(err)
+					}
+					b.SetBytes(int64(len(doc)))
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						if _, err := m.Match(doc); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func BenchmarkMatchBatch(b *testing.B) {
+	docs := make([][]byte, 1000)
+	var total int64
+	for i := range docs {
+		docs[i] = benchEvent(i, 50)
+		total += int64(len(docs[i]))
+	}
+	m, err := NewMatcher(`request.status:>=500 AND host.region:"us-east-1"`, LazyValidation())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("sequential", func(b *testing.B) {
+		b.SetBytes(total)
+		for i := 0; i < b.N; i++ {
+			for _, doc := range docs {
+				if _, err := m.Match(doc); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		b.SetBytes(total)
+		for i := 0; i < b.N; i++ {
+			m.MatchBatch(docs)
+		}
+	})
+}
+
+// BenchmarkBlocklist matches a message against a large equality set, such as
+// a blocklist of terms.
+func BenchmarkBlocklist(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	terms := make([]string, 5000)
+	for i := range terms {
+		word := make([]byte, 5+r.Intn(8))
+		for j := range word {
+			word[j] = byte('a' + r.Intn(26))
+		}
+		terms[i] = strconv.Quote(string(word))
+	}
+	query := fmt.Sprintf(`message:(%s)`, strings.Join(terms, ","))
+	m, err := NewMatcher(query, TrustInput())
+	if err != nil {
+		b.Fatal(err)
+	}
+	doc := benchEvent(3, 2)
+	b.SetBytes(int64(len(doc)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.Match(doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
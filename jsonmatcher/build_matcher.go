@@ -2,89 +2,169 @@ package jsonmatcher
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/flowchartsman/aql/parser/ast"
 )
 
 type builder struct {
-	withStats bool
+	opts *matcherOpts
 	// track all expression node fields for returning detailed stats on what the
 	// query is encountering in the field
-	// integrate node_stats
+	fieldStats map[string]*FieldStats
+	// tracks current subdoc prefix so that field stats use the full path
+	subdocPrefix ast.Path
+	// paths to extract from the document root in a single pass
+	plan *pathPlan
+	// full paths of the fields the query references, to check the fields
+	// given to FieldDateLayouts
+	fields map[string]bool
 }
 
-func newBuilder(withStats bool) *builder {
+func newBuilder(opts *matcherOpts) *builder {
 	b := &builder{
-		withStats: withStats,
+		opts:   opts,
+		plan:   newPathPlan(),
+		fields: map[string]bool{},
+	}
+	if opts.withFieldStats {
+		b.fieldStats = map[string]*FieldStats{}
 	}
-	// TODO: Fieldstats (optional)
-	// if withStats {
-	// 	integrate field_stats
-	// }
 	return b
 }
 
+// planPath adds a path to the plan, if it is relative to the document root,
+// returning the indexes of its prefixes for getField.
+func (b *builder) planPath(field ast.Path) []int {
+	if len(b.subdocPrefix) > 0 {
+		// relative to a subdocument
+		return nil
+	}
+	return b.plan.add(field)
+}
+
+// getFieldStats returns the shared stats for a field, relative to the current
+// subdoc, creating them if necessary. It returns nil if field stats are
+// disabled.
+func (b *builder) getFieldStats(field ast.Path, expecting ...expectedType) *FieldStats {
+	if b.fieldStats == nil {
+		return nil
+	}
+	name := b.fullPath(field)
+	fs, ok := b.fieldStats[name]
+	if !ok {
+		fs = NewFieldStats()
+		b.fieldStats[name] = fs
+	}
+	fs.expect(expecting...)
+	return fs
+}
+
+// fullPath returns the name of a field relative to the document root, rather
+// than the current subdoc.
+func (b *builder) fullPath(field ast.Path) string {
+	fullPath := make(ast.Path, 0, len(b.subdocPrefix)+len(field))
+	fullPath = append(fullPath, b.subdocPrefix...)
+	fullPath = append(fullPath, field...)
+	return fullPath.String()
+}
+
+// dateParser returns the parser for datetime values in a field, using the
+// layouts set for it with FieldDateLayouts, if any.
+func (b *builder) dateParser(field ast.Path, fs *FieldStats) *dateParser {
+	name := b.fullPath(field)
+	b.fields[name] = true
+	layouts := b.opts.dateLayouts
+	if fl, ok := b.opts.fieldDateLayouts[name]; ok {
+		layouts = fl
+	}
+	return &dateParser{
+		layouts:    layouts,
+		loc:        b.opts.location,
+		fieldStats: fs,
+	}
+}
+
+// checkFieldDateLayouts returns an error for the first field, in sorted order,
+// given to FieldDateLayouts that the query does not reference, which is most
+// likely a typo. It must be called after build.
+func (b *builder) checkFieldDateLayouts() error {
+	var unknown []string
+	for field := range b.opts.fieldDateLayouts {
+		if !b.fields[field] {
+			unknown = append(unknown, field)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("date layouts for field %s, which the query does not reference", unknown[0])
+}
+
 func (b *builder) build(node ast.Node) boolNode {
 	// make a set of expectedtypes during build to build fieldstats
 	// var matcher matcherNode
 	switch n := node.(type) {
 	case *ast.AndNode:
-		node := &andNode{
-			left:  b.build(n.Left),
-			right: b.build(n.Right),
+		node := &andNode{}
+		if b.opts.optimize {
+			node.children, node.order = b.buildOptimized(andOperands(n), false)
+		} else {
+			node.children = []boolNode{b.build(n.Left), b.build(n.Right)}
 		}
-		if b.withStats {
-			node.nodeStats = &nodeStats{
-				nodeName: "AND",
-			}
+		if b.opts.withStats {
+			node.nodeStats = newNodeStats(NodeAnd, "AND")
 		}
 		return node
 	case *ast.OrNode:
-		node := &orNode{
-			left:  b.build(n.Left),
-			right: b.build(n.Right),
+		node := &orNode{}
+		if b.opts.optimize {
+			node.children, node.order = b.buildOptimized(orOperands(n), true)
+		} else {
+			node.children = []boolNode{b.build(n.Left), b.build(n.Right)}
 		}
-		if b.withStats {
-			node.nodeStats = &nodeStats{
-				nodeName: "OR",
-			}
+		if b.opts.withStats {
+			node.nodeStats = newNodeStats(NodeOr, "OR")
 		}
 		return node
 	case *ast.NotNode:
 		node := &notNode{
 			sub: b.build(n.Expr),
 		}
-		if b.withStats {
-			node.nodeStats = &nodeStats{
-				nodeName: "NOT",
-			}
+		if b.opts.withStats {
+			node.nodeStats = newNodeStats(NodeNot, "NOT")
+		}
+		return node
+	case *ast.SubdocNode:
+		// subdocument paths are relative to the enclosing subdocument, so
+		// nested subdocs need no additional tracking
+		node := &subdocNode{
+			name:       ast.FieldString(n.Field) + "{}",
+			path:       n.Field,
+			planned:    b.planPath(n.Field),
+			opts:       b.opts,
+			fieldStats: b.getFieldStats(n.Field, expectObject),
+		}
+		b.subdocPrefix = append(b.subdocPrefix, n.Field...)
+		node.sub = b.build(n.Expr)
+		b.subdocPrefix = b.subdocPrefix[:len(b.subdocPrefix)-len(n.Field)]
+		if b.opts.withStats {
+			node.nodeStats = newNodeStats(NodeSubdoc, node.name)
 		}
 		return node
-	// case *ast.SubdocNode:
-	// tracks current subdoc prefix so they can be nested
-	// subdocPrefix []string
-	// 	b.subdocPrefix = append(b.subdocPrefix, n.Field...)
-	// 	node := &subdocNode{
-	// 		prefix: b.subdocPrefix,
-	// 		sub:    b.build(n.Expr),
-	// 	}
-	// 	if b.withStats {
-	// 		node.nodeStats = &nodeStats{
-	// 			nodeName: ast.FieldString(n.Field) + "{}",
-	// 		}
-	// 	}
-	// 	b.subdocPrefix = b.subdocPrefix[:len(b.subdocPrefix)-len(n.Field)]
-	// 	return node
 	case *ast.ExprNode:
 		node := &exprNode{
-			path: n.Field,
+			name:       n.FriendlyString(),
+			path:       n.Field,
+			planned:    b.planPath(n.Field),
+			opts:       b.opts,
+			fieldStats: b.getFieldStats(n.Field, expectedTypes(n)...),
 		}
-		if b.withStats {
-			node.nodeStats = &nodeStats{
-				nodeName: n.FriendlyString(),
-			}
+		node.dates = b.dateParser(n.Field, node.fieldStats)
+		if b.opts.withStats {
+			node.nodeStats = newNodeStats(NodeExpr, node.name)
 		}
-		// would assign expected types here
 		// TODO: single matcher and unify
 		// closures?
 		switch n.Op {
@@ -100,20 +180,22 @@ func (b *builder) build(node ast.Node) boolNode {
 		// binary:
 		case ast.LT, ast.LTE, ast.GT, ast.GTE:
 			node.exprs = []fieldExpr{
-				exprNumeric(n.Op, n.RVals),
+				exprNumeric(n.Op, n.RVals, b.opts),
 			}
 		// ternary
 		case ast.BET:
 			node.exprs = []fieldExpr{
-				exprBetween(n.RVals),
+				exprBetween(n, b.opts),
 			}
 		// n-ary
 		case ast.EQ:
-			node.exprs = exprEQ(n.RVals)
+			node.exprs = exprEQ(n.RVals, b.opts)
 		case ast.SIM:
-			node.exprs = exprEQ(n.RVals)
-			// exprSim Deprecated
-			// node.exprs = exprSim(n.RVals)
+			node.exprs = exprSim(n, b.opts)
+		case ast.EXA, ast.EXI:
+			node.exprs = []fieldExpr{
+				exprExactSet(n.RVals, n.Op == ast.EXI),
+			}
 		default:
 			// backstop
 			panic(fmt.Sprintf("undefined operation: [%s]", n.Op))
@@ -123,3 +205,29 @@ func (b *builder) build(node ast.Node) boolNode {
 	// return matcher
 	return nil
 }
+
+// expectedTypes returns the types of values an expression expects to find.
+func expectedTypes(n *ast.ExprNode) []expectedType {
+	switch n.Op {
+	case ast.EXS:
+		return []expectedType{expectExists}
+	case ast.NUL:
+		return []expectedType{expectNull}
+	}
+	var out []expectedType
+	for _, rv := range n.RVals {
+		switch rv.(type) {
+		case *ast.StringVal, *ast.RegexpVal:
+			out = append(out, expectString)
+		case *ast.IntVal, *ast.FloatVal:
+			out = append(out, expectNumeric)
+		case *ast.BoolVal:
+			out = append(out, expectBoolean)
+		case *ast.TimeVal:
+			out = append(out, expectDatetime)
+		case *ast.NetVal:
+			out = append(out, expectNetwork)
+		}
+	}
+	return out
+}
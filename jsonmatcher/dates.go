@@ -0,0 +1,140 @@
+package jsonmatcher
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/buger/jsonparser"
+)
+
+// DateLayout is a way of writing datetime values in documents, for the
+// [DateLayouts] and [FieldDateLayouts] options. A layout either reads strings
+// with a Go time layout, or reads numbers as time since the Unix epoch.
+type DateLayout struct {
+	layout string
+	// epoch is the unit of numeric values, if set
+	epoch time.Duration
+}
+
+var (
+	// RFC3339 reads RFC3339 strings, with or without fractional seconds.
+	RFC3339 = Layout(time.RFC3339Nano)
+	// FullDate reads dates written YYYY-MM-DD, which are taken to be midnight
+	// in the matcher's time zone.
+	FullDate = Layout(`2006-01-02`)
+	// EpochSeconds reads numbers as seconds since the Unix epoch. Fractions of
+	// a second are allowed.
+	EpochSeconds = Epoch(time.Second)
+	// EpochMillis reads numbers as milliseconds since the Unix epoch.
+	EpochMillis = Epoch(time.Millisecond)
+	// EpochMicros reads numbers as microseconds since the Unix epoch.
+	EpochMicros = Epoch(time.Microsecond)
+	// EpochNanos reads numbers as nanoseconds since the Unix epoch.
+	EpochNanos = Epoch(time.Nanosecond)
+)
+
+// Layout returns a DateLayout that reads strings with a Go time layout, as
+// used by [time.Parse]. Values without a time zone are read in the matcher's
+// time zone.
+func Layout(layout string) DateLayout {
+	return DateLayout{layout: layout}
+}
+
+// Epoch returns a DateLayout that reads numbers as a count of unit since the
+// Unix epoch.
+func Epoch(unit time.Duration) DateLayout {
+	return DateLayout{epoch: unit}
+}
+
+// dateParser reads datetime values from the documents found in a single field,
+// recording those that its layouts cannot read in the field's statistics.
+type dateParser struct {
+	// layouts are tried in order. If there are none, values are read with
+	// dateparse, which accepts almost any format.
+	layouts    []DateLayout
+	loc        *time.Location
+	fieldStats *FieldStats
+}
+
+// recordsFailures reports whether values that cannot be read should be
+// recorded in the field's statistics, which only the first expression to read
+// the field in the document in st does. Without layouts, dateparse is tried on
+// every string in the field, most of which were never meant to be dates, so
+// nothing is recorded.
+func (d *dateParser) recordsFailures(st *evalState) bool {
+	return d.fieldStats != nil && d.layouts != nil && d.fieldStats.markDatesRead(st)
+}
+
+// parse returns the nanoseconds since the Unix epoch of a datetime value. If
+// record is set, strings and numbers that cannot be read are recorded in the
+// field's statistics.
+func (d *dateParser) parse(v jsonValue, record bool) (int64, bool) {
+	if v.dataType != jsonparser.String && v.dataType != jsonparser.Number {
+		return 0, false
+	}
+	ns, ok := d.parseValue(v)
+	if !ok && record {
+		d.fieldStats.markDateFailure(v)
+	}
+	return ns, ok
+}
+
+func (d *dateParser) parseValue(v jsonValue) (int64, bool) {
+	if d.layouts == nil {
+		// TODO: tighten this up for numstrings. Probably want to be more
+		// careful about what we consider a date with how flexible dateparse
+		// is. The DateLayouts option is the way to do this for now.
+		sv, ok := getStringVal(v)
+		if !ok {
+			return 0, false
+		}
+		t, err := dateparse.ParseIn(sv, d.loc)
+		if err != nil {
+			return 0, false
+		}
+		return t.UnixNano(), true
+	}
+	var sv string
+	if v.dataType == jsonparser.String {
+		s, err := jsonparser.ParseString(v.data)
+		if err != nil {
+			return 0, false
+		}
+		sv = s
+	}
+	for _, l := range d.layouts {
+		switch {
+		case l.epoch != 0 && v.dataType == jsonparser.Number:
+			if ns, ok := epochNanos(string(v.data), l.epoch); ok {
+				return ns, true
+			}
+		case l.epoch == 0 && v.dataType == jsonparser.String:
+			if t, err := time.ParseInLocation(l.layout, sv, d.loc); err == nil {
+				return t.UnixNano(), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// epochNanos converts a count of unit since the Unix epoch to nanoseconds,
+// failing if the result is out of range.
+func epochNanos(num string, unit time.Duration) (int64, bool) {
+	if i, err := strconv.ParseInt(num, 10, 64); err == nil {
+		if i > math.MaxInt64/int64(unit) || i < math.MinInt64/int64(unit) {
+			return 0, false
+		}
+		return i * int64(unit), true
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, false
+	}
+	ns := f * float64(unit)
+	if math.IsNaN(ns) || ns >= math.MaxInt64 || ns < math.MinInt64 {
+		return 0, false
+	}
+	return int64(ns), true
+}
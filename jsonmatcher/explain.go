@@ -0,0 +1,126 @@
+package jsonmatcher
+
+import "encoding/json"
+
+// Explanation describes how a query was evaluated against a single document.
+// Like [MatchStats], it mirrors the structure of the query.
+type Explanation struct {
+	NodeName string `json:"node_name"`
+	NodeType string `json:"node_type"`
+	Matched  bool   `json:"matched"`
+	// Matches describes the values that satisfied an expression, for
+	// expressions that can report them, such as the words found by a fuzzy
+	// match.
+	Matches  []string       `json:"matches,omitempty"`
+	Children []*Explanation `json:"children,omitempty"`
+}
+
+// explainer is implemented by field expressions that can describe the values
+// they matched.
+type explainer interface {
+	explain(field *field) []string
+}
+
+// Explain evaluates the query against a document like [Matcher.Match], and
+// returns an explanation of the result. Unlike Match, every child of an AND or
+// OR node is evaluated, so that the explanation is complete. Explain does not
+// record statistics.
+func (m *Matcher) Explain(data []byte) (*Explanation, error) {
+	if m.opts.validation == validateFull && !json.Valid(data) {
+		return nil, ErrInvalidJSON
+	}
+	st := m.states.Get().(*evalState)
+	defer func() {
+		st.reset()
+		m.states.Put(st)
+	}()
+	ex := m.root.explain(data, st)
+	if st.invalid {
+		return nil, ErrInvalidJSON
+	}
+	return ex, nil
+}
+
+func (a *andNode) explain(root []byte, st *evalState) *Explanation {
+	ex := &Explanation{
+		NodeName: "AND",
+		NodeType: NodeAnd,
+		Matched:  true,
+	}
+	for _, c := range a.children {
+		cex := c.explain(root, st)
+		ex.Matched = ex.Matched && cex.Matched
+		ex.Children = append(ex.Children, cex)
+	}
+	return ex
+}
+
+func (o *orNode) explain(root []byte, st *evalState) *Explanation {
+	ex := &Explanation{
+		NodeName: "OR",
+		NodeType: NodeOr,
+	}
+	for _, c := range o.children {
+		cex := c.explain(root, st)
+		ex.Matched = ex.Matched || cex.Matched
+		ex.Children = append(ex.Children, cex)
+	}
+	return ex
+}
+
+func (n *notNode) explain(root []byte, st *evalState) *Explanation {
+	sub := n.sub.explain(root, st)
+	return &Explanation{
+		NodeName: "NOT",
+		NodeType: NodeNot,
+		Matched:  !sub.Matched,
+		Children: []*Explanation{sub},
+	}
+}
+
+// explain reports the subquery for the first object that satisfied it, or for
+// the last one checked if none did.
+func (s *subdocNode) explain(root []byte, st *evalState) *Explanation {
+	ex := &Explanation{
+		NodeName: s.name,
+		NodeType: NodeSubdoc,
+	}
+	field := getField(s.path, s.planned, root, s.opts, st)
+	var sub *Explanation
+	for _, doc := range field.subdocs() {
+		sub = s.sub.explain(doc.data, st)
+		if sub.Matched {
+			ex.Matched = true
+			break
+		}
+	}
+	if sub != nil {
+		ex.Children = []*Explanation{sub}
+	}
+	return ex
+}
+
+func (e exprNode) explain(root []byte, st *evalState) *Explanation {
+	ex := &Explanation{
+		NodeName: e.name,
+		NodeType: NodeExpr,
+	}
+	field := getField(e.path, e.planned, root, e.opts, st)
+	field.dates = e.dates
+	if len(field.values) == 0 {
+		return ex
+	}
+	for _, m := range e.exprs {
+		if x, ok := m.(explainer); ok {
+			if matches := x.explain(field); len(matches) > 0 {
+				ex.Matched = true
+				ex.Matches = append(ex.Matches, matches...)
+			}
+			continue
+		}
+		if m.matches(field) {
+			ex.Matched = true
+		}
+	}
+	return ex
+}
@@ -6,23 +6,39 @@ import (
 	"github.com/flowchartsman/aql/parser/ast"
 )
 
-func exprBetween(RVals []ast.Val) fieldExpr {
-	if len(RVals) != 2 {
+// exprBetween returns a matcher for a range. A range with only one bounded
+// end is the same as a single comparison, so [1 TO *] is matched as >=1.
+func exprBetween(n *ast.ExprNode, opts *matcherOpts) fieldExpr {
+	lo, hi := n.RangeValues()
+	switch {
+	case lo == nil && hi == nil:
 		// backstop
-		panic(fmt.Sprintf("betweenMatcher expects two constant values - got %d", len(RVals)))
+		panic("betweenMatcher expects at least one bounded end")
+	case lo == nil:
+		op := ast.LTE
+		if n.Bounds[1] == ast.BoundExclusive {
+			op = ast.LT
+		}
+		return exprNumeric(op, []ast.Val{hi}, opts)
+	case hi == nil:
+		op := ast.GTE
+		if n.Bounds[0] == ast.BoundExclusive {
+			op = ast.GT
+		}
+		return exprNumeric(op, []ast.Val{lo}, opts)
 	}
-	switch RVals[0].(type) {
+	switch lo.(type) {
 	case *ast.FloatVal, *ast.IntVal:
 		// numeric between
 		var constantValues [2]float64
 
-		switch v := RVals[0].(type) {
+		switch v := lo.(type) {
 		case *ast.FloatVal:
 			constantValues[0] = v.Value()
 		case *ast.IntVal:
 			constantValues[0] = float64(v.Value())
 		}
-		switch v := RVals[1].(type) {
+		switch v := hi.(type) {
 		case *ast.FloatVal:
 			constantValues[1] = v.Value()
 		case *ast.IntVal:
@@ -31,20 +47,23 @@ func exprBetween(RVals []ast.Val) fieldExpr {
 
 		return &exprFloat{
 			values: constantValues,
+			bounds: n.Bounds,
 			op:     ast.BET,
 		}
 	case *ast.TimeVal:
 		// datetime between
 		// 2nd argument guaranteed by validator
-		return &exprDatetime{
-			values: [2]int64{
-				RVals[0].(*ast.TimeVal).Value().UnixNano(),
-				RVals[1].(*ast.TimeVal).Value().UnixNano(),
-			},
-			op: ast.BET,
-		}
+		return exprTime(ast.BET, n.Bounds, []*ast.TimeVal{lo.(*ast.TimeVal), hi.(*ast.TimeVal)}, opts)
 	default:
 		// backstop
-		panic(fmt.Sprintf("bad value type for betweenMatcher: %T", RVals[0]))
+		panic(fmt.Sprintf("bad value type for betweenMatcher: %T", lo))
 	}
 }
+
+// inRange reports whether a value is within a range with the given bounds,
+// from how it compares to the lower and upper values: -1 if less, 0 if equal
+// and 1 if greater. Both ends must be bounded.
+func inRange(bounds [2]ast.Bound, cmpLo, cmpHi int) bool {
+	return (cmpLo > 0 || cmpLo == 0 && bounds[0] == ast.BoundInclusive) &&
+		(cmpHi < 0 || cmpHi == 0 && bounds[1] == ast.BoundInclusive)
+}
@@ -2,44 +2,109 @@ package jsonmatcher
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/flowchartsman/aql/parser/ast"
 )
 
 type exprDatetime struct {
 	values [2]int64
+	// bounds are the bounds of a range, for BET
+	bounds [2]ast.Bound
 	op     ast.Op
+	// resolve returns the values at the time of the match, if any of them are
+	// relative to the current time.
+	resolve func() [2]int64
 }
 
-func (e *exprDatetime) matches(field *field) bool {
-	for _, v := range field.scalarValues() {
-		dv, ok := getDatetimeVal(v)
-		if !ok {
-			continue
+// exprTime returns a matcher comparing datetimes in a document with query
+// values by op, with bounds for a range. The values of an equality that stand
+// for a whole period, such as a full date or a rounded relative value like
+// now/d, match anything within it. Otherwise, as with Elasticsearch date math,
+// a rounded relative value is taken from the end of its period where that
+// includes the whole of it, so ts:<=now/d includes all of today, and ts:>now/d
+// excludes it.
+func exprTime(op ast.Op, bounds [2]ast.Bound, tvs []*ast.TimeVal, opts *matcherOpts) *exprDatetime {
+	e := &exprDatetime{op: op, bounds: bounds}
+	span := op == ast.EQ && (tvs[0].DayOnly() || tvs[0].Rounded())
+	if span {
+		e.op = ast.BET
+	}
+	values := func(now time.Time) [2]int64 {
+		var out [2]int64
+		if span {
+			start, end := timeSpan(tvs[0], now, opts.location)
+			out[0], out[1] = start.UnixNano(), end.UnixNano()-1
+			return out
+		}
+		for i, tv := range tvs {
+			start, end := timeSpan(tv, now, opts.location)
+			if tv.Rounded() && roundsUp(op, bounds, i) {
+				out[i] = end.UnixNano() - 1
+			} else {
+				out[i] = start.UnixNano()
+			}
+		}
+		return out
+	}
+	relative := false
+	for _, tv := range tvs {
+		relative = relative || tv.Relative()
+	}
+	if relative {
+		e.resolve = func() [2]int64 {
+			return values(opts.now())
+		}
+	} else {
+		e.values = values(time.Time{})
+	}
+	return e
+}
+
+// roundsUp reports whether the value at i of a comparison by op is taken from
+// the end of the period it is rounded to, rather than the start.
+func roundsUp(op ast.Op, bounds [2]ast.Bound, i int) bool {
+	switch op {
+	case ast.GT, ast.LTE:
+		return true
+	case ast.BET:
+		if i == 0 {
+			return bounds[0] == ast.BoundExclusive
 		}
+		return bounds[1] == ast.BoundInclusive
+	}
+	return false
+}
+
+func (e *exprDatetime) matches(field *field) bool {
+	values := e.values
+	if e.resolve != nil {
+		values = e.resolve()
+	}
+	for _, dv := range field.datetimeValues() {
 		switch e.op {
 		case ast.EQ:
-			if dv == e.values[0] {
+			if dv == values[0] {
 				return true
 			}
 		case ast.LT:
-			if dv < e.values[0] {
+			if dv < values[0] {
 				return true
 			}
 		case ast.LTE:
-			if dv <= e.values[0] {
+			if dv <= values[0] {
 				return true
 			}
 		case ast.GT:
-			if dv > e.values[0] {
+			if dv > values[0] {
 				return true
 			}
 		case ast.GTE:
-			if dv >= e.values[0] {
+			if dv >= values[0] {
 				return true
 			}
 		case ast.BET:
-			if dv >= e.values[0] && dv <= e.values[1] {
+			if inRange(e.bounds, compareInt(dv, values[0]), compareInt(dv, values[1])) {
 				return true
 			}
 		// backstop
@@ -49,3 +114,32 @@ func (e *exprDatetime) matches(field *field) bool {
 	}
 	return false
 }
+
+// timeSpan returns the period a query datetime value stands for, from start up
+// to, but not including, end, which is equal to start for a single moment.
+func timeSpan(tv *ast.TimeVal, now time.Time, loc *time.Location) (start, end time.Time) {
+	switch {
+	case tv.Relative():
+		return tv.Span(now.In(loc))
+	case tv.DayOnly():
+		if l := tv.Location(); l != nil {
+			loc = l
+		}
+		t := tv.Value()
+		// days are not always 24h long, so find the start of the next one in
+		// the same location
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 0, 1)
+	}
+	return tv.Value(), tv.Value()
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
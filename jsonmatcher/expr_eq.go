@@ -2,27 +2,41 @@ package jsonmatcher
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/flowchartsman/aql/parser/ast"
 )
 
-// TODO: some optimizations possible here, including multi-term string matchers,
-// which can be unified into a singular regular expression.
-//
-// i.e: combinedRVals := CoalesceRVals(n.RVals) for vtype, RVals := range
-// combinedRVals
-func exprEQ(RVals []ast.Val) []fieldExpr {
+// exprEQ returns matchers for an equality set. Literal ASCII strings are
+// combined into a single exprStringSet for each case sensitivity when there is
+// more than one.
+func exprEQ(RVals []ast.Val, opts *matcherOpts) []fieldExpr {
 	if len(RVals) < 1 {
 		// backstop
 		panic("eqMatcher expects at least one constant value")
 	}
 	matchers := make([]fieldExpr, 0, len(RVals))
+	// set terms, indexed by case sensitivity
+	var setTerms [2][]string
+	for _, r := range RVals {
+		if sv, ok := r.(*ast.StringVal); ok && isSetTerm(sv.Value()) {
+			cs := opts.isCaseSensitive(sv)
+			setTerms[b2i(cs)] = append(setTerms[b2i(cs)], sv.Value())
+		}
+	}
+	for i, terms := range setTerms {
+		if len(terms) > 1 {
+			matchers = append(matchers, newStringSet(terms, i == 1))
+		}
+	}
 	for _, r := range RVals {
 		switch rval := r.(type) {
 		case *ast.StringVal:
 			str := rval.Value()
-			matchers = append(matchers, getStringMatcher(str))
+			cs := opts.isCaseSensitive(rval)
+			if len(setTerms[b2i(cs)]) > 1 && isSetTerm(str) {
+				continue
+			}
+			matchers = append(matchers, getStringMatcher(str, cs))
 		case *ast.RegexpVal:
 			matchers = append(matchers, &exprRegexp{
 				value: rval.Value(),
@@ -43,25 +57,9 @@ func exprEQ(RVals []ast.Val) []fieldExpr {
 				op:    ast.EQ,
 			})
 		case *ast.TimeVal:
-			if rval.DayOnly() {
-				endOfDay := rval.Value().Add(24*time.Hour).UnixNano() - 1
-				matchers = append(matchers, &exprDatetime{
-					values: [2]int64{
-						rval.Value().UnixNano(),
-						endOfDay,
-					},
-					op: ast.BET,
-				})
-			} else {
-				matchers = append(matchers, &exprDatetime{
-					values: [2]int64{rval.Value().UnixNano()},
-					op:     ast.EQ,
-				})
-			}
+			matchers = append(matchers, exprTime(ast.EQ, [2]ast.Bound{}, []*ast.TimeVal{rval}, opts))
 		case *ast.NetVal:
-			matchers = append(matchers, &exprNet{
-				value: rval.Value(),
-			})
+			matchers = append(matchers, newExprNet(rval))
 		default:
 			// backstop
 			panic(fmt.Sprintf("bad value type for numeric matcher: %T", RVals[0]))
@@ -69,3 +67,10 @@ func exprEQ(RVals []ast.Val) []fieldExpr {
 	}
 	return matchers
 }
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package jsonmatcher
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/buger/jsonparser"
+	"github.com/flowchartsman/aql/parser/ast"
+)
+
+// exprExact matches JSON string values that are equal to one of a set of
+// strings in their entirety. Unlike equality, there are no wildcards or word
+// boundaries, and only string values are considered, so `name:="1"` will not
+// match the number 1.
+type exprExact struct {
+	values   map[string]struct{}
+	foldCase bool
+}
+
+func exprExactSet(RVals []ast.Val, foldCase bool) *exprExact {
+	if len(RVals) < 1 {
+		// backstop
+		panic("exact matcher expects at least one constant value")
+	}
+	e := &exprExact{
+		values:   make(map[string]struct{}, len(RVals)),
+		foldCase: foldCase,
+	}
+	for _, r := range RVals {
+		sv, ok := r.(*ast.StringVal)
+		if !ok {
+			// backstop
+			panic(fmt.Sprintf("bad value type for exact matcher: %T", r))
+		}
+		e.values[e.key(sv.Value())] = struct{}{}
+	}
+	return e
+}
+
+func (e *exprExact) matches(field *field) bool {
+	for _, v := range field.scalarValues() {
+		if v.dataType != jsonparser.String {
+			continue
+		}
+		str, ok := getStringVal(v)
+		if !ok {
+			continue
+		}
+		if _, found := e.values[e.key(str)]; found {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *exprExact) key(str string) string {
+	if !e.foldCase {
+		return str
+	}
+	return foldString(str)
+}
+
+// foldString maps every rune in str to the smallest rune it is equivalent to
+// under Unicode simple case folding, so that two strings fold to the same
+// value exactly when strings.EqualFold would report them equal.
+func foldString(str string) string {
+	if isASCII(str) {
+		// the smallest rune equivalent to an ASCII letter is its upper case
+		buf := []byte(str)
+		for i, c := range buf {
+			if 'a' <= c && c <= 'z' {
+				buf[i] = c - ('a' - 'A')
+			}
+		}
+		return string(buf)
+	}
+	buf := make([]byte, 0, len(str))
+	for _, r := range str {
+		buf = utf8.AppendRune(buf, foldRune(r))
+	}
+	return string(buf)
+}
+
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
@@ -0,0 +1,34 @@
+package jsonmatcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFoldString(t *testing.T) {
+	pairs := [][2]string{
+		{"andy", "ANDY"},
+		{"k", "K"},           // Kelvin sign
+		{"s", "ſ"},           // long s
+		{"straße", "STRAẞE"}, // sharp s
+		{"Ωmega", "ωMEGA"},   // mixed ASCII and non-ASCII
+		{"σοφία", "ΣΟΦΊΑ"},
+		{"", ""},
+	}
+	for _, p := range pairs {
+		if !strings.EqualFold(p[0], p[1]) {
+			t.Fatalf("bad test pair %q, %q", p[0], p[1])
+		}
+		if a, b := foldString(p[0]), foldString(p[1]); a != b {
+			t.Errorf("foldString(%q) = %q, foldString(%q) = %q", p[0], a, p[1], b)
+		}
+	}
+	for _, p := range [][2]string{
+		{"andy", "andi"},
+		{"ss", "ß"},
+	} {
+		if foldString(p[0]) == foldString(p[1]) {
+			t.Errorf("foldString(%q) == foldString(%q)", p[0], p[1])
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 
 type exprFloat struct {
 	values [2]float64
+	// bounds are the bounds of a range, for BET
+	bounds [2]ast.Bound
 	op     ast.Op
 }
 
@@ -39,7 +41,7 @@ func (e *exprFloat) matches(field *field) bool {
 				return true
 			}
 		case ast.BET:
-			if fv >= e.values[0] && fv <= e.values[1] {
+			if inRange(e.bounds, compareFloat(fv, e.values[0]), compareFloat(fv, e.values[1])) {
 				return true
 			}
 		// backstop
@@ -49,3 +51,13 @@ func (e *exprFloat) matches(field *field) bool {
 	}
 	return false
 }
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
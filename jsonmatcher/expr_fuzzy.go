@@ -0,0 +1,170 @@
+package jsonmatcher
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// exprFuzzy matches string values containing a word within maxEdits edits of
+// a term, where an edit is the insertion, deletion or substitution of a
+// character, or the transposition of two adjacent ones (the optimal string
+// alignment distance, as used by Lucene).
+//
+// Words are runs of letters, marks, digits and underscores, which divides
+// ASCII text at the same places as \b in the regular expressions used for
+// equality.
+type exprFuzzy struct {
+	term            []rune
+	maxEdits        int
+	foldCase        bool
+	stripDiacritics bool
+}
+
+func newFuzzy(term string, maxEdits int, foldCase, stripDiacritics bool) *exprFuzzy {
+	e := &exprFuzzy{
+		maxEdits:        maxEdits,
+		foldCase:        foldCase,
+		stripDiacritics: stripDiacritics,
+	}
+	e.term = e.normalize(nil, term)
+	return e
+}
+
+func (e *exprFuzzy) matches(field *field) bool {
+	matched := false
+	e.each(field, func(string, int) bool {
+		matched = true
+		return false
+	})
+	return matched
+}
+
+func (e *exprFuzzy) explain(field *field) []string {
+	var found []string
+	e.each(field, func(word string, distance int) bool {
+		found = append(found, fmt.Sprintf("%q (distance %d)", word, distance))
+		return true
+	})
+	return found
+}
+
+// each calls fn with each word within maxEdits of the term and its distance,
+// until fn returns false.
+func (e *exprFuzzy) each(field *field, fn func(word string, distance int) bool) {
+	var (
+		word []rune
+		rows = newEditRows(len(e.term))
+	)
+	for _, v := range field.scalarValues() {
+		str, ok := getStringVal(v)
+		if !ok {
+			continue
+		}
+		for start := 0; start < len(str); {
+			r, size := utf8.DecodeRuneInString(str[start:])
+			if !isWordRune(r) {
+				start += size
+				continue
+			}
+			end := start + size
+			for end < len(str) {
+				r, size := utf8.DecodeRuneInString(str[end:])
+				if !isWordRune(r) {
+					break
+				}
+				end += size
+			}
+			word = e.normalize(word[:0], str[start:end])
+			if d := rows.distance(word, e.term, e.maxEdits); d <= e.maxEdits {
+				if !fn(str[start:end], d) {
+					return
+				}
+			}
+			start = end
+		}
+	}
+}
+
+// normalize appends the runes of s to buf, with case folded and diacritics
+// removed as configured.
+func (e *exprFuzzy) normalize(buf []rune, s string) []rune {
+	if e.stripDiacritics && !isASCII(s) {
+		s = norm.NFD.String(s)
+	}
+	for _, r := range s {
+		if e.stripDiacritics && unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if e.foldCase {
+			r = foldRune(r)
+		}
+		buf = append(buf, r)
+	}
+	return buf
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// editRows holds the rows of the edit distance matrix, so they can be reused
+// for each word.
+type editRows struct {
+	prev2, prev, cur []int
+}
+
+func newEditRows(n int) *editRows {
+	return &editRows{
+		prev2: make([]int, n+1),
+		prev:  make([]int, n+1),
+		cur:   make([]int, n+1),
+	}
+}
+
+// distance returns the optimal string alignment distance between a and b, or
+// max+1 if it exceeds max. The rows must have been made for the length of b.
+func (er *editRows) distance(a, b []rune, max int) int {
+	if d := len(a) - len(b); d > max || -d > max {
+		return max + 1
+	}
+	prev2, prev, cur := er.prev2, er.prev, er.cur
+	for j := 0; j <= len(b); j++ {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d = minInt(d, prev2[j-2]+1)
+			}
+			cur[j] = d
+			rowMin = minInt(rowMin, d)
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	if prev[len(b)] > max {
+		return max + 1
+	}
+	return prev[len(b)]
+}
+
+func minInt(first int, rest ...int) int {
+	for _, v := range rest {
+		if v < first {
+			first = v
+		}
+	}
+	return first
+}
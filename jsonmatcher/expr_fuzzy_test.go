@@ -0,0 +1,52 @@
+package jsonmatcher
+
+import (
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"colour", "colour", 2, 0},
+		{"color", "colour", 2, 1},
+		{"colour", "color", 2, 1},
+		{"coluor", "colour", 2, 1},
+		{"clour", "colour", 2, 1},
+		{"kolor", "colour", 2, 2},
+		{"kolr", "colour", 2, 3},
+		{"kolr", "colour", 1, 2},
+		{"", "ab", 2, 2},
+		{"ab", "", 2, 2},
+		{"abc", "", 2, 3},
+		{"ca", "abc", 3, 3}, // optimal string alignment, not unrestricted Damerau
+		{"über", "uber", 1, 1},
+		{"大懒虫", "大懒", 1, 1},
+	}
+	for _, tt := range tests {
+		a, b := []rune(tt.a), []rune(tt.b)
+		if got := newEditRows(len(b)).distance(a, b, tt.max); got != tt.want {
+			t.Errorf("distance(%q, %q, %d): want: %d, got: %d", tt.a, tt.b, tt.max, tt.want, got)
+		}
+	}
+}
+
+func TestFuzzyNormalize(t *testing.T) {
+	tests := []struct {
+		foldCase, stripDiacritics bool
+		in, want                  string
+	}{
+		{false, false, "Ünïcode", "Ünïcode"},
+		{true, false, "Ünïcode", "ÜNÏCODE"},
+		{false, true, "Ünïcode", "Unicode"},
+		{true, true, "Ünïcode", "UNICODE"},
+	}
+	for _, tt := range tests {
+		e := &exprFuzzy{foldCase: tt.foldCase, stripDiacritics: tt.stripDiacritics}
+		if got := string(e.normalize(nil, tt.in)); got != tt.want {
+			t.Errorf("normalize(%q) (fold case: %v, strip diacritics: %v): want: %q, got: %q", tt.in, tt.foldCase, tt.stripDiacritics, tt.want, got)
+		}
+	}
+}
@@ -3,13 +3,34 @@ package jsonmatcher
 import (
 	"net/netip"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"github.com/flowchartsman/aql/parser/ast"
 )
 
-var getNets = regexp.MustCompile(`(?:\d{1,3}\.){3}\d{1,3}(?:/\d{1,2})?`)
+// getNets finds candidate IPv4 and IPv6 addresses and blocks in text, which
+// must still be parsed to see if they are valid. IPv6 candidates are tried
+// first, so that an IPv4-mapped address like ::ffff:10.0.0.1 is found whole.
+var getNets = regexp.MustCompile(
+	`(?:[0-9A-Fa-f]{0,4}:){2,7}(?:(?:\d{1,3}\.){3}\d{1,3}|[0-9A-Fa-f]{1,4})?(?:/\d{1,3})?` +
+		`|(?:\d{1,3}\.){3}\d{1,3}(?:/\d{1,2})?`)
 
+// exprNet matches addresses and blocks found in string values against a
+// network value. If the value has ports, only sockets with a port in range
+// match: addr:port for IPv4, and [addr]:port for IPv6.
 type exprNet struct {
-	value netip.Prefix
+	value    netip.Prefix
+	ports    [2]uint16
+	hasPorts bool
+}
+
+func newExprNet(nv *ast.NetVal) *exprNet {
+	e := &exprNet{
+		value: unmapPrefix(nv.Value()),
+	}
+	e.ports[0], e.ports[1], e.hasPorts = nv.Ports()
+	return e
 }
 
 func (e *exprNet) matches(field *field) bool {
@@ -21,6 +42,17 @@ func (e *exprNet) matches(field *field) bool {
 		// find all CIDRs/IPAddrs in string
 		for _, ipIdx := range getNets.FindAllStringIndex(sv, -1) {
 			netsv := sv[ipIdx[0]:ipIdx[1]]
+			isV6 := strings.Contains(netsv, `:`)
+			if isV6 && !isWordBoundary(sv, ipIdx[0], ipIdx[1]) {
+				// part of a longer word, like the d:: in std::vector
+				continue
+			}
+			if e.hasPorts {
+				port, ok := socketPort(sv, ipIdx[0], ipIdx[1], isV6)
+				if !ok || port < e.ports[0] || port > e.ports[1] {
+					continue
+				}
+			}
 			switch {
 			case strings.Contains(netsv, `/`):
 				// net block, check for overlap
@@ -29,18 +61,16 @@ func (e *exprNet) matches(field *field) bool {
 					// report incorrect field
 					continue
 				}
-				if e.value.Overlaps(netblock) {
+				if e.value.Overlaps(unmapPrefix(netblock)) {
 					return true
 				}
-			// would add port awareness here if needed
-			// case strings.Contains(netsv, `:`):
 			default:
 				// plain netaddr, check if it's contained/equals
 				netaddr, err := netip.ParseAddr(netsv)
 				if err != nil {
 					continue
 				}
-				if e.value.Contains(netaddr) {
+				if e.value.Contains(netaddr.Unmap()) {
 					return true
 				}
 			}
@@ -48,3 +78,49 @@ func (e *exprNet) matches(field *field) bool {
 	}
 	return false
 }
+
+// socketPort returns the port following the address at str[start:end], if it
+// is part of a socket. IPv6 addresses must be in brackets, as in [::1]:443,
+// since the port could otherwise be taken as part of the address.
+func socketPort(str string, start, end int, isV6 bool) (uint16, bool) {
+	rest := str[end:]
+	if isV6 {
+		if start == 0 || str[start-1] != '[' || !strings.HasPrefix(rest, `]`) {
+			return 0, false
+		}
+		rest = rest[1:]
+	}
+	if !strings.HasPrefix(rest, `:`) {
+		return 0, false
+	}
+	rest = rest[1:]
+	n := 0
+	for n < len(rest) && '0' <= rest[n] && rest[n] <= '9' {
+		n++
+	}
+	if n == 0 || n < len(rest) && isWordByte(rest[n]) {
+		return 0, false
+	}
+	port, err := strconv.ParseUint(rest[:n], 10, 16)
+	if err != nil {
+		return 0, false
+	}
+	return uint16(port), true
+}
+
+// unmapPrefix returns the IPv4 equivalent of a block of IPv4-mapped IPv6
+// addresses, such as ::ffff:10.0.0.0/104 for 10.0.0.0/8, so that they match
+// the IPv4 addresses they stand for.
+func unmapPrefix(p netip.Prefix) netip.Prefix {
+	if !p.Addr().Is4In6() || p.Bits() < 96 {
+		return p
+	}
+	return netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+}
+
+// isWordBoundary reports whether str[start:end] is not preceded or followed by
+// a letter, digit or underscore.
+func isWordBoundary(str string, start, end int) bool {
+	return (start == 0 || !isWordByte(str[start-1])) &&
+		(end == len(str) || !isWordByte(str[end]))
+}
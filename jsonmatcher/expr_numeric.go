@@ -6,7 +6,7 @@ import (
 	"github.com/flowchartsman/aql/parser/ast"
 )
 
-func exprNumeric(op ast.Op, RVals []ast.Val) fieldExpr {
+func exprNumeric(op ast.Op, RVals []ast.Val, opts *matcherOpts) fieldExpr {
 	if len(RVals) != 1 {
 		// backstop
 		panic(fmt.Sprintf("numericMatcher expects only one constant value - got %d", len(RVals)))
@@ -23,10 +23,7 @@ func exprNumeric(op ast.Op, RVals []ast.Val) fieldExpr {
 			op:     op,
 		}
 	case *ast.TimeVal:
-		return &exprDatetime{
-			values: [2]int64{v.Value().UnixNano()},
-			op:     op,
-		}
+		return exprTime(op, [2]ast.Bound{}, []*ast.TimeVal{v}, opts)
 	default:
 		// backstop
 		panic(fmt.Sprintf("bad value type for numeric matcher: %T", RVals[0]))
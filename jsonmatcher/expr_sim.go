@@ -0,0 +1,75 @@
+package jsonmatcher
+
+import (
+	"fmt"
+
+	"github.com/flowchartsman/aql/parser/ast"
+	"golang.org/x/text/language"
+	"golang.org/x/text/search"
+)
+
+// exprSim returns matchers for a similarity match. String values are searched
+// for within document values using the collation rules of the language and
+// strength given as parameters of the operation, so that, for example,
+// "cafe" can match "Café". Fuzzy terms are matched against each word of a
+// value instead, respecting the strength, but not the language. Other values
+// are matched as they would be for equality, for compatibility with older
+// queries.
+func exprSim(n *ast.ExprNode, opts *matcherOpts) []fieldExpr {
+	lang, strength := simParams(n.Params)
+	m := search.New(lang, searchOptions(strength)...)
+	var (
+		matchers []fieldExpr
+		legacy   []ast.Val
+	)
+	for _, r := range n.RVals {
+		sv, ok := r.(*ast.StringVal)
+		if !ok {
+			legacy = append(legacy, r)
+			continue
+		}
+		if maxEdits, fuzzy := sv.Fuzziness(); fuzzy {
+			matchers = append(matchers, newFuzzy(sv.Value(), maxEdits,
+				strength != ast.StrengthIgnoreDiacritics,
+				strength != ast.StrengthIgnoreCase))
+			continue
+		}
+		matchers = append(matchers, &unicodeMatcher{
+			pat: m.CompileString(sv.Value()),
+		})
+	}
+	if len(legacy) > 0 {
+		matchers = append(matchers, exprEQ(legacy, opts)...)
+	}
+	return matchers
+}
+
+// simParams returns the language and strength given in the parameters of a
+// similarity match, which have already been validated by the parser.
+func simParams(params []string) (language.Tag, string) {
+	lang, strength := language.Und, ast.StrengthLoose
+	for _, p := range params {
+		switch p {
+		case ast.StrengthLoose, ast.StrengthIgnoreDiacritics, ast.StrengthIgnoreCase:
+			strength = p
+		default:
+			tag, err := language.Parse(p)
+			if err != nil {
+				// backstop
+				panic(fmt.Sprintf("invalid language tag for similarity match: %s", p))
+			}
+			lang = tag
+		}
+	}
+	return lang, strength
+}
+
+func searchOptions(strength string) []search.Option {
+	switch strength {
+	case ast.StrengthIgnoreDiacritics:
+		return []search.Option{search.IgnoreDiacritics}
+	case ast.StrengthIgnoreCase:
+		return []search.Option{search.IgnoreCase, search.IgnoreWidth}
+	}
+	return []search.Option{search.Loose}
+}
@@ -19,11 +19,14 @@ const (
 type expectedType string
 
 const (
-	expectString  = "string"
-	expectNumeric = "numeric"
-	expectBoolean = "boolean"
-	expectNull    = "null"
-	expectExists  = "<exists>"
+	expectString   expectedType = "string"
+	expectNumeric  expectedType = "numeric"
+	expectBoolean  expectedType = "boolean"
+	expectDatetime expectedType = "datetime"
+	expectNetwork  expectedType = "network"
+	expectObject   expectedType = "object"
+	expectNull     expectedType = "null"
+	expectExists   expectedType = "<exists>"
 )
 
 const (
@@ -57,149 +60,289 @@ var encounteredName = [numEncounteredTypes]string{
 	"null (in array)",
 }
 
-// TODO: Replace uber types with native types pending MarshalScalar (see field_stats.go)
+// FieldStats tracks what the matcher encounters at a field referenced in a
+// query, to help diagnose queries that do not match as expected. A field is
+// counted at most once per document, however many expressions look it up, and
+// the values it holds are recorded from the first lookup that finds them.
+//
+// TODO: Replace uber types with native types pending MarshalScalar (see node_stats.go)
 type FieldStats struct {
 	Expecting       []expectedType                        `json:"expecting"`
 	TimesSampled    atomic.Int64                          `json:"times_sampled"`
+	TimesFound      atomic.Int64                          `json:"times_found"`
+	TimesMatched    atomic.Int64                          `json:"times_matched"`
 	TypesEnountered [numEncounteredTypes]EncounteredStats `json:"types_encountered"`
+	// DateParseFailures counts the values that could not be read as a
+	// datetime by an expression that expected one, once per document, with
+	// examples. It is only counted for fields with layouts set by DateLayouts
+	// or FieldDateLayouts.
+	DateParseFailures EncounteredStats `json:"date_parse_failures"`
 }
 
 func NewFieldStats(expectedTypes ...expectedType) *FieldStats {
-	fs := &FieldStats{
-		Expecting: expectedTypes,
+	fs := &FieldStats{}
+	fs.expect(expectedTypes...)
+	for i := range fs.TypesEnountered {
+		fs.TypesEnountered[i].Examples = &ExampleList{}
 	}
-	for _, enc := range fs.TypesEnountered {
-		enc.Examples = &ExampleList{}
+	fs.DateParseFailures.Examples = &ExampleList{}
+	return fs
+}
+
+// expect adds to the expected types for this field, ignoring duplicates. It
+// is only called while building the matcher.
+func (n *FieldStats) expect(expectedTypes ...expectedType) {
+ELOOP:
+	for _, e := range expectedTypes {
+		for _, existing := range n.Expecting {
+			if e == existing {
+				continue ELOOP
+			}
+		}
+		n.Expecting = append(n.Expecting, e)
 	}
-	return &FieldStats{
-		Expecting: expectedTypes,
+}
+
+// Encountered returns the number of times each type of JSON value has been
+// found in this field, by name.
+func (n *FieldStats) Encountered() map[string]int64 {
+	out := map[string]int64{}
+	for i := range n.TypesEnountered {
+		if seen := n.TypesEnountered[i].TimesSeen.Load(); seen != 0 {
+			out[encounteredName[i]] = seen
+		}
 	}
+	return out
+}
+
+// Examples returns example values for each type of JSON value that has been
+// found in this field, by name.
+func (n *FieldStats) Examples() map[string][]string {
+	out := map[string][]string{}
+	for i := range n.TypesEnountered {
+		if examples := n.TypesEnountered[i].Examples.Get(); len(examples) > 0 {
+			out[encounteredName[i]] = examples
+		}
+	}
+	return out
 }
 
-func (n *FieldStats) MarshalJson() ([]byte, error) {
+func (n *FieldStats) MarshalJSON() ([]byte, error) {
 	out := map[string]interface{}{}
 	out["expecting"] = n.Expecting
-	out["times_seen"] = n.TimesSampled.Load()
+	out["times_sampled"] = n.TimesSampled.Load()
+	out["times_found"] = n.TimesFound.Load()
+	out["times_matched"] = n.TimesMatched.Load()
 	encountered := map[string]map[string]interface{}{}
-	for i, e := range n.TypesEnountered {
+	for i := range n.TypesEnountered {
+		e := &n.TypesEnountered[i]
 		if e.TimesSeen.Load() != 0 {
 			em := map[string]interface{}{}
 			em["times_seen"] = e.TimesSeen.Load()
-			em["times_matched"] = e.TimesMatched.Load()
-			em["examples"] = e.Examples.Get()
+			if examples := e.Examples.Get(); len(examples) > 0 {
+				em["examples"] = examples
+			}
 			encountered[encounteredName[i]] = em
 		}
 	}
 	if len(encountered) > 0 {
 		out["encountered"] = encountered
 	}
+	if seen := n.DateParseFailures.TimesSeen.Load(); seen != 0 {
+		out["date_parse_failures"] = map[string]interface{}{
+			"times_seen": seen,
+			"examples":   n.DateParseFailures.Examples.Get(),
+		}
+	}
 	return json.Marshal(out)
 }
 
+// markDateFailure records a string or number that could not be read as a
+// datetime, keeping examples in the same way as markValue.
+func (n *FieldStats) markDateFailure(v jsonValue) {
+	seen := n.DateParseFailures.TimesSeen.Inc()
+	if seen > fieldNumExamples && seen%fieldMarkWindow != 0 {
+		return
+	}
+	sv := string(v.data)
+	if v.dataType == jsonparser.String {
+		s, err := jsonparser.ParseString(v.data)
+		if err != nil {
+			s = "invalid string"
+		}
+		sv = s
+	}
+	n.DateParseFailures.Examples.addExample(sv)
+}
+
+// markDatesRead records that datetime values have been read from the field in
+// the document in st, returning false if they already had been, so that values
+// that cannot be read are counted once per document, however many expressions
+// read them.
+func (n *FieldStats) markDatesRead(st *evalState) bool {
+	if st.marked == nil {
+		st.marked = map[*FieldStats]fieldMark{}
+	}
+	prev := st.marked[n]
+	if prev.datesRead {
+		return false
+	}
+	prev.datesRead = true
+	st.marked[n] = prev
+	return true
+}
+
 var stopIter = errors.New("found maximum number of values")
 
-func (n *FieldStats) mark(foundValues []jsonValue, matchIdx int, inArray bool) {
-	n.TimesSampled.Inc()
+// fieldMark is what has been recorded for a field in the current document.
+type fieldMark struct {
+	sampled bool
+	found   bool
+	matched bool
+	// datesRead is set once datetime values have been read from the field,
+	// so that those which cannot be read are only recorded once
+	datesRead bool
+}
+
+// mark records the values found for the field in a single lookup, and whether
+// the expression using it matched. The field is counted at most once per
+// document in st, so later lookups only record what earlier ones did not.
+// Elements of arrays are recorded as being in an array.
+func (n *FieldStats) mark(st *evalState, foundValues []jsonValue, matched bool) {
+	if st.marked == nil {
+		st.marked = map[*FieldStats]fieldMark{}
+	}
+	prev := st.marked[n]
+	if !prev.sampled {
+		n.TimesSampled.Inc()
+	}
+	found := len(foundValues) > 0
+	st.marked[n] = fieldMark{
+		sampled:   true,
+		found:     prev.found || found,
+		matched:   prev.matched || matched,
+		datesRead: prev.datesRead,
+	}
+	if matched && !prev.matched {
+		n.TimesMatched.Inc()
+	}
+	if !found || prev.found {
+		return
+	}
+	n.TimesFound.Inc()
 	for _, foundValue := range foundValues {
-		var which int
-		var isObject bool
-		switch foundValue.dataType {
-		case jsonparser.String:
-			which = str
-		case jsonparser.Number:
-			which = number
-		case jsonparser.Boolean:
-			which = boolean
-		case jsonparser.Array:
-			which = array
-		case jsonparser.Object:
-			which = object
-			isObject = true
-		case jsonparser.Null:
-			which = null
-		}
-		if inArray {
-			which++
-		}
-		n.TypesEnountered[which].TimesSeen.Inc()
-		// every 1000 times this field type marked, extract an example field
-		if which < boolean && n.TimesSampled.Load()%fieldMarkWindow == 0 {
-			if !isObject {
-				var (
-					sv  string
-					err error
-				)
-				if foundValue.dataType == jsonparser.String {
-					sv, err = jsonparser.ParseString(foundValue.data)
-					if err != nil {
-						sv = "invalid string"
-					}
-					if len(sv) == 0 {
-						sv = "empty string"
-					}
-				} else {
-					// a number, should be able to just use raw bytes
-					sv = string(foundValue.data)
-				}
-				n.TypesEnountered[which].Examples.addExample(sv)
-			} else {
-				// object
-				var sb strings.Builder
-				sb.WriteString("<object with keys: ")
-				numkeys := 0
-				jsonparser.ObjectEach(foundValue.data, func(key []byte, value []byte, dataType jsonparser.ValueType, _ int) error {
-					if numkeys > 0 {
-						sb.WriteString(",")
-					}
-					keystr, err := jsonparser.ParseString(key)
-					if err != nil {
-						keystr = "<invalid key>"
-					}
-					sb.WriteString(`"` + keystr + `"`)
-					numkeys++
-					if numkeys > fieldMapMaxKeys {
-						return stopIter
-					}
-					return nil
+		n.markValue(foundValue, false)
+		if foundValue.dataType == jsonparser.Array {
+			jsonparser.ArrayEach(foundValue.data,
+				func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+					n.markValue(jsonValue{data: value, dataType: dataType}, true)
 				})
-				sb.WriteString(">")
-				n.TypesEnountered[which].Examples.addExample(sb.String())
+		}
+	}
+}
+
+func (n *FieldStats) markValue(foundValue jsonValue, inArray bool) {
+	var which int
+	switch foundValue.dataType {
+	case jsonparser.String:
+		which = str
+	case jsonparser.Number:
+		which = number
+	case jsonparser.Boolean:
+		which = boolean
+	case jsonparser.Array:
+		which = array
+	case jsonparser.Object:
+		which = object
+	case jsonparser.Null:
+		which = null
+	default:
+		return
+	}
+	if inArray {
+		which++
+	}
+	enc := &n.TypesEnountered[which]
+	seen := enc.TimesSeen.Inc()
+	// examples are only useful for strings, numbers and objects. Take the
+	// first few, then one every fieldMarkWindow times this type is seen.
+	if which >= boolean || (seen > fieldNumExamples && seen%fieldMarkWindow != 0) {
+		return
+	}
+	if foundValue.dataType != jsonparser.Object {
+		var (
+			sv  string
+			err error
+		)
+		if foundValue.dataType == jsonparser.String {
+			sv, err = jsonparser.ParseString(foundValue.data)
+			if err != nil {
+				sv = "invalid string"
+			}
+			if len(sv) == 0 {
+				sv = "empty string"
 			}
+		} else {
+			// a number, should be able to just use raw bytes
+			sv = string(foundValue.data)
 		}
+		enc.Examples.addExample(sv)
+		return
 	}
+	// object
+	var sb strings.Builder
+	sb.WriteString("<object with keys: ")
+	numkeys := 0
+	jsonparser.ObjectEach(foundValue.data, func(key []byte, value []byte, dataType jsonparser.ValueType, _ int) error {
+		if numkeys > 0 {
+			sb.WriteString(",")
+		}
+		keystr, err := jsonparser.ParseString(key)
+		if err != nil {
+			keystr = "<invalid key>"
+		}
+		sb.WriteString(`"` + keystr + `"`)
+		numkeys++
+		if numkeys > fieldMapMaxKeys {
+			return stopIter
+		}
+		return nil
+	})
+	sb.WriteString(">")
+	enc.Examples.addExample(sb.String())
 }
 
 type EncounteredStats struct {
-	TimesSeen    atomic.Int64 `json:"times_seen"`
-	TimesMatched atomic.Int64 `json:"times_matched"`
-	Examples     *ExampleList `json:"examples,omitempty"`
+	TimesSeen atomic.Int64 `json:"times_seen"`
+	Examples  *ExampleList `json:"examples,omitempty"`
 }
 
+// ExampleList is a rolling list of the most recent example values.
 type ExampleList struct {
 	mux      sync.Mutex
 	examples [fieldNumExamples]string
 	eIdx     int
+	full     bool
 }
 
 func (el *ExampleList) addExample(example string) {
 	el.mux.Lock()
 	defer el.mux.Unlock()
+	el.examples[el.eIdx] = example
 	el.eIdx++
 	if el.eIdx == len(el.examples) {
 		el.eIdx = 0
+		el.full = true
 	}
-	el.examples[el.eIdx] = example
 }
 
+// Get returns the examples, oldest first.
 func (el *ExampleList) Get() []string {
 	el.mux.Lock()
 	defer el.mux.Unlock()
 	out := []string{}
-	for _, example := range el.examples {
-		if example == "" {
-			break
-		}
-		out = append(out, example)
+	if el.full {
+		out = append(out, el.examples[el.eIdx:]...)
 	}
-	return out
+	return append(out, el.examples[:el.eIdx]...)
 }
@@ -0,0 +1,242 @@
+package jsonmatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFieldStats(t *testing.T) {
+	m, err := NewMatcher(`status:200 AND tags:"prod" AND items{sku:"A1" AND qty:>1}`, TrackFieldStats())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	docs := []string{
+		`{"status": "200", "tags": ["prod", "web"], "items": [{"sku": "A1", "qty": 2}]}`,
+		`{"status": "404", "tags": ["prod"], "items": [{"sku": "B2", "qty": 1}]}`,
+		`{"tags": "dev"}`,
+	}
+	for _, doc := range docs {
+		if _, err := m.Match([]byte(doc)); err != nil {
+			t.Fatalf("unexpected matcher error: %v", err)
+		}
+	}
+	fieldStats := m.FieldStats()
+	for _, name := range []string{"status", "tags", "items", "items.sku", "items.qty"} {
+		if _, ok := fieldStats[name]; !ok {
+			t.Fatalf("missing stats for field %q, have: %v", name, fieldStats)
+		}
+	}
+
+	status := fieldStats["status"]
+	if want := []expectedType{expectNumeric}; !reflect.DeepEqual(status.Expecting, want) {
+		t.Errorf("status: expected %v, got %v", want, status.Expecting)
+	}
+	if status.TimesSampled.Load() != 3 || status.TimesFound.Load() != 2 || status.TimesMatched.Load() != 1 {
+		t.Errorf("status: expected 3 samples, 2 found, 1 match, got %d, %d, %d",
+			status.TimesSampled.Load(), status.TimesFound.Load(), status.TimesMatched.Load())
+	}
+	if want := map[string]int64{"string": 2}; !reflect.DeepEqual(status.Encountered(), want) {
+		t.Errorf("status: expected encountered %v, got %v", want, status.Encountered())
+	}
+	if want := map[string][]string{"string": {"200", "404"}}; !reflect.DeepEqual(status.Examples(), want) {
+		t.Errorf("status: expected examples %v, got %v", want, status.Examples())
+	}
+
+	// the third document short-circuits before tags are checked
+	tags := fieldStats["tags"]
+	if want := map[string]int64{"array": 1, "string (in array)": 2}; !reflect.DeepEqual(tags.Encountered(), want) {
+		t.Errorf("tags: expected encountered %v, got %v", want, tags.Encountered())
+	}
+
+	items := fieldStats["items"]
+	if want := []expectedType{expectObject}; !reflect.DeepEqual(items.Expecting, want) {
+		t.Errorf("items: expected %v, got %v", want, items.Expecting)
+	}
+	if want := map[string][]string{"object (in array)": {`<object with keys: "sku","qty">`}}; !reflect.DeepEqual(items.Examples(), want) {
+		t.Errorf("items: expected examples %v, got %v", want, items.Examples())
+	}
+	if fieldStats["items.sku"].TimesSampled.Load() != 1 {
+		t.Errorf("items.sku: expected 1 sample, got %d", fieldStats["items.sku"].TimesSampled.Load())
+	}
+
+	out, err := json.Marshal(fieldStats["status"])
+	if err != nil {
+		t.Fatalf("error marshalling field stats: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("error unmarshalling field stats: %v", err)
+	}
+	if decoded["times_sampled"] != float64(3) {
+		t.Errorf("expected marshalled times_sampled 3, got %v", decoded["times_sampled"])
+	}
+	if _, ok := decoded["encountered"].(map[string]any)["string"]; !ok {
+		t.Errorf("expected marshalled string encounters, got %s", out)
+	}
+}
+
+func TestFieldStatsSharedPath(t *testing.T) {
+	m, err := NewMatcher(`a:1 OR a:"one"`, TrackFieldStats())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fieldStats := m.FieldStats()
+	if len(fieldStats) != 1 {
+		t.Fatalf("expected stats for one field, got %d", len(fieldStats))
+	}
+	if want := []expectedType{expectNumeric, expectString}; !reflect.DeepEqual(fieldStats["a"].Expecting, want) {
+		t.Errorf("expected %v, got %v", want, fieldStats["a"].Expecting)
+	}
+	// both expressions look up a in each document, but it is counted once
+	for _, doc := range []string{`{"a": "one"}`, `{"a": 2}`, `{"b": 1}`} {
+		if _, err := m.Match([]byte(doc)); err != nil {
+			t.Fatalf("unexpected matcher error: %v", err)
+		}
+	}
+	a := fieldStats["a"]
+	if a.TimesSampled.Load() != 3 || a.TimesFound.Load() != 2 || a.TimesMatched.Load() != 1 {
+		t.Errorf("expected 3 samples, 2 found, 1 match, got %d, %d, %d",
+			a.TimesSampled.Load(), a.TimesFound.Load(), a.TimesMatched.Load())
+	}
+	if want := map[string]int64{"string": 1, "number": 1}; !reflect.DeepEqual(a.Encountered(), want) {
+		t.Errorf("expected encountered %v, got %v", want, a.Encountered())
+	}
+}
+
+func TestFieldStatsSubdocument(t *testing.T) {
+	m, err := NewMatcher(`items{sku:"C3"}`, TrackFieldStats())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	docs := []string{
+		`{"items": [{"sku": "A1"}, {"sku": "B2"}, {"sku": "C3"}]}`,
+		`{"items": [{"qty": 1}, {"sku": "B2"}]}`,
+	}
+	for _, doc := range docs {
+		if _, err := m.Match([]byte(doc)); err != nil {
+			t.Fatalf("unexpected matcher error: %v", err)
+		}
+	}
+	// sku is looked up once per element, but counted once per document
+	sku := m.FieldStats()["items.sku"]
+	if sku.TimesSampled.Load() != 2 || sku.TimesFound.Load() != 2 || sku.TimesMatched.Load() != 1 {
+		t.Errorf("expected 2 samples, 2 found, 1 match, got %d, %d, %d",
+			sku.TimesSampled.Load(), sku.TimesFound.Load(), sku.TimesMatched.Load())
+	}
+}
+
+func TestFieldStatsDateParseFailures(t *testing.T) {
+	m, err := NewMatcher(`ts:>2024-01-01`, TrackFieldStats(), DateLayouts(RFC3339))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	docs := []string{
+		`{"ts": "2024-03-10T03:30:00Z"}`,
+		`{"ts": 20240310}`,
+		`{"ts": ["03/10/2024", true]}`,
+	}
+	for _, doc := range docs {
+		if _, err := m.Match([]byte(doc)); err != nil {
+			t.Fatalf("unexpected matcher error: %v", err)
+		}
+	}
+	ts := m.FieldStats()["ts"]
+	if got := ts.DateParseFailures.TimesSeen.Load(); got != 2 {
+		t.Errorf("expected 2 date parse failures, got %d", got)
+	}
+	if want := []string{"20240310", "03/10/2024"}; !reflect.DeepEqual(ts.DateParseFailures.Examples.Get(), want) {
+		t.Errorf("expected examples %v, got %v", want, ts.DateParseFailures.Examples.Get())
+	}
+	out, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatalf("error marshalling field stats: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("error unmarshalling field stats: %v", err)
+	}
+	if _, ok := decoded["date_parse_failures"]; !ok {
+		t.Errorf("expected marshalled date parse failures, got %s", out)
+	}
+}
+
+func TestFieldStatsDateParseFailuresPerValue(t *testing.T) {
+	// the set compares each value with two dates, but reads it once
+	m, err := NewMatcher(`ts:(2024-01-01, 2024-02-01)`, TrackFieldStats(), DateLayouts(RFC3339))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := m.Match([]byte(`{"ts": ["03/10/2024", "2024-03-10T03:30:00Z"]}`)); err != nil {
+		t.Fatalf("unexpected matcher error: %v", err)
+	}
+	ts := m.FieldStats()["ts"]
+	if got := ts.DateParseFailures.TimesSeen.Load(); got != 1 {
+		t.Errorf("expected 1 date parse failure, got %d", got)
+	}
+}
+
+func TestFieldStatsDateParseFailuresPerDocument(t *testing.T) {
+	// both expressions read the field, but each value is counted once per
+	// document
+	m, err := NewMatcher(`ts:>now-1d OR ts:<1970-01-01`, TrackFieldStats(), DateLayouts(RFC3339))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	docs := []string{
+		`{"ts": ["03/10/2024", "yesterday"]}`,
+		`{"ts": "03/10/2024"}`,
+	}
+	for _, doc := range docs {
+		if _, err := m.Match([]byte(doc)); err != nil {
+			t.Fatalf("unexpected matcher error: %v", err)
+		}
+	}
+	ts := m.FieldStats()["ts"]
+	if got := ts.DateParseFailures.TimesSeen.Load(); got != 3 {
+		t.Errorf("expected 3 date parse failures, got %d", got)
+	}
+	if ts.TimesSampled.Load() != 2 {
+		t.Errorf("expected 2 samples, got %d", ts.TimesSampled.Load())
+	}
+}
+
+func TestFieldStatsDateParseFailuresNeedLayouts(t *testing.T) {
+	m, err := NewMatcher(`ts:>2024-01-01`, TrackFieldStats())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := m.Match([]byte(`{"ts": "not a date"}`)); err != nil {
+		t.Fatalf("unexpected matcher error: %v", err)
+	}
+	if got := m.FieldStats()["ts"].DateParseFailures.TimesSeen.Load(); got != 0 {
+		t.Errorf("expected no date parse failures without layouts, got %d", got)
+	}
+}
+
+func TestFieldStatsDisabled(t *testing.T) {
+	m, err := NewMatcher(`a:1`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.FieldStats() != nil {
+		t.Fatalf("expected no field stats by default")
+	}
+}
+
+func TestExampleList(t *testing.T) {
+	el := &ExampleList{}
+	if got := el.Get(); len(got) != 0 {
+		t.Fatalf("expected no examples, got %v", got)
+	}
+	var want []string
+	for i := 0; i < fieldNumExamples+3; i++ {
+		el.addExample(fmt.Sprint(i))
+		want = append(want, fmt.Sprint(i))
+	}
+	want = want[3:]
+	if got := el.Get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -1,12 +1,13 @@
 package jsonmatcher
 
 import (
+	"encoding/json"
 	"math"
 	"regexp"
 	"strconv"
 
-	"github.com/araddon/dateparse"
 	"github.com/buger/jsonparser"
+	"github.com/flowchartsman/aql/parser/ast"
 )
 
 type jsonValue struct {
@@ -17,16 +18,112 @@ type jsonValue struct {
 type field struct {
 	// offsets when possible
 	values []jsonValue
+	opts   *matcherOpts
+	st     *evalState
+	// dates reads datetime values in the field, for the expression looking
+	// it up
+	dates *dateParser
+	// datetimes holds the values read by dates, once datesRead is set
+	datetimes []int64
+	datesRead bool
 }
 
-// TODO: cache paths
-func getField(path []string, root []byte) *field {
-	values := getValues(path, root, jsonparser.Object)
+// getField returns the field at path in root. If planned holds the indexes of
+// the path's prefixes in the matcher's path plan, they are used to find it,
+// otherwise the path is walked from root.
+func getField(path ast.Path, planned []int, root []byte, opts *matcherOpts, st *evalState) *field {
+	var values []jsonValue
+	if planned != nil {
+		values = st.plan.resolve(path, planned, root, opts, st)
+	} else {
+		values = getValues(valuepath(path), root, jsonparser.Object, opts, st)
+	}
 	return &field{
 		values: values,
+		opts:   opts,
+		st:     st,
+	}
+}
+
+// evalState holds the state of matching a single document, shared by all of
+// the nodes in the query.
+type evalState struct {
+	// plan and values hold the paths extracted from the document root, which
+	// happens on first use.
+	plan      *pathPlan
+	values    []jsonValue
+	extracted bool
+	// lazy is set when only the parts of the document the query touches are
+	// validated, in which case any that are malformed mark the whole document
+	// invalid.
+	lazy    bool
+	invalid bool
+	// marked holds the fields whose statistics have been recorded for the
+	// document, so that each is counted once.
+	marked map[*FieldStats]fieldMark
+}
+
+func (st *evalState) reset() {
+	// drop the references into the document, so that it can be collected
+	// while the state is pooled
+	for i := range st.values {
+		st.values[i] = jsonValue{}
+	}
+	st.values = st.values[:0]
+	st.extracted = false
+	st.invalid = false
+	for fs := range st.marked {
+		delete(st.marked, fs)
 	}
 }
 
+// checkErr marks the document invalid if err shows it to be malformed. A
+// missing key is not an error. It is a no-op unless validating lazily.
+func (st *evalState) checkErr(err error) {
+	if !st.lazy || err == nil || err == jsonparser.KeyPathNotFoundError {
+		return
+	}
+	st.invalid = true
+}
+
+// checkValue marks the document invalid if a value it contains is malformed.
+// It is a no-op unless validating lazily.
+func (st *evalState) checkValue(v jsonValue) {
+	if !st.lazy || st.invalid {
+		return
+	}
+	switch v.dataType {
+	case jsonparser.String:
+		// jsonparser strips the quotes from strings
+		_, err := jsonparser.ParseString(v.data)
+		st.checkErr(err)
+	case jsonparser.NotExist, jsonparser.Unknown:
+		st.invalid = true
+	default:
+		if !json.Valid(v.data) {
+			st.invalid = true
+		}
+	}
+}
+
+// datetimeValues returns the scalar values of the field that can be read as
+// datetimes, in nanoseconds since the Unix epoch. They are read on first use
+// and kept, so that each value that cannot be read is only recorded once,
+// however many datetime comparisons the expression makes.
+func (f *field) datetimeValues() []int64 {
+	if f.datesRead {
+		return f.datetimes
+	}
+	f.datesRead = true
+	record := f.dates.recordsFailures(f.st)
+	for _, v := range f.scalarValues() {
+		if dv, ok := f.dates.parse(v, record); ok {
+			f.datetimes = append(f.datetimes, dv)
+		}
+	}
+	return f.datetimes
+}
+
 func (f *field) scalarValues() []jsonValue {
 	var out []jsonValue
 	for _, v := range f.values {
@@ -34,17 +131,11 @@ func (f *field) scalarValues() []jsonValue {
 		case jsonparser.Object:
 			continue
 		case jsonparser.Array:
-			jsonparser.ArrayEach(v.data,
-				func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
-					switch dataType {
-					case jsonparser.Object, jsonparser.Array:
-					default:
-						out = append(out, jsonValue{
-							data:     value,
-							dataType: dataType,
-						})
-					}
-				})
+			eachArrayValue(v.data, f.opts, f.st, func(value jsonValue) {
+				if value.dataType != jsonparser.Object {
+					out = append(out, value)
+				}
+			})
 		default:
 			out = append(out, v)
 		}
@@ -52,6 +143,25 @@ func (f *field) scalarValues() []jsonValue {
 	return out
 }
 
+// subdocs returns all objects at the field location, including those in an
+// array at that location.
+func (f *field) subdocs() []jsonValue {
+	var out []jsonValue
+	for _, v := range f.values {
+		switch v.dataType {
+		case jsonparser.Object:
+			out = append(out, v)
+		case jsonparser.Array:
+			eachArrayValue(v.data, f.opts, f.st, func(value jsonValue) {
+				if value.dataType == jsonparser.Object {
+					out = append(out, value)
+				}
+			})
+		}
+	}
+	return out
+}
+
 func (f *field) listValues() []jsonValue {
 	var out []jsonValue
 	for _, v := range f.values {
@@ -62,7 +172,43 @@ func (f *field) listValues() []jsonValue {
 	return out
 }
 
-type valuepath []string
+// eachArrayValue calls fn for every value in an array that is not itself an
+// array, flattening nested arrays up to the maximum array depth, where the
+// outermost array is level 1. Values in arrays nested more deeply are skipped,
+// as are any elements past the maximum number of array elements.
+func eachArrayValue(data []byte, opts *matcherOpts, st *evalState, fn func(jsonValue)) {
+	eachArrayValueAt(data, 1, opts, st, fn)
+}
+
+func eachArrayValueAt(data []byte, depth int, opts *matcherOpts, st *evalState, fn func(jsonValue)) {
+	if opts.maxArrayDepth >= 0 && depth > opts.maxArrayDepth {
+		return
+	}
+	seen := 0
+	_, err := jsonparser.ArrayEach(data,
+		func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+			if opts.maxArrayElements >= 0 && seen >= opts.maxArrayElements {
+				return
+			}
+			seen++
+			if dataType == jsonparser.Array {
+				eachArrayValueAt(value, depth+1, opts, st, fn)
+				return
+			}
+			v := jsonValue{
+				data:     value,
+				dataType: dataType,
+			}
+			if dataType != jsonparser.Object {
+				// objects are checked as they are traversed
+				st.checkValue(v)
+			}
+			fn(v)
+		})
+	st.checkErr(err)
+}
+
+type valuepath ast.Path
 
 func (vp valuepath) bottom() bool {
 	return len(vp) == 1
@@ -75,38 +221,67 @@ func (vp valuepath) next() valuepath {
 	return vp[1:]
 }
 
-func (vp valuepath) current() string {
+func (vp valuepath) current() ast.PathSegment {
 	return vp[0]
 }
 
 // possible optimization: if a field is referenced only in an exists query,
 // getValues can return early
-func getValues(path valuepath, data []byte, dataType jsonparser.ValueType) (fieldValues []jsonValue) {
+func getValues(path valuepath, data []byte, dataType jsonparser.ValueType, opts *matcherOpts, st *evalState) (fieldValues []jsonValue) {
 	var outputValues []jsonValue
 
+	if path.current().IsSelector() {
+		// selecting specific elements of an array, so gather them all up to
+		// resolve the selector against the length of the array
+		if dataType != jsonparser.Array {
+			return nil
+		}
+		var elements []jsonValue
+		_, err := jsonparser.ArrayEach(data,
+			func(child []byte, dataType jsonparser.ValueType, offset int, err error) {
+				elements = append(elements, jsonValue{data: child, dataType: dataType})
+			})
+		st.checkErr(err)
+		start, end := path.current().Bounds(len(elements))
+		if opts.maxArrayElements >= 0 && end-start > opts.maxArrayElements {
+			end = start + opts.maxArrayElements
+		}
+		for _, e := range elements[start:end] {
+			if path.bottom() {
+				st.checkValue(e)
+				outputValues = append(outputValues, e)
+				continue
+			}
+			outputValues = append(outputValues, getValues(path.next(), e.data, e.dataType, opts, st)...)
+		}
+		return outputValues
+	}
+
 	switch dataType {
 	case jsonparser.Object:
 		// looking for a path segment in an object, so look for that key
-		child, childType, _, _ := jsonparser.Get(data, path.current())
+		child, childType, _, err := jsonparser.Get(data, path.current().Key)
+		st.checkErr(err)
 		if child == nil {
 			return nil
 		}
 		// We found the value, and this is the last path segment, go ahead
 		// and return it.
 		if path.bottom() {
-			return []jsonValue{{data: child, dataType: childType}}
+			v := jsonValue{data: child, dataType: childType}
+			st.checkValue(v)
+			return []jsonValue{v}
 		}
 		// otherwise drill down on the next value in the chain
-		outputValues = append(outputValues, getValues(path.next(), child, childType)...)
+		outputValues = append(outputValues, getValues(path.next(), child, childType, opts, st)...)
 	case jsonparser.Array:
 		// looking for a path segment in an array, so look at every item, at
-		// this same level.
-		jsonparser.ArrayEach(data,
-			func(child []byte, dataType jsonparser.ValueType, offset int, err error) {
-				if dataType == jsonparser.Object {
-					outputValues = append(outputValues, getValues(path, child, jsonparser.Object)...)
-				}
-			})
+		// this same level, including those in nested arrays.
+		eachArrayValue(data, opts, st, func(child jsonValue) {
+			if child.dataType == jsonparser.Object {
+				outputValues = append(outputValues, getValues(path, child.data, jsonparser.Object, opts, st)...)
+			}
+		})
 	}
 	return outputValues
 }
@@ -154,21 +329,6 @@ func getBoolVal(v jsonValue) (boolVal bool, found bool) {
 	return false, false
 }
 
-func getDatetimeVal(v jsonValue) (intVal int64, found bool) {
-	// TODO: tighten this up for numstrings. Probably want to be more careful
-	// about what we consider a date with how flexible dateparse is. Maybe a
-	// special getStringVal() that only accepts int-like unix epochs.
-	sv, ok := getStringVal(v)
-	if !ok {
-		return 0, false
-	}
-	t, err := dateparse.ParseAny(sv)
-	if err != nil {
-		return 0, false
-	}
-	return t.UnixNano(), true
-}
-
 // true:
 //   - <boolean> true
 //   - <numeric> != 0
@@ -2,41 +2,90 @@ package jsonmatcher
 
 import (
 	"encoding/json"
-	"errors"
+	"sync"
 
 	"github.com/flowchartsman/aql/parser"
+	"github.com/flowchartsman/aql/parser/transform"
 )
 
-// Matcher performs an AQL query against JSON to see if it matches
+// Matcher performs an AQL query against JSON to see if it matches.
+//
+// A Matcher is safe for concurrent use by multiple goroutines, so a single
+// Matcher can be shared by any number of workers. Its configuration is fixed
+// when it is created, and any statistics it collects are aggregated with
+// atomic counters, so [Matcher.Stats] and [Matcher.FieldStats] can be called
+// while matches are in progress. Since each counter is updated independently,
+// a snapshot taken during matching may be slightly inconsistent between
+// nodes.
 type Matcher struct {
-	root     boolNode
-	query    string
-	messages []*parser.ParserMessage
+	root       boolNode
+	query      string
+	messages   []*parser.ParserMessage
+	opts       *matcherOpts
+	fieldStats map[string]*FieldStats
+	plan       *pathPlan
+	states     sync.Pool
 }
 
 // NewMatcher creates a new matcher that returns whether a JSON document matches
 // an AQL query
-func NewMatcher(aqlQuery string /*options*/) (*Matcher, error) {
+func NewMatcher(aqlQuery string, options ...MatcherOption) (*Matcher, error) {
+	m := &Matcher{
+		query: aqlQuery,
+		opts:  defaultOpts(),
+	}
+	for _, o := range options {
+		if err := o(m); err != nil {
+			return nil, err
+		}
+	}
 	visitor := parser.NewMessageVisitor(messageVisitor)
-	root, err := parser.ParseQuery(aqlQuery, parser.Visitors(visitor))
+	visitors := append([]parser.Visitor{visitor}, m.opts.visitors...)
+	root, err := parser.ParseQuery(aqlQuery, parser.Visitors(visitors...))
 	if err != nil {
 		return nil, err
 	}
-	builder := newBuilder(true)
-
-	return &Matcher{
-		root:     builder.build(root),
-		query:    aqlQuery,
-		messages: visitor.Messages(),
-	}, nil
+	m.messages = visitor.Messages()
+	if m.opts.normalize {
+		var hints []*parser.ParserMessage
+		root, hints = transform.Normalize(root)
+		m.messages = append(m.messages, hints...)
+	}
+	builder := newBuilder(m.opts)
+	m.root = builder.build(root)
+	if err := builder.checkFieldDateLayouts(); err != nil {
+		return nil, err
+	}
+	m.fieldStats = builder.fieldStats
+	m.plan = builder.plan
+	m.plan.index = nil
+	m.states.New = func() any {
+		return &evalState{
+			plan: m.plan,
+			lazy: m.opts.validation == validateLazy,
+		}
+	}
+	return m, nil
 }
 
-// Match returns whether or not the query matches on a JSON document.
+// Match returns whether or not the query matches on a JSON document. By
+// default, the whole document is validated first, and ErrInvalidJSON is
+// returned if it is not valid. See [LazyValidation] and [TrustInput] to reduce
+// this cost.
 func (m *Matcher) Match(data []byte) (bool, error) {
-	if !json.Valid(data) {
-		return false, errors.New("invalid JSON")
+	if m.opts.validation == validateFull && !json.Valid(data) {
+		return false, ErrInvalidJSON
+	}
+	st := m.states.Get().(*evalState)
+	defer func() {
+		st.reset()
+		m.states.Put(st)
+	}()
+	result := m.root.result(data, st)
+	if st.invalid {
+		return false, ErrInvalidJSON
 	}
-	return m.root.result(data), nil
+	return result, nil
 }
 
 // Messages will return any hints or warning messages that the matcher may have
@@ -50,15 +99,22 @@ func (m *Matcher) Query() string {
 	return m.query
 }
 
+// Stats returns the match statistics for the query. It will return nil unless
+// the matcher was created with the [TrackQueryStats] option.
 func (m *Matcher) Stats() *MatchStats {
 	return m.root.stats()
 }
 
-type MatcherOption func(*Matcher) error
-
-func TrackQueryStats( /*options*/ ) MatcherOption {
-	return func(m *Matcher) error {
-		_ = m
+// FieldStats returns statistics about the values found in each field
+// referenced by the query, keyed by the full path of the field. It will return
+// nil unless the matcher was created with the [TrackFieldStats] option.
+func (m *Matcher) FieldStats() map[string]*FieldStats {
+	if m.fieldStats == nil {
 		return nil
 	}
+	out := make(map[string]*FieldStats, len(m.fieldStats))
+	for k, v := range m.fieldStats {
+		out[k] = v
+	}
+	return out
 }
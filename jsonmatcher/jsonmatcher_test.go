@@ -1,13 +1,21 @@
 package jsonmatcher
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/flowchartsman/aql/parser"
+	"github.com/flowchartsman/aql/parser/ast"
 )
 
 func ExampleMatcher() {
@@ -38,7 +46,7 @@ func TestMatcher(t *testing.T) {
 		t.Fatalf("failed to read json file: %v", err)
 	}
 
-	paths, err := filepath.Glob(filepath.Join("testData", "*.input"))
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.input"))
 	if err != nil {
 		t.Fatalf("test data not found")
 	}
@@ -59,16 +67,20 @@ func TestMatcher(t *testing.T) {
 					if strings.TrimSpace(st.query) == "" {
 						t.Fatalf("empty test")
 					}
-					matcher, err := NewMatcher(st.query)
-					if err != nil {
-						t.Fatalf("unexpected error: %v", err)
-					}
-					matched, err := matcher.Match(jb)
-					if err != nil {
-						t.Fatalf("unexpected matcher error: %v", err)
-					}
-					if matched != st.expect {
-						t.Fatalf("want: %v, got: %v", st.expect, matched)
+					// valid documents match the same regardless of validation
+					// or optimization
+					for _, mode := range append(validationModes, optimizeModes...) {
+						matcher, err := NewMatcher(st.query, mode.option)
+						if err != nil {
+							t.Fatalf("unexpected error: %v", err)
+						}
+						matched, err := matcher.Match(jb)
+						if err != nil {
+							t.Fatalf("%s: unexpected matcher error: %v", mode.name, err)
+						}
+						if matched != st.expect {
+							t.Fatalf("%s: want: %v, got: %v", mode.name, st.expect, matched)
+						}
 					}
 				})
 			}
@@ -76,6 +88,389 @@ func TestMatcher(t *testing.T) {
 	}
 }
 
+var validationModes = []struct {
+	name   string
+	option MatcherOption
+}{
+	{"full", func(*Matcher) error { return nil }},
+	{"lazy", LazyValidation()},
+	{"none", TrustInput()},
+}
+
+var optimizeModes = []struct {
+	name   string
+	option MatcherOption
+}{
+	{"optimized", Optimize()},
+	{"adaptive", AdaptiveOptimize()},
+	{"normalized", NormalizeQuery()},
+}
+
+func TestLazyValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		doc       string
+		wantMatch bool
+		// wantErr is the expected error with lazy validation. Full
+		// validation always returns ErrInvalidJSON.
+		wantErr bool
+	}{
+		{"untouched error", `a:1`, `{"a":1, "b": nope}`, true, false},
+		{"truncated after match", `a:1`, `{"a":1, "b": [1, 2`, true, false},
+		{"bad literal", `a:true`, `{"a":tru}`, false, true},
+		{"bad escape", `a:"x"`, `{"a":"x\q"}`, false, true},
+		{"bad number", `a:1`, `{"a":1.2.3}`, false, true},
+		{"bad array element", `a:3`, `{"a":[1, 2, nope, 3]}`, false, true},
+		{"truncated array", `b:3`, `{"a":1, "b": [1, 2`, false, true},
+		{"bad object leaf", `a:exists`, `{"a":{"b":}}`, false, true},
+		{"bad subdoc", `a{b:1}`, `{"a":[{"b":1, "c":[}]}`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			full, err := NewMatcher(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := full.Match([]byte(tt.doc)); err != ErrInvalidJSON {
+				t.Errorf("expected full validation to fail, got %v", err)
+			}
+			lazy, err := NewMatcher(tt.query, LazyValidation())
+			if err != nil {
+				t.Fatal(err)
+			}
+			matched, err := lazy.Match([]byte(tt.doc))
+			if tt.wantErr {
+				if err != ErrInvalidJSON {
+					t.Errorf("expected ErrInvalidJSON, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if matched != tt.wantMatch {
+				t.Errorf("want: %v, got: %v", tt.wantMatch, matched)
+			}
+			// trusted input gives the same result without any checks
+			trusted, err := NewMatcher(tt.query, TrustInput())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched, err := trusted.Match([]byte(tt.doc)); err != nil || matched != tt.wantMatch {
+				t.Errorf("TrustInput: want: %v, got: %v, %v", tt.wantMatch, matched, err)
+			}
+		})
+	}
+}
+
+func TestMaxArrayDepth(t *testing.T) {
+	const doc = `{"a": [1, [2, [3, [4]]]], "b": [[{"c": 1}]]}`
+	tests := []struct {
+		depth  int
+		query  string
+		expect bool
+	}{
+		{1, `a:1`, true},
+		{1, `a:2`, false},
+		{2, `a:2`, true},
+		{2, `a:3`, false},
+		{3, `a:3`, true},
+		{3, `a:4`, false},
+		{-1, `a:4`, true},
+		{1, `b.c:1`, false},
+		{2, `b.c:1`, true},
+		{1, `b{c:1}`, false},
+		{2, `b{c:1}`, true},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d %s", test.depth, test.query), func(t *testing.T) {
+			matcher, err := NewMatcher(test.query, MaxArrayDepth(test.depth))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			matched, err := matcher.Match([]byte(doc))
+			if err != nil {
+				t.Fatalf("unexpected matcher error: %v", err)
+			}
+			if matched != test.expect {
+				t.Fatalf("want: %v, got: %v", test.expect, matched)
+			}
+		})
+	}
+	if _, err := NewMatcher(`a:1`, MaxArrayDepth(0)); err == nil {
+		t.Fatal("expected error for zero max array depth")
+	}
+}
+
+func TestMatcherOptions(t *testing.T) {
+	const doc = `{
+		"name": "Andy",
+		"hash": "dGVzdA==",
+		"word": "école",
+		"ts": "2024-03-10 06:30:00",
+		"created": "2024-03-10T03:30:00Z",
+		"day": "2024-03-10",
+		"num": 20240310,
+		"epoch": 1710041400,
+		"epoch_ms": 1710041400123,
+		"custom": "10/03/2024 06:30",
+		"list": [1, 2, 3, 4, 5]
+	}`
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	tests := []struct {
+		name    string
+		query   string
+		options []MatcherOption
+		expect  bool
+	}{
+		{"case-insensitive by default", `name:"andy"`, nil, true},
+		{"case-sensitive mismatch", `name:"andy"`, []MatcherOption{CaseSensitive()}, false},
+		{"case-sensitive match", `name:"Andy"`, []MatcherOption{CaseSensitive()}, true},
+		{"case-sensitive wildcard", `hash:"dGVz*"`, []MatcherOption{CaseSensitive()}, true},
+		{"case-sensitive wildcard mismatch", `hash:"dgvz*"`, []MatcherOption{CaseSensitive()}, false},
+		{"case-sensitive modifier mismatch", `name:"andy"c`, nil, false},
+		{"case-sensitive modifier match", `name:"Andy"c`, nil, true},
+		{"case-sensitive modifier wildcard", `hash:"dgvz*"c`, nil, false},
+		{"case-insensitive modifier overrides option", `name:"andy"i`, []MatcherOption{CaseSensitive()}, true},
+		{"case-insensitive modifier wildcard", `hash:"dgvz*"i`, []MatcherOption{CaseSensitive()}, true},
+		{"case-sensitive modifier unicode mismatch", `word:"ÉCOLE"c`, nil, false},
+		{"case-sensitive modifier unicode match", `word:"école"c`, nil, true},
+		{"mixed set", `name:("bob"c, "ANDY"c, "andy"i, "carl")`, []MatcherOption{CaseSensitive()}, true},
+		{"mixed set mismatch", `name:("bob"i, "ANDY"c, "andy"c, "carl")`, nil, false},
+		{"lenient dates by default", `ts:2024-03-10`, nil, true},
+		{"strict dates rejects loose formats", `ts:2024-03-10`, []MatcherOption{StrictDates()}, false},
+		{"strict dates accepts RFC3339", `created:2024-03-10`, []MatcherOption{StrictDates()}, true},
+		{"strict dates accepts full dates", `day:2024-03-10`, []MatcherOption{StrictDates()}, true},
+		{"strict dates rejects numbers", `num:>1970-01-01`, []MatcherOption{StrictDates()}, false},
+		{"date layouts reject numbers", `num:>1970-01-01`, []MatcherOption{DateLayouts(RFC3339)}, false},
+		{"date layouts reject other layouts", `day:2024-03-10`, []MatcherOption{DateLayouts(RFC3339)}, false},
+		{"epoch seconds", `epoch:2024-03-10`, []MatcherOption{DateLayouts(EpochSeconds)}, true},
+		{"epoch seconds mismatch", `epoch:>2024-03-10T03:31:00Z`, []MatcherOption{DateLayouts(EpochSeconds)}, false},
+		{"epoch millis", `epoch_ms:><(2024-03-10T03:30:00.1Z, 2024-03-10T03:30:00.2Z)`, []MatcherOption{DateLayouts(EpochMillis)}, true},
+		{"epoch millis read as seconds", `epoch_ms:>1970-01-01`, []MatcherOption{DateLayouts(EpochSeconds)}, false},
+		{"epoch layouts ignore strings", `created:2024-03-10`, []MatcherOption{DateLayouts(EpochSeconds)}, false},
+		{"custom layout", `custom:>2024-03-10T06:00:00Z`, []MatcherOption{DateLayouts(Layout("02/01/2006 15:04"))}, true},
+		{"custom layout in UTC", `custom:>2024-03-10T10:00:00Z`, []MatcherOption{DateLayouts(Layout("02/01/2006 15:04"))}, false},
+		{"custom layout in time zone", `custom:>2024-03-10T10:00:00Z`, []MatcherOption{DateLayouts(Layout("02/01/2006 15:04")), TimeZone(newYork)}, true},
+		{"layouts tried in order", `custom:2024-03-10`, []MatcherOption{DateLayouts(RFC3339, Layout("02/01/2006 15:04"))}, true},
+		{"field date layouts", `epoch:2024-03-10`, []MatcherOption{DateLayouts(RFC3339), FieldDateLayouts("epoch", EpochSeconds)}, true},
+		{"field date layouts only for field", `num:>1970-01-01 OR epoch:>2024-03-11`, []MatcherOption{DateLayouts(RFC3339), FieldDateLayouts("epoch", EpochSeconds)}, false},
+		{"full date in UTC", `created:2024-03-10`, nil, true},
+		{"full date in time zone", `created:2024-03-10`, []MatcherOption{TimeZone(newYork)}, false},
+		{"full date in time zone previous day", `created:2024-03-09`, []MatcherOption{TimeZone(newYork)}, true},
+		{"full date with time zone", `created:2024-03-10@America/New_York`, nil, false},
+		{"full date with time zone previous day", `created:2024-03-09@America/New_York`, nil, true},
+		{"full date time zone overrides option", `created:2024-03-10@UTC`, []MatcherOption{TimeZone(newYork)}, true},
+		{"full dates with time zones in range", `created:><(2024-03-10@UTC, 2024-03-10@America/New_York)`, nil, true},
+		{"full date with time zone in comparison", `created:<2024-03-10@America/New_York`, nil, true},
+		{"zoneless document date in UTC", `ts:>2024-03-10T10:00:00Z`, nil, false},
+		{"zoneless document date in time zone", `ts:>2024-03-10T10:00:00Z`, []MatcherOption{TimeZone(newYork)}, true},
+		{"array elements unlimited by default", `list:5`, nil, true},
+		{"array elements limited", `list:5`, []MatcherOption{MaxArrayElements(4)}, false},
+		{"array elements within limit", `list:4`, []MatcherOption{MaxArrayElements(4)}, true},
+		{"array selector elements limited", `list[1:]:5`, []MatcherOption{MaxArrayElements(3)}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matcher, err := NewMatcher(test.query, test.options...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			matched, err := matcher.Match([]byte(doc))
+			if err != nil {
+				t.Fatalf("unexpected matcher error: %v", err)
+			}
+			if matched != test.expect {
+				t.Fatalf("want: %v, got: %v", test.expect, matched)
+			}
+		})
+	}
+}
+
+func TestFieldDateLayoutsUnknownField(t *testing.T) {
+	_, err := NewMatcher(`event.created:>now-1d`, FieldDateLayouts("event.create", EpochSeconds))
+	want := "date layouts for field event.create, which the query does not reference"
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected error %q, got %v", want, err)
+	}
+	if _, err := NewMatcher(`event{created:>now-1d}`, FieldDateLayouts("event.created", EpochSeconds)); err != nil {
+		t.Fatalf("unexpected error for a field in a subdoc: %v", err)
+	}
+}
+
+func TestTrackQueryStats(t *testing.T) {
+	m, err := NewMatcher(`a:1 AND b:2`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats := m.Stats(); stats != nil {
+		t.Fatalf("expected no stats by default, got %+v", stats)
+	}
+	m, err = NewMatcher(`a:1 AND b:2`, TrackQueryStats())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := m.Match([]byte(`{"a":1,"b":2}`)); err != nil {
+		t.Fatalf("unexpected matcher error: %v", err)
+	}
+	stats := m.Stats()
+	if stats == nil {
+		t.Fatalf("expected stats")
+	}
+	if stats.TimesChecked != 1 || stats.TimesMatched != 1 {
+		t.Fatalf("expected 1 check and 1 match, got %d and %d", stats.TimesChecked, stats.TimesMatched)
+	}
+}
+
+func TestMatchStatsTree(t *testing.T) {
+	m, err := NewMatcher(`a:1 AND (b:2 OR !c:3) AND d{e:1}`, TrackQueryStats())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, doc := range []string{
+		`{"a":1,"b":2,"d":{"e":1}}`,
+		`{"a":1,"c":4,"d":[{"e":2},{"e":1}]}`,
+		`{"a":2}`,
+	} {
+		if _, err := m.Match([]byte(doc)); err != nil {
+			t.Fatalf("unexpected matcher error: %v", err)
+		}
+	}
+	stats := m.Stats()
+
+	var describe func(ms *MatchStats) string
+	describe = func(ms *MatchStats) string {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "(%s %d/%d/%d", ms.NodeName, ms.TimesChecked, ms.TimesMatched, ms.TimesShortCircuited)
+		for _, c := range ms.Children {
+			sb.WriteString(" ")
+			sb.WriteString(describe(c))
+		}
+		sb.WriteString(")")
+		return sb.String()
+	}
+	want := `(AND 3/2/1 (a: 1 3/2/0) (AND 2/2/0 (OR 2/2/1 (b: 2 2/1/0) (NOT 1/1/0 (c: 3 1/0/0))) (d{} 2/2/0 (e: 1 3/2/0))))`
+	if got := describe(stats); got != want {
+		t.Fatalf("\nexpected:\n%s\ngot:\n%s", want, got)
+	}
+	if stats.NodeType != NodeAnd || stats.Children[1].Children[1].NodeType != NodeSubdoc {
+		t.Fatalf("unexpected node types: %s, %s", stats.NodeType, stats.Children[1].Children[1].NodeType)
+	}
+	if stats.TotalTime <= 0 {
+		t.Fatalf("expected timing to be recorded")
+	}
+	if stats.Selectivity() != 2.0/3.0 {
+		t.Fatalf("expected selectivity of 2/3, got %f", stats.Selectivity())
+	}
+
+	statsB, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("error marshalling stats: %v", err)
+	}
+	var decoded MatchStats
+	if err := json.Unmarshal(statsB, &decoded); err != nil {
+		t.Fatalf("error unmarshalling stats: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, stats) {
+		t.Fatalf("stats did not survive round-trip:\n%s", statsB)
+	}
+}
+
+func TestConcurrentMatch(t *testing.T) {
+	const (
+		workers    = 16
+		iterations = 200
+	)
+	query := `(name:"andy*" OR description:"懒虫") AND ` +
+		`items{sku:/^A\d$/ AND qty:>1} AND ` +
+		`!ip:10.0.0.0/8 AND ts:><(2020-01-01,2030-01-01) AND tags[-1]:exists`
+	docs := []struct {
+		doc    string
+		expect bool
+	}{
+		{`{"name":"Andy Smith","items":[{"sku":"A1","qty":2}],"ip":"192.168.1.1","ts":"2024-01-01","tags":["a"]}`, true},
+		{`{"description":"大懒虫","items":[{"sku":"A2","qty":5}],"ts":"2024-06-01T00:00:00Z","tags":[1,2]}`, true},
+		{`{"name":"Andy","items":[{"sku":"A1","qty":1},{"sku":"B1","qty":9}],"ts":"2024-01-01","tags":["a"]}`, false},
+		{`{"name":"Andy","items":{"sku":"A3","qty":3},"ip":"10.1.2.3","ts":"2024-01-01","tags":["a"]}`, false},
+		{`{"name":"Bob","items":[{"sku":"A1","qty":2}],"ts":"2024-01-01","tags":["a"]}`, false},
+	}
+	m, err := NewMatcher(query, TrackQueryStats(), TrackFieldStats())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				d := docs[(w+i)%len(docs)]
+				matched, err := m.Match([]byte(d.doc))
+				if err != nil {
+					errs <- err
+					return
+				}
+				if matched != d.expect {
+					errs <- fmt.Errorf("doc %s: want: %v, got: %v", d.doc, d.expect, matched)
+					return
+				}
+				// read stats while matching is in progress
+				if i%50 == 0 {
+					if _, err := json.Marshal(m.Stats()); err != nil {
+						errs <- err
+						return
+					}
+					if _, err := json.Marshal(m.FieldStats()); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	stats := m.Stats()
+	if stats.TimesChecked != workers*iterations {
+		t.Fatalf("expected %d checks, got %d", workers*iterations, stats.TimesChecked)
+	}
+	if want := int64(workers * iterations * 2 / len(docs)); stats.TimesMatched != want {
+		t.Fatalf("expected %d matches, got %d", want, stats.TimesMatched)
+	}
+}
+
+func TestParserVisitors(t *testing.T) {
+	var fields []string
+	visitor := parser.VisitorFunc(func(node ast.Node) error {
+		if e, ok := node.(*ast.ExprNode); ok {
+			fields = append(fields, e.Field.String())
+		}
+		return nil
+	})
+	if _, err := NewMatcher(`a:1 AND b.c[0]:2`, ParserVisitors(visitor)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(fields, ",") != "a,b.c[0]" {
+		t.Fatalf("unexpected fields visited: %v", fields)
+	}
+	rejecter := parser.VisitorFunc(func(node ast.Node) error {
+		return errors.New("rejected")
+	})
+	if _, err := NewMatcher(`a:1`, ParserVisitors(rejecter)); err == nil {
+		t.Fatalf("expected visitor error")
+	}
+}
+
 type queryTest struct {
 	expect bool
 	name   string
@@ -131,3 +526,282 @@ func getTests(filename string) ([]queryTest, error) {
 	}
 	return out, nil
 }
+
+func TestNormalizeQuery(t *testing.T) {
+	const query = `(NOT (a:1 OR a:2) OR x:1 OR x:2) AND !(!b:3) AND c:>5 AND c:<3`
+	plain, err := NewMatcher(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	normalized, err := NewMatcher(query, NormalizeQuery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hints int
+	for _, m := range normalized.Messages() {
+		if m.Type == parser.MsgHint {
+			hints++
+		}
+	}
+	if hints != 4 {
+		t.Errorf("expected 4 hints, got %v", normalized.Messages())
+	}
+	for _, doc := range []string{
+		`{"a":3,"b":3,"c":[6,2]}`,
+		`{"a":[3,1],"b":3,"c":[6,2]}`,
+		`{"a":1,"x":2,"b":3,"c":[6,2]}`,
+		`{"b":[1,3],"c":[1,7]}`,
+		`{"b":3,"c":4}`,
+		`{"a":3,"c":[6,2]}`,
+	} {
+		want, err := plain.Match([]byte(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := normalized.Match([]byte(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: normalized query matched %v, original matched %v", doc, got, want)
+		}
+	}
+}
+
+func TestSimilarityLanguage(t *testing.T) {
+	tests := []struct {
+		query  string
+		doc    string
+		expect bool
+	}{
+		// å is a separate letter in Danish, not a with a diacritic
+		{`city:~"Arhus"`, `{"city":"Århus"}`, true},
+		{`city:~[da]"Arhus"`, `{"city":"Århus"}`, false},
+		{`city:~[da]"århus"`, `{"city":"Århus"}`, true},
+		// and so is ö in Swedish
+		{`name:~"Orjan"`, `{"name":"Örjan"}`, true},
+		{`name:~[sv]"Orjan"`, `{"name":"Örjan"}`, false},
+		{`name:~[fr]"Orjan"`, `{"name":"Örjan"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			m, err := NewMatcher(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := m.Match([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expect {
+				t.Errorf("%s: want: %v, got: %v", tt.doc, tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestFullDateDST(t *testing.T) {
+	// clocks in New York went forward an hour on 2024-03-10, so the day was
+	// only 23 hours long, from 05:00 UTC to 04:00 UTC the next day
+	tests := []struct {
+		ts     string
+		expect bool
+	}{
+		{"2024-03-10T04:59:59Z", false},
+		{"2024-03-10T05:00:00Z", true},
+		{"2024-03-11T03:59:59Z", true},
+		{"2024-03-11T04:00:00Z", false},
+		{"2024-03-11T04:30:00Z", false},
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	queries := map[string][]MatcherOption{
+		`ts:2024-03-10@America/New_York`: nil,
+		`ts:2024-03-10`:                  {TimeZone(newYork)},
+	}
+	for query, opts := range queries {
+		m, err := NewMatcher(query, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			got, err := m.Match([]byte(`{"ts":"` + tt.ts + `"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expect {
+				t.Errorf("%s: %s: want: %v, got: %v", query, tt.ts, tt.expect, got)
+			}
+		}
+	}
+}
+
+func TestRelativeDates(t *testing.T) {
+	const doc = `{
+		"ts": "2024-03-10T11:30:00Z",
+		"early": "2024-03-10T03:00:00Z",
+		"day": "2024-03-10",
+		"list": ["2024-03-04T00:00:00Z", "2024-03-03T23:59:59Z"]
+	}`
+	clock := Clock(func() time.Time {
+		return time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	})
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	tests := []struct {
+		query   string
+		options []MatcherOption
+		expect  bool
+	}{
+		{`ts:>now-1h`, nil, true},
+		{`ts:>now-15m`, nil, false},
+		{`ts:<now`, nil, true},
+		{`ts:><(now/d, now)`, nil, true},
+		// rounded values include the whole period at the upper end of a range
+		{`ts:><(now-1d/d, now/d)`, nil, true},
+		{`ts:[now-1d/d TO now/d}`, nil, false},
+		{`ts:[* TO now/d]`, nil, true},
+		{`ts:[* TO now-1d/d]`, nil, false},
+		{`ts:{now/d TO *]`, nil, false},
+		{`ts:{now-1d/d TO *]`, nil, true},
+		{`ts:>now/d`, nil, false},
+		{`ts:>now-1d/d`, nil, true},
+		{`ts:>=now/d`, nil, true},
+		{`ts:<=now/d`, nil, true},
+		{`ts:<=now-1d/d`, nil, false},
+		{`ts:<now/d`, nil, false},
+		{`ts:now/d`, nil, true},
+		{`ts:now-1d/d`, nil, false},
+		{`ts:now/h`, nil, false},
+		{`ts:now-1h/h`, nil, true},
+		{`day:now/d`, nil, true},
+		{`day:now/M`, nil, true},
+		{`day:now-1M/M`, nil, false},
+		{`day:now/y`, nil, true},
+		{`list:>=now/w`, nil, true},
+		{`list:><(now/w, now-5d)`, nil, true},
+		{`list:><(now-1w/w, now/w)`, nil, true},
+		// 03:00 UTC is still the previous day in New York
+		{`early:now/d`, nil, true},
+		{`early:now/d`, []MatcherOption{TimeZone(newYork)}, false},
+		{`early:now-1d/d`, []MatcherOption{TimeZone(newYork)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			m, err := NewMatcher(tt.query, append(tt.options, clock)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := m.Match([]byte(doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expect {
+				t.Errorf("want: %v, got: %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestRelativeDatesClock(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	m, err := NewMatcher(`ts:>now-1h`, Clock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := []byte(`{"ts":"2024-03-10T11:30:00Z"}`)
+	for _, tt := range []struct {
+		advance time.Duration
+		expect  bool
+	}{
+		{0, true},
+		{time.Hour, false},
+	} {
+		now = now.Add(tt.advance)
+		got, err := m.Match(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.expect {
+			t.Errorf("at %s: want: %v, got: %v", now, tt.expect, got)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	m, err := NewMatcher(`title:~"colour"~1 AND (tags:"red" OR NOT tags:"blue") AND items{name:~"widgit"~1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex, err := m.Explain([]byte(`{
+		"title": "The colors of the rainbow, in color",
+		"tags": ["blue"],
+		"items": [{"name": "gadget"}, {"name": "widget"}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Explanation{
+		NodeName: "AND",
+		NodeType: NodeAnd,
+		Children: []*Explanation{
+			{
+				NodeName: `title:~ "colour"~1`,
+				NodeType: NodeExpr,
+				Matched:  true,
+				Matches:  []string{`"color" (distance 1)`},
+			},
+			{
+				NodeName: "AND",
+				NodeType: NodeAnd,
+				Children: []*Explanation{
+					{
+						NodeName: "OR",
+						NodeType: NodeOr,
+						Children: []*Explanation{
+							{NodeName: `tags: "red"`, NodeType: NodeExpr},
+							{
+								NodeName: "NOT",
+								NodeType: NodeNot,
+								Children: []*Explanation{
+									{NodeName: `tags: "blue"`, NodeType: NodeExpr, Matched: true},
+								},
+							},
+						},
+					},
+					{
+						NodeName: "items{}",
+						NodeType: NodeSubdoc,
+						Matched:  true,
+						Children: []*Explanation{
+							{
+								NodeName: `name:~ "widgit"~1`,
+								NodeType: NodeExpr,
+								Matched:  true,
+								Matches:  []string{`"widget" (distance 1)`},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(ex, want) {
+		got, _ := json.MarshalIndent(ex, "", "  ")
+		t.Errorf("unexpected explanation:\n%s", got)
+	}
+	matched, err := m.Match([]byte(`{"title":"colour","tags":"red","items":{"name":"widget"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !matched {
+		t.Errorf("expected match")
+	}
+	if _, err := m.Explain([]byte(`{"title":`)); err != ErrInvalidJSON {
+		t.Errorf("expected ErrInvalidJSON, got %v", err)
+	}
+}
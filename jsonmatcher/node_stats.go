@@ -1,12 +1,52 @@
 package jsonmatcher
 
-import "go.uber.org/atomic"
+import (
+	"time"
 
+	"go.uber.org/atomic"
+)
+
+// Node types reported in [MatchStats].
+const (
+	NodeAnd    = "AND"
+	NodeOr     = "OR"
+	NodeNot    = "NOT"
+	NodeSubdoc = "SUBDOC"
+	NodeExpr   = "EXPR"
+)
+
+// MatchStats is a snapshot of match statistics for a node in the query, along
+// with the statistics of its children, which mirror the structure of the
+// query.
 type MatchStats struct {
-	NodeName     string        `json:"node_name"`
-	TimesChecked int64         `json:"times_checked"`
-	TimesMatched int64         `json:"times_matched"`
-	Children     []*MatchStats `json:"children,omitempty"`
+	NodeName     string `json:"node_name"`
+	NodeType     string `json:"node_type"`
+	TimesChecked int64  `json:"times_checked"`
+	TimesMatched int64  `json:"times_matched"`
+	// TimesShortCircuited is the number of times an AND or OR node was able to
+	// determine its result without checking all of its children.
+	TimesShortCircuited int64 `json:"times_short_circuited"`
+	// TotalTime is the total time spent evaluating this node, including its
+	// children.
+	TotalTime time.Duration `json:"total_time_ns"`
+	Children  []*MatchStats `json:"children,omitempty"`
+}
+
+// Selectivity returns the fraction of checks for which the node matched, or
+// -1 if it has never been checked.
+func (ms *MatchStats) Selectivity() float64 {
+	if ms.TimesChecked == 0 {
+		return -1
+	}
+	return float64(ms.TimesMatched) / float64(ms.TimesChecked)
+}
+
+// AverageTime returns the average time spent evaluating the node.
+func (ms *MatchStats) AverageTime() time.Duration {
+	if ms.TimesChecked == 0 {
+		return 0
+	}
+	return ms.TotalTime / time.Duration(ms.TimesChecked)
 }
 
 // TODO: When MarshalScalar/UnmarshalScalar land, these types can be replaced
@@ -14,23 +54,50 @@ type MatchStats struct {
 // ref: https://github.com/golang/go/issues/56235
 // ref: https://github.com/golang/go/issues/54582
 type nodeStats struct {
-	nodeName     string
-	timesChecked atomic.Int64 `json:"times_checked"`
-	timesMatched atomic.Int64 `json:"times_matched"`
+	nodeName            string
+	nodeType            string
+	timesChecked        atomic.Int64
+	timesMatched        atomic.Int64
+	timesShortCircuited atomic.Int64
+	totalNanos          atomic.Int64
 }
 
-func (ns *nodeStats) mark(matched bool) {
+func newNodeStats(nodeType string, nodeName string) *nodeStats {
+	return &nodeStats{
+		nodeName: nodeName,
+		nodeType: nodeType,
+	}
+}
+
+// start returns the start time for an evaluation, if stats are being
+// collected. It is safe to call on a nil *nodeStats.
+func (ns *nodeStats) start() time.Time {
+	if ns == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// mark records the result of a single evaluation that began at start.
+func (ns *nodeStats) mark(matched bool, shortCircuited bool, start time.Time) {
+	ns.totalNanos.Add(int64(time.Since(start)))
 	ns.timesChecked.Inc()
 	if matched {
 		ns.timesMatched.Inc()
 	}
+	if shortCircuited {
+		ns.timesShortCircuited.Inc()
+	}
 }
 
 func (ns *nodeStats) toStatsNode(children ...boolNode) *MatchStats {
 	sn := &MatchStats{
-		NodeName:     ns.nodeName,
-		TimesChecked: ns.timesChecked.Load(),
-		TimesMatched: ns.timesMatched.Load(),
+		NodeName:            ns.nodeName,
+		NodeType:            ns.nodeType,
+		TimesChecked:        ns.timesChecked.Load(),
+		TimesMatched:        ns.timesMatched.Load(),
+		TimesShortCircuited: ns.timesShortCircuited.Load(),
+		TotalTime:           time.Duration(ns.totalNanos.Load()),
 	}
 	if len(children) > 0 {
 		sn.Children = make([]*MatchStats, 0, len(children))
@@ -1,47 +1,104 @@
 package jsonmatcher
 
+import "github.com/flowchartsman/aql/parser/ast"
+
 type statsProvider interface {
 	stats() *MatchStats
 }
 
 type boolNode interface {
 	statsProvider
-	// result() (bool, error)
-	result([]byte) bool
+	// counters returns the node's own statistics, which are nil unless they
+	// are being collected.
+	counters() *nodeStats
+	// result evaluates the node against a document, or a subdocument of it.
+	result(root []byte, st *evalState) bool
+	// explain evaluates the node like result, describing how it did so.
+	explain(root []byte, st *evalState) *Explanation
 }
 
+// andNode matches if all of its children match, evaluating them in order
+// until one does not.
 type andNode struct {
-	left, right boolNode
-	nodeStats   *nodeStats
+	// children are in the order they are written, which the stats tree
+	// follows
+	children []boolNode
+	// order is the order to evaluate the children in, if the query is
+	// optimized
+	order     *childOrder
+	nodeStats *nodeStats
 }
 
-func (a *andNode) result(root []byte) bool {
-	result := a.left.result(root) && a.right.result(root)
+func (a *andNode) result(root []byte, st *evalState) bool {
+	start := a.nodeStats.start()
+	children := a.children
+	if a.order != nil {
+		children = a.order.get()
+	}
+	result := true
+	shortCircuited := false
+	for i, c := range children {
+		if !c.result(root, st) {
+			result = false
+			shortCircuited = i < len(children)-1
+			break
+		}
+	}
 	if a.nodeStats != nil {
-		a.nodeStats.mark(result)
+		a.nodeStats.mark(result, shortCircuited, start)
 	}
 	return result
 }
 
 func (a *andNode) stats() *MatchStats {
-	return a.nodeStats.toStatsNode()
+	if a.nodeStats == nil {
+		return nil
+	}
+	return a.nodeStats.toStatsNode(a.children...)
+}
+
+func (a *andNode) counters() *nodeStats {
+	return a.nodeStats
 }
 
+// orNode matches if any of its children match, evaluating them in order until
+// one does.
 type orNode struct {
-	left, right boolNode
-	nodeStats   *nodeStats
+	children  []boolNode
+	order     *childOrder
+	nodeStats *nodeStats
 }
 
-func (o *orNode) result(root []byte) bool {
-	result := o.left.result(root) || o.right.result(root)
+func (o *orNode) result(root []byte, st *evalState) bool {
+	start := o.nodeStats.start()
+	children := o.children
+	if o.order != nil {
+		children = o.order.get()
+	}
+	result := false
+	shortCircuited := false
+	for i, c := range children {
+		if c.result(root, st) {
+			result = true
+			shortCircuited = i < len(children)-1
+			break
+		}
+	}
 	if o.nodeStats != nil {
-		o.nodeStats.mark(result)
+		o.nodeStats.mark(result, shortCircuited, start)
 	}
 	return result
 }
 
 func (o *orNode) stats() *MatchStats {
-	return o.nodeStats.toStatsNode()
+	if o.nodeStats == nil {
+		return nil
+	}
+	return o.nodeStats.toStatsNode(o.children...)
+}
+
+func (o *orNode) counters() *nodeStats {
+	return o.nodeStats
 }
 
 type notNode struct {
@@ -49,55 +106,84 @@ type notNode struct {
 	nodeStats *nodeStats
 }
 
-func (n *notNode) result(root []byte) bool {
-	result := !n.sub.result(root)
+func (n *notNode) result(root []byte, st *evalState) bool {
+	start := n.nodeStats.start()
+	result := !n.sub.result(root, st)
 	if n.nodeStats != nil {
-		n.nodeStats.mark(result)
+		n.nodeStats.mark(result, false, start)
 	}
 	return result
 }
 
 func (n *notNode) stats() *MatchStats {
-	return n.nodeStats.toStatsNode()
-}
-
-// type subdocNode struct {
-// 	prefix    []string
-// 	sub       matcherNode
-// 	nodeStats *nodeStats
-// }
-
-// func (s *subdocNode) matches(value *fastjson.Value) bool {
-// 	rootDocs := getValues(s.prefix, value)
-// 	matched := false
-// 	for _, rootDoc := range rootDocs {
-// 		if s.sub.matches(rootDoc) {
-// 			matched = true
-// 			break
-// 		}
-// 	}
-// 	if s.nodeStats != nil {
-// 		s.nodeStats.mark(matched)
-// 	}
-// 	return matched
-// }
-
-// func (s *subdocNode) stats() *StatsNode {
-// 	if s.nodeStats == nil {
-// 		return nil
-// 	}
-// 	return s.nodeStats.toStatsNode(s.sub)
-// }
+	if n.nodeStats == nil {
+		return nil
+	}
+	return n.nodeStats.toStatsNode(n.sub)
+}
+
+func (n *notNode) counters() *nodeStats {
+	return n.nodeStats
+}
+
+// subdocNode evaluates its subquery against each object found at path,
+// matching if any single object satisfies the whole subquery.
+type subdocNode struct {
+	name       string
+	path       ast.Path
+	planned    []int
+	sub        boolNode
+	opts       *matcherOpts
+	nodeStats  *nodeStats
+	fieldStats *FieldStats
+}
+
+func (s *subdocNode) result(root []byte, st *evalState) bool {
+	start := s.nodeStats.start()
+	matched := false
+	field := getField(s.path, s.planned, root, s.opts, st)
+	for _, doc := range field.subdocs() {
+		if s.sub.result(doc.data, st) {
+			matched = true
+			break
+		}
+	}
+	if s.fieldStats != nil {
+		s.fieldStats.mark(st, field.values, matched)
+	}
+	if s.nodeStats != nil {
+		s.nodeStats.mark(matched, false, start)
+	}
+	return matched
+}
+
+func (s *subdocNode) stats() *MatchStats {
+	if s.nodeStats == nil {
+		return nil
+	}
+	return s.nodeStats.toStatsNode(s.sub)
+}
+
+func (s *subdocNode) counters() *nodeStats {
+	return s.nodeStats
+}
 
 type exprNode struct {
-	path      []string
-	exprs     []fieldExpr
-	nodeStats *nodeStats
+	name       string
+	path       ast.Path
+	planned    []int
+	exprs      []fieldExpr
+	opts       *matcherOpts
+	nodeStats  *nodeStats
+	fieldStats *FieldStats
+	dates      *dateParser
 }
 
-func (e exprNode) result(root []byte) bool {
+func (e exprNode) result(root []byte, st *evalState) bool {
+	start := e.nodeStats.start()
 	matched := false
-	field := getField(e.path, root)
+	field := getField(e.path, e.planned, root, e.opts, st)
+	field.dates = e.dates
 	if len(field.values) > 0 {
 		for _, m := range e.exprs {
 			if m.matches(field) {
@@ -107,8 +193,11 @@ func (e exprNode) result(root []byte) bool {
 		}
 	}
 
+	if e.fieldStats != nil {
+		e.fieldStats.mark(st, field.values, matched)
+	}
 	if e.nodeStats != nil {
-		e.nodeStats.mark(matched)
+		e.nodeStats.mark(matched, false, start)
 	}
 	return matched
 }
@@ -119,3 +208,7 @@ func (e exprNode) stats() *MatchStats {
 	}
 	return e.nodeStats.toStatsNode()
 }
+
+func (e exprNode) counters() *nodeStats {
+	return e.nodeStats
+}
@@ -0,0 +1,249 @@
+package jsonmatcher
+
+import (
+	"sort"
+
+	"github.com/flowchartsman/aql/parser/ast"
+	"go.uber.org/atomic"
+)
+
+// Relative costs of evaluating an expression against a single value, used to
+// order the children of AND and OR nodes so that the cheapest are evaluated
+// first.
+const (
+	costExists  = 1
+	costBool    = 2
+	costNumeric = 3
+	costNetwork = 3
+	// exact matches are a single lookup, regardless of the number of values
+	costExact  = 3
+	costString = 4
+	// dates may need to be parsed in many formats
+	costDatetime = 5
+	costRegex    = 5
+	costUnicode  = 6
+	// every word of a value is compared with a fuzzy term
+	costFuzzy = 8
+	// a subdocument query is evaluated against every object at its path
+	subdocFactor = 2
+)
+
+const (
+	// reorderInterval is how many evaluations of an adaptive node pass
+	// between reorderings of its children.
+	reorderInterval = 1024
+	// minReorderSamples is the number of times a child must have been
+	// evaluated before its measurements are used to order it.
+	minReorderSamples = 64
+)
+
+// andOperands returns the operands of a chain of AND nodes, flattening
+// (a AND (b AND c)) into [a, b, c].
+func andOperands(n *ast.AndNode) []ast.Node {
+	var out []ast.Node
+	for _, operand := range []ast.Node{n.Left, n.Right} {
+		if and, ok := operand.(*ast.AndNode); ok {
+			out = append(out, andOperands(and)...)
+			continue
+		}
+		out = append(out, operand)
+	}
+	return out
+}
+
+// orOperands returns the operands of a chain of OR nodes, flattening
+// (a OR (b OR c)) into [a, b, c].
+func orOperands(n *ast.OrNode) []ast.Node {
+	var out []ast.Node
+	for _, operand := range []ast.Node{n.Left, n.Right} {
+		if or, ok := operand.(*ast.OrNode); ok {
+			out = append(out, orOperands(or)...)
+			continue
+		}
+		out = append(out, operand)
+	}
+	return out
+}
+
+// buildOptimized builds the operands of an n-ary AND or OR node in the order
+// they are written, along with the order to evaluate them in, by their
+// estimated cost. If the matcher is adaptive, the order also adapts to their
+// measured cost and selectivity.
+func (b *builder) buildOptimized(operands []ast.Node, isOr bool) ([]boolNode, *childOrder) {
+	children := make([]boolNode, len(operands))
+	costs := make([]float64, len(operands))
+	for i, operand := range operands {
+		children[i] = b.build(operand)
+		costs[i] = estimateCost(operand, b.opts)
+	}
+	// the stable sort preserves the written order of operands with the same
+	// cost
+	idx := make([]int, len(operands))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return costs[idx[i]] < costs[idx[j]]
+	})
+	initial := make([]boolNode, len(operands))
+	for i, oi := range idx {
+		initial[i] = children[oi]
+	}
+	return children, newChildOrder(initial, isOr, b.opts.adaptive)
+}
+
+// estimateCost returns the estimated relative cost of evaluating a node,
+// assuming every part of it is evaluated.
+func estimateCost(node ast.Node, opts *matcherOpts) float64 {
+	switch n := node.(type) {
+	case *ast.AndNode:
+		return estimateCost(n.Left, opts) + estimateCost(n.Right, opts)
+	case *ast.OrNode:
+		return estimateCost(n.Left, opts) + estimateCost(n.Right, opts)
+	case *ast.NotNode:
+		return estimateCost(n.Expr, opts)
+	case *ast.SubdocNode:
+		return subdocFactor * estimateCost(n.Expr, opts)
+	case *ast.ExprNode:
+		switch n.Op {
+		case ast.EXS, ast.NUL:
+			return costExists
+		case ast.EXA, ast.EXI:
+			return costExact
+		}
+		var cost float64
+		for _, rv := range n.RVals {
+			if sv, ok := rv.(*ast.StringVal); ok && n.Op == ast.SIM {
+				// see exprSim
+				if _, fuzzy := sv.Fuzziness(); fuzzy {
+					cost += costFuzzy
+				} else {
+					cost += costUnicode
+				}
+				continue
+			}
+			cost += valueCost(rv, opts)
+		}
+		return cost
+	}
+	return 0
+}
+
+func valueCost(rv ast.Val, opts *matcherOpts) float64 {
+	switch v := rv.(type) {
+	case *ast.BoolVal:
+		return costBool
+	case *ast.IntVal, *ast.FloatVal:
+		return costNumeric
+	case *ast.NetVal:
+		return costNetwork
+	case *ast.TimeVal:
+		if opts.dateLayouts != nil {
+			return costNumeric
+		}
+		return costDatetime
+	case *ast.RegexpVal:
+		return costRegex
+	case *ast.StringVal:
+		// see getStringMatcher
+		if !isASCII(v.Value()) && !hasWildcard(v.Value()) {
+			return costUnicode
+		}
+		return costString
+	}
+	return costString
+}
+
+// childOrder is the order to evaluate the children of an AND or OR node in.
+// If it is adaptive, it adapts to their measured cost and selectivity. The
+// children of an AND node are ordered by how much time they take for each
+// document they eliminate, and those of an OR node by how much time they take
+// for each document they match, so that the node can short-circuit as cheaply
+// as possible.
+type childOrder struct {
+	// initial is the order by estimated cost
+	initial  []boolNode
+	isOr     bool
+	adaptive bool
+	checks   atomic.Int64
+	// order holds the current []boolNode, if adaptive
+	order atomic.Value
+}
+
+func newChildOrder(initial []boolNode, isOr bool, adaptive bool) *childOrder {
+	o := &childOrder{
+		initial:  initial,
+		isOr:     isOr,
+		adaptive: adaptive,
+	}
+	if adaptive {
+		o.order.Store(initial)
+	}
+	return o
+}
+
+// get returns the current order of the children, periodically updating it if
+// it is adaptive.
+func (o *childOrder) get() []boolNode {
+	if !o.adaptive {
+		return o.initial
+	}
+	if o.checks.Inc()%reorderInterval == 0 {
+		o.reorder()
+	}
+	return o.order.Load().([]boolNode)
+}
+
+// reorder sorts the children by their rank. Children that never short-circuit
+// the node go after those that do, and those that have not been evaluated
+// enough to be measured keep their estimated order after the rest, since they
+// are usually the ones that are rarely reached.
+func (o *childOrder) reorder() {
+	ranks := make(map[boolNode]childRank, len(o.initial))
+	for _, c := range o.initial {
+		ranks[c] = o.rank(c.counters())
+	}
+	order := append([]boolNode(nil), o.initial...)
+	sort.SliceStable(order, func(i, j int) bool {
+		return ranks[order[i]].less(ranks[order[j]])
+	})
+	o.order.Store(order)
+}
+
+type childRank struct {
+	tier int
+	cost float64
+}
+
+const (
+	tierShortCircuits = iota
+	tierNeverShortCircuits
+	tierUnmeasured
+)
+
+func (r childRank) less(other childRank) bool {
+	if r.tier != other.tier {
+		return r.tier < other.tier
+	}
+	return r.cost < other.cost
+}
+
+func (o *childOrder) rank(ns *nodeStats) childRank {
+	if ns == nil {
+		return childRank{tier: tierUnmeasured}
+	}
+	checked := ns.timesChecked.Load()
+	if checked < minReorderSamples {
+		return childRank{tier: tierUnmeasured}
+	}
+	avgNanos := float64(ns.totalNanos.Load()) / float64(checked)
+	// the probability that this child short-circuits the node
+	p := float64(ns.timesMatched.Load()) / float64(checked)
+	if !o.isOr {
+		p = 1 - p
+	}
+	if p == 0 {
+		return childRank{tier: tierNeverShortCircuits, cost: avgNanos}
+	}
+	return childRank{tier: tierShortCircuits, cost: avgNanos / p}
+}
@@ -0,0 +1,152 @@
+package jsonmatcher
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// describeOrder describes the structure and evaluation order of a matcher's
+// stats tree.
+func describeOrder(ms *MatchStats) string {
+	if len(ms.Children) == 0 {
+		return ms.NodeName
+	}
+	var parts []string
+	for _, c := range ms.Children {
+		parts = append(parts, describeOrder(c))
+	}
+	return fmt.Sprintf("(%s %s)", ms.NodeName, strings.Join(parts, ", "))
+}
+
+// describeEvalOrder describes the structure of a matcher's nodes in the order
+// they are evaluated. Stats must be enabled to name the nodes.
+func describeEvalOrder(n boolNode) string {
+	var children []boolNode
+	switch n := n.(type) {
+	case *andNode:
+		children = n.children
+		if n.order != nil {
+			children = n.order.get()
+		}
+	case *orNode:
+		children = n.children
+		if n.order != nil {
+			children = n.order.get()
+		}
+	case *notNode:
+		children = []boolNode{n.sub}
+	case *subdocNode:
+		children = []boolNode{n.sub}
+	}
+	name := n.counters().nodeName
+	if len(children) == 0 {
+		return name
+	}
+	var parts []string
+	for _, c := range children {
+		parts = append(parts, describeEvalOrder(c))
+	}
+	return fmt.Sprintf("(%s %s)", name, strings.Join(parts, ", "))
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: `a:"x" AND b:exists AND (c:1 AND d:true)`,
+			want:  `(AND b:exists , d: true, c: 1, a: "x")`,
+		},
+		{
+			query: `a:/x/ OR (b:"y" OR (c:1.5 OR d:null))`,
+			want:  `(OR d:null , c: 1.5, b: "y", a: /x/)`,
+		},
+		{
+			// mixed chains are not merged, and compound clauses are costed
+			// by all of their parts
+			query: `(a:"x" OR b:"y") AND c:"é" AND !d:1 AND e{f:1 AND g:2}`,
+			want:  `(AND (NOT d: 1), c: "é", (OR a: "x", b: "y"), (e{} (AND f: 1, g: 2)))`,
+		},
+		{
+			// equal costs keep the written order
+			query: `b:1 AND a:2 AND c:3`,
+			want:  `(AND b: 1, a: 2, c: 3)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			m, err := NewMatcher(tt.query, Optimize(), TrackQueryStats())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := describeEvalOrder(m.root); got != tt.want {
+				t.Errorf("\nexpected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOptimizeStatsShape(t *testing.T) {
+	// without Optimize, the stats tree mirrors the query as written
+	m, err := NewMatcher(`a:"x" AND b:exists AND c:1`, TrackQueryStats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `(AND a: "x", (AND b:exists , c: 1))`
+	if got := describeOrder(m.Stats()); got != want {
+		t.Errorf("\nexpected:\n%s\ngot:\n%s", want, got)
+	}
+	// with it, the chain is flattened, but keeps the written order, so that
+	// aqlgraph can match the stats to the query
+	m, err = NewMatcher(`a:"x" AND b:exists AND c:1`, Optimize(), TrackQueryStats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `(AND a: "x", b:exists , c: 1)`
+	if got := describeOrder(m.Stats()); got != want {
+		t.Errorf("\nexpected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestAdaptiveOptimize(t *testing.T) {
+	// b:exists is cheaper, but never eliminates a document, so a:1 should be
+	// moved ahead of it
+	const query = `b:exists AND a:1`
+	const docs = 5 * reorderInterval
+	doc := []byte(`{"a":2,"b":true}`)
+
+	static, err := NewMatcher(query, Optimize(), TrackQueryStats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	adaptive, err := NewMatcher(query, AdaptiveOptimize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < docs; i++ {
+		for _, m := range []*Matcher{static, adaptive} {
+			matched, err := m.Match(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if matched {
+				t.Fatal("unexpected match")
+			}
+		}
+	}
+	// stats keep the written order
+	existsStats := func(m *Matcher) *MatchStats {
+		return m.Stats().Children[0]
+	}
+	if checked := existsStats(static).TimesChecked; checked != docs {
+		t.Errorf("expected b:exists to be checked for every document in the static order, got %d", checked)
+	}
+	if checked := existsStats(adaptive).TimesChecked; checked > reorderInterval {
+		t.Errorf("expected b:exists to stop being checked after reordering, got %d checks", checked)
+	}
+	if checked := adaptive.Stats().Children[1].TimesChecked; checked != docs {
+		t.Errorf("expected a:1 to be checked for every document, got %d", checked)
+	}
+}
@@ -0,0 +1,251 @@
+package jsonmatcher
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flowchartsman/aql/parser"
+)
+
+const (
+	// DefaultMaxArrayDepth is the default maximum depth of nested arrays the
+	// matcher will descend into when searching for values.
+	DefaultMaxArrayDepth = 8
+	// DefaultMaxArrayElements is the default maximum number of elements the
+	// matcher will examine in any one array (unlimited).
+	DefaultMaxArrayElements = -1
+)
+
+type validationMode int
+
+const (
+	validateFull validationMode = iota
+	validateLazy
+	validateNone
+)
+
+// matcherOpts holds the settings a matcher is built with. They are fixed once
+// the matcher is created.
+type matcherOpts struct {
+	withStats        bool
+	withFieldStats   bool
+	caseSensitive    bool
+	dateLayouts      []DateLayout
+	fieldDateLayouts map[string][]DateLayout
+	location         *time.Location
+	now              func() time.Time
+	maxArrayDepth    int
+	maxArrayElements int
+	validation       validationMode
+	normalize        bool
+	optimize         bool
+	adaptive         bool
+	visitors         []parser.Visitor
+}
+
+func defaultOpts() *matcherOpts {
+	return &matcherOpts{
+		location:         time.UTC,
+		now:              time.Now,
+		maxArrayDepth:    DefaultMaxArrayDepth,
+		maxArrayElements: DefaultMaxArrayElements,
+	}
+}
+
+// MatcherOption configures a [Matcher] when it is created with [NewMatcher].
+type MatcherOption func(*Matcher) error
+
+// TrackQueryStats enables collection of match statistics, which can be
+// retrieved with [Matcher.Stats]. Statistics are disabled by default, since
+// they add overhead to every match.
+func TrackQueryStats() MatcherOption {
+	return func(m *Matcher) error {
+		m.opts.withStats = true
+		return nil
+	}
+}
+
+// TrackFieldStats enables collection of statistics about the values found in
+// each field referenced by the query, which can be retrieved with
+// [Matcher.FieldStats]. Field statistics are disabled by default, since they
+// add overhead to every match.
+func TrackFieldStats() MatcherOption {
+	return func(m *Matcher) error {
+		m.opts.withFieldStats = true
+		return nil
+	}
+}
+
+// CaseSensitive makes string and wildcard matches case-sensitive. By default,
+// string matching ignores case. Individual strings in a query can override
+// this with a case modifier: "value"c is always case-sensitive, and "value"i
+// never is. Regular expressions are unaffected, and can use the (?i) flag as
+// usual.
+func CaseSensitive() MatcherOption {
+	return func(m *Matcher) error {
+		m.opts.caseSensitive = true
+		return nil
+	}
+}
+
+// StrictDates restricts the datetime values the matcher will recognize in
+// documents to RFC3339 strings and full dates (YYYY-MM-DD). By default, the
+// matcher will attempt to interpret a wide variety of date formats, which can
+// cause unrelated strings and numbers to be treated as dates. It is the same
+// as DateLayouts(RFC3339, FullDate).
+func StrictDates() MatcherOption {
+	return DateLayouts(RFC3339, FullDate)
+}
+
+// DateLayouts restricts the datetime values the matcher will recognize in
+// documents to those written in one of the given layouts, which are tried in
+// order. Strings are only read by string layouts, and numbers only by epoch
+// layouts, so DateLayouts(RFC3339) will never treat a number as a date. Values
+// that cannot be read are reported in [Matcher.FieldStats].
+func DateLayouts(layouts ...DateLayout) MatcherOption {
+	return func(m *Matcher) error {
+		if len(layouts) == 0 {
+			return errors.New("no date layouts")
+		}
+		m.opts.dateLayouts = layouts
+		return nil
+	}
+}
+
+// FieldDateLayouts is like [DateLayouts], but only for a single field, which
+// takes precedence over the layouts for the matcher as a whole. The field is
+// written as it would be in a query, relative to the document root, as in
+// event.created or items[*].ts. NewMatcher returns an error if the query does
+// not reference the field.
+func FieldDateLayouts(field string, layouts ...DateLayout) MatcherOption {
+	return func(m *Matcher) error {
+		if len(layouts) == 0 {
+			return fmt.Errorf("no date layouts for field %s", field)
+		}
+		if m.opts.fieldDateLayouts == nil {
+			m.opts.fieldDateLayouts = map[string][]DateLayout{}
+		}
+		m.opts.fieldDateLayouts[field] = layouts
+		return nil
+	}
+}
+
+// TimeZone sets the time zone used to interpret full-date query values, such
+// as 2006-01-02, and document dates that do not specify a zone, and to round
+// relative values, such as now/d. The default is UTC.
+func TimeZone(loc *time.Location) MatcherOption {
+	return func(m *Matcher) error {
+		if loc == nil {
+			return errors.New("nil time zone")
+		}
+		m.opts.location = loc
+		return nil
+	}
+}
+
+// Clock sets the function the matcher calls for the current time when it
+// evaluates relative datetime values, such as now-15m. The default is
+// time.Now. A fixed clock makes matches deterministic, for testing.
+func Clock(now func() time.Time) MatcherOption {
+	return func(m *Matcher) error {
+		if now == nil {
+			return errors.New("nil clock")
+		}
+		m.opts.now = now
+		return nil
+	}
+}
+
+// ParserVisitors adds visitors to the query parsing pass, allowing callers to
+// inspect or validate the query before the matcher is built.
+func ParserVisitors(visitors ...parser.Visitor) MatcherOption {
+	return func(m *Matcher) error {
+		m.opts.visitors = append(m.opts.visitors, visitors...)
+		return nil
+	}
+}
+
+// MaxArrayDepth sets the maximum depth of directly-nested arrays the matcher
+// will flatten when searching for values, where the outermost array is depth
+// 1. Values nested more deeply are ignored. A negative depth means no limit,
+// and a depth of 0 is an error, since it would ignore the contents of every
+// array.
+func MaxArrayDepth(depth int) MatcherOption {
+	return func(m *Matcher) error {
+		if depth == 0 {
+			return errors.New("max array depth must be positive, or negative for no limit")
+		}
+		m.opts.maxArrayDepth = depth
+		return nil
+	}
+}
+
+// MaxArrayElements sets the maximum number of elements the matcher will
+// examine in any one array, bounding the work done on documents with very
+// large arrays. Elements past the limit are ignored. A negative number means
+// no limit.
+func MaxArrayElements(n int) MatcherOption {
+	return func(m *Matcher) error {
+		m.opts.maxArrayElements = n
+		return nil
+	}
+}
+
+// LazyValidation skips validating each document in full before it is matched.
+// Instead, only the parts of the document the query examines are validated,
+// and [Matcher.Match] returns ErrInvalidJSON if any of them are malformed.
+// Errors elsewhere in the document go unnoticed, and since evaluation stops
+// as soon as the result is known, whether an error is found can depend on the
+// document's contents. Statistics may include the partial evaluation of
+// invalid documents.
+func LazyValidation() MatcherOption {
+	return func(m *Matcher) error {
+		m.opts.validation = validateLazy
+		return nil
+	}
+}
+
+// TrustInput skips validating documents entirely, for input that is known to
+// be valid JSON, such as the output of an encoder. The result of matching an
+// invalid document is undefined, though it will not cause a panic.
+func TrustInput() MatcherOption {
+	return func(m *Matcher) error {
+		m.opts.validation = validateNone
+		return nil
+	}
+}
+
+// Optimize reorders the query for faster evaluation. Chains of AND and OR
+// clauses are flattened, and their clauses are evaluated in order of estimated
+// cost, cheapest first, so that expensive checks are skipped whenever a cheap
+// one decides the result. This does not change which documents match, but the
+// statistics from [Matcher.Stats] will reflect the optimized query.
+func Optimize() MatcherOption {
+	return func(m *Matcher) error {
+		m.opts.optimize = true
+		return nil
+	}
+}
+
+// AdaptiveOptimize is like [Optimize], but also periodically reorders clauses
+// based on how long they take and how often they match, as measured by the
+// matcher. It enables [TrackQueryStats], since it depends on them.
+func AdaptiveOptimize() MatcherOption {
+	return func(m *Matcher) error {
+		m.opts.optimize = true
+		m.opts.adaptive = true
+		m.opts.withStats = true
+		return nil
+	}
+}
+
+// NormalizeQuery simplifies the query with [transform.Normalize] before the
+// matcher is built, removing redundant clauses and merging comparisons where
+// possible. Each change is reported as a hint in [Matcher.Messages].
+func NormalizeQuery() MatcherOption {
+	return func(m *Matcher) error {
+		m.opts.normalize = true
+		return nil
+	}
+}
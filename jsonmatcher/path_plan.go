@@ -0,0 +1,97 @@
+package jsonmatcher
+
+import (
+	"strings"
+
+	"github.com/buger/jsonparser"
+	"github.com/flowchartsman/aql/parser/ast"
+)
+
+// pathPlan collects the paths a query references from the document root, so
+// that they can all be extracted in a single pass over the document, rather
+// than each expression walking the document from the root itself.
+//
+// jsonparser.EachKey cannot descend into arrays of objects, so each path is
+// registered along with every key prefix of it. The longest prefix found in a
+// document is then resolved the rest of the way with getValues, which gives
+// the same result as walking the whole path.
+type pathPlan struct {
+	paths [][]string
+	// index of each path by its string form, during building only
+	index map[string]int
+}
+
+func newPathPlan() *pathPlan {
+	return &pathPlan{
+		index: map[string]int{},
+	}
+}
+
+// add registers path and its key prefixes with the plan, returning their
+// indexes, longest first. It returns nil if the path cannot be planned, in
+// which case it should be resolved with getValues.
+func (p *pathPlan) add(path ast.Path) []int {
+	var prefixes []int
+	for i, seg := range path {
+		// jsonparser treats keys starting with '[' as array indexes, and
+		// cannot handle empty keys.
+		if seg.IsSelector() || seg.Key == "" || strings.HasPrefix(seg.Key, "[") {
+			break
+		}
+		prefix := path[:i+1]
+		name := prefix.String()
+		idx, ok := p.index[name]
+		if !ok {
+			idx = len(p.paths)
+			p.index[name] = idx
+			keys := make([]string, len(prefix))
+			for j, s := range prefix {
+				keys[j] = s.Key
+			}
+			p.paths = append(p.paths, keys)
+		}
+		prefixes = append([]int{idx}, prefixes...)
+	}
+	return prefixes
+}
+
+// extract finds the values of all paths in the plan in a single pass over the
+// document.
+func (p *pathPlan) extract(root []byte, st *evalState) {
+	st.extracted = true
+	if cap(st.values) < len(p.paths) {
+		st.values = make([]jsonValue, len(p.paths))
+	}
+	st.values = st.values[:len(p.paths)]
+	for i := range st.values {
+		st.values[i] = jsonValue{}
+	}
+	jsonparser.EachKey(root, func(idx int, value []byte, dataType jsonparser.ValueType, err error) {
+		st.checkErr(err)
+		if idx < 0 || err != nil {
+			return
+		}
+		st.values[idx] = jsonValue{data: value, dataType: dataType}
+	}, p.paths...)
+}
+
+// resolve returns the values at path using the plan, extracting them from the
+// document on first use.
+func (p *pathPlan) resolve(path ast.Path, prefixes []int, root []byte, opts *matcherOpts, st *evalState) []jsonValue {
+	if !st.extracted {
+		p.extract(root, st)
+	}
+	for _, idx := range prefixes {
+		v := st.values[idx]
+		if v.dataType == jsonparser.NotExist {
+			continue
+		}
+		found := len(p.paths[idx])
+		if found == len(path) {
+			st.checkValue(v)
+			return []jsonValue{v}
+		}
+		return getValues(valuepath(path[found:]), v.data, v.dataType, opts, st)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package jsonmatcher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flowchartsman/aql/parser"
+	"github.com/flowchartsman/aql/parser/ast"
+)
+
+func TestPathPlan(t *testing.T) {
+	const doc = `{
+		"a": {"b": {"c": 1}, "list": [{"c": 2}, {"c": [3, {"c": 4}]}, [{"c": 5}]]},
+		"scalar": "x",
+		"arr": [1, [2, 3], {"k": "v"}],
+		"dotted.key": {"inner": true},
+		"[bracket]": 6,
+		"": {"empty": 7},
+		"esc\"aped": 8,
+		"objs": [{"x": {"y": 1}}, {"x": {"y": 2}}, {"z": 3}]
+	}`
+	tests := []struct {
+		query string
+		found bool
+	}{
+		{`a.b.c:1`, true},
+		{`a.b:exists`, true},
+		{`a.list.c:exists`, true},
+		{`a.list[1].c:exists`, true},
+		{`a.list[*].c:exists`, true},
+		{`a.b.c.d:exists`, false},
+		{`scalar.x:exists`, false},
+		{`scalar:exists`, true},
+		{`arr:exists`, true},
+		{`arr[1]:exists`, true},
+		{`arr.k:exists`, true},
+		{`"dotted.key".inner:exists`, true},
+		{`"[bracket]":exists`, true},
+		{`"".empty:exists`, true},
+		{`"esc\"aped":exists`, true},
+		{`objs.x.y:exists`, true},
+		{`objs[-1].z:exists`, true},
+		{`missing.path:exists`, false},
+	}
+	var paths []ast.Path
+	for _, tt := range tests {
+		root, err := parser.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		paths = append(paths, root.(*ast.ExprNode).Field)
+	}
+
+	opts := defaultOpts()
+	opts.validation = validateLazy
+	plan := newPathPlan()
+	planned := make([][]int, len(paths))
+	for i, path := range paths {
+		planned[i] = plan.add(path)
+	}
+	for _, lazy := range []bool{false, true} {
+		st := &evalState{plan: plan, lazy: lazy}
+		for i, path := range paths {
+			want := getField(path, nil, []byte(doc), opts, st).values
+			got := getField(path, planned[i], []byte(doc), opts, st).values
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: planned values %v differ from walked values %v", tests[i].query, got, want)
+			}
+			if found := len(got) > 0; found != tests[i].found {
+				t.Errorf("%s: want found: %v, got: %v", tests[i].query, tests[i].found, found)
+			}
+		}
+		if st.invalid {
+			t.Errorf("valid document marked invalid")
+		}
+		// a pooled state must not keep the document alive
+		values := st.values[:cap(st.values)]
+		st.reset()
+		if len(st.values) != 0 {
+			t.Errorf("expected no extracted values after reset, got %d", len(st.values))
+		}
+		for i, v := range values {
+			if v.data != nil {
+				t.Errorf("extracted value %d still references the document after reset", i)
+			}
+		}
+	}
+}
+
+func TestPathPlanUnplannable(t *testing.T) {
+	plan := newPathPlan()
+	for _, path := range []ast.Path{
+		{{Type: ast.SegmentIndex}},
+		ast.NewPath(""),
+		ast.NewPath("[0]"),
+	} {
+		if prefixes := plan.add(path); prefixes != nil {
+			t.Errorf("%s: expected path not to be planned, got %v", path, prefixes)
+		}
+	}
+	prefixes := plan.add(ast.NewPath("a", "b", "[c]", "d"))
+	if !reflect.DeepEqual(prefixes, []int{1, 0}) {
+		t.Errorf("expected only key prefixes to be planned, got %v", prefixes)
+	}
+	// shared prefixes are only extracted once
+	if prefixes := plan.add(ast.NewPath("a", "e")); !reflect.DeepEqual(prefixes, []int{2, 0}) {
+		t.Errorf("expected shared prefix, got %v", prefixes)
+	}
+}
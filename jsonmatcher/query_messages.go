@@ -17,8 +17,8 @@ func messageVisitor(node ast.Node, tape *parser.MessageTape) error {
 		for _, v := range n.RVals {
 			switch rv := v.(type) {
 			case *ast.StringVal:
-				if n.Op == ast.SIM {
-					tape.WarningAt(rv.Pos(), `Similarity comparison is deprecated for string values. Please use the normal comparison operator - field:"<string>"`)
+				if n.Op == ast.SIM && hasWildcard(rv.Value()) {
+					tape.HintAt(rv.Pos(), `similarity matches do not support wildcards, so %s will be matched literally`, rv)
 				}
 			case *ast.RegexpVal:
 				if n.Op == ast.SIM {
@@ -26,7 +26,7 @@ func messageVisitor(node ast.Node, tape *parser.MessageTape) error {
 				}
 				reStr := strings.Trim(v.String(), `/`)
 				if strings.HasPrefix(reStr, "(?i)") && !isASCII(v.String()) {
-					tape.WarningAt(rv.Pos(), `Case-insensitive regular expression %s contains unicode characters. This may not work as intended. Consider a fuzzy match - 'field:~("value", "language")`, v.String())
+					tape.WarningAt(rv.Pos(), `Case-insensitive regular expression %s contains unicode characters. This may not work as intended. Consider a similarity match - 'field:~[language]"value"'`, v.String())
 				}
 				reStr = strings.TrimPrefix(reStr, "(?i)")
 				if strings.HasPrefix(reStr, ".*") || strings.HasSuffix(reStr, ".*") {
@@ -41,7 +41,7 @@ func messageVisitor(node ast.Node, tape *parser.MessageTape) error {
 					reg = reg.Sub[0]
 				}
 				if reg.Op == syntax.OpAlternate && !doesSomethingSpecial(reg) {
-					tape.HintAt(rv.Pos(), `if you are doing large string alternations, consider using a multi-string match: such as '%s:("one", "two")'`, strings.Join(n.Field, "."))
+					tape.HintAt(rv.Pos(), `if you are doing large string alternations, consider using a multi-string match: such as '%s:("one", "two")'`, n.Subject())
 				}
 				if len(reg.Sub) >= 2 {
 					if isDotStar(reg.Sub[0]) {
@@ -54,7 +54,7 @@ func messageVisitor(node ast.Node, tape *parser.MessageTape) error {
 					}
 				}
 			case *ast.TimeVal:
-				if n.Op == ast.EQ && !rv.DayOnly() {
+				if n.Op == ast.EQ && !rv.DayOnly() && !rv.Rounded() {
 					tape.WarningAt(rv.Pos(), `exact matches on full datetime values match the time EXACTLY, consider using [:] and a short date (YYYY-MM-DD) to match the day or a numeric comparison`)
 				}
 			}
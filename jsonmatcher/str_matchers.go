@@ -6,29 +6,42 @@ import (
 	"strings"
 	"unicode/utf8"
 
+	"github.com/flowchartsman/aql/parser/ast"
 	"golang.org/x/text/language"
 	"golang.org/x/text/search"
 )
 
-func getStringMatcher(str string) fieldExpr {
+// isCaseSensitive returns whether a string value should be matched
+// case-sensitively, taking its case modifier into account.
+func (o *matcherOpts) isCaseSensitive(sv *ast.StringVal) bool {
+	switch sv.Case() {
+	case ast.CaseSensitive:
+		return true
+	case ast.CaseInsensitive:
+		return false
+	}
+	return o.caseSensitive
+}
+
+func getStringMatcher(str string, caseSensitive bool) fieldExpr {
 	if !isASCII(str) && !hasWildcard(str) {
-		// TODO: replace this with a function type match
-		// foo:lang_match(<lang>, <str>)
-		// or
-		// foo:lang_match(<str>) with auto-detect language
-		return newUnicodeMatcher(str)
+		// language-specific matching is available with a similarity match:
+		// field:~[<lang>]"<str>"
+		return newUnicodeMatcher(str, caseSensitive)
 	}
 	return &exprRegexp{
-		value: stringSearchRegexp(str),
+		value: stringSearchRegexp(str, caseSensitive),
 	}
 }
 
 // todo: replace runs of spaces or handle spaces specially somehow?
-func stringSearchRegexp(wcString string) *regexp.Regexp {
-	wcString = strings.ToLower(wcString)
-	wcRunes := []rune(wcString)
+func stringSearchRegexp(wcString string, caseSensitive bool) *regexp.Regexp {
 	var buf bytes.Buffer
-	buf.WriteString(`(?i)`)
+	if !caseSensitive {
+		wcString = strings.ToLower(wcString)
+		buf.WriteString(`(?i)`)
+	}
+	wcRunes := []rune(wcString)
 	asciiOnly := isASCII(wcString)
 	if asciiOnly {
 		buf.WriteString(`\b`)
@@ -71,9 +84,15 @@ type unicodeMatcher struct {
 	pat *search.Pattern
 }
 
-func newUnicodeMatcher(str string) *unicodeMatcher {
+func newUnicodeMatcher(str string, caseSensitive bool) *unicodeMatcher {
+	opts := []search.Option{search.Loose}
+	if caseSensitive {
+		// width is compared at the same collation level as case, so it can't
+		// be ignored without also ignoring case
+		opts = []search.Option{search.IgnoreDiacritics}
+	}
 	return &unicodeMatcher{
-		pat: search.New(language.Und, search.Loose).CompileString(str),
+		pat: search.New(language.Und, opts...).CompileString(str),
 	}
 }
 
@@ -0,0 +1,243 @@
+package jsonmatcher
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// exprStringSet matches any of a set of literal ASCII strings with the same
+// semantics as stringSearchRegexp, which would otherwise compile a regular
+// expression for each one: a term must be found in a value as a whole word,
+// respecting ASCII word boundaries (\b), and case-insensitively unless
+// caseSensitive is set.
+//
+// The terms are compiled into an Aho-Corasick automaton, which finds all of
+// them in a single pass over the value, so that large sets, such as
+// blocklists, are practical.
+type exprStringSet struct {
+	ac            *acMatcher
+	caseSensitive bool
+}
+
+// isSetTerm returns whether a string can be matched by exprStringSet.
+func isSetTerm(str string) bool {
+	return str != "" && isASCII(str) && !hasWildcard(str)
+}
+
+func newStringSet(terms []string, caseSensitive bool) *exprStringSet {
+	if !caseSensitive {
+		lowered := make([]string, len(terms))
+		for i, t := range terms {
+			lowered[i] = strings.ToLower(t)
+		}
+		terms = lowered
+	}
+	return &exprStringSet{
+		ac:            newACMatcher(terms),
+		caseSensitive: caseSensitive,
+	}
+}
+
+func (e *exprStringSet) matches(field *field) bool {
+	for _, v := range field.scalarValues() {
+		str, ok := getStringVal(v)
+		if !ok {
+			continue
+		}
+		if e.matchString(str) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *exprStringSet) matchString(str string) bool {
+	if isASCII(str) {
+		return e.ac.match(len(str), func(i int) (byte, bool) {
+			c := str[i]
+			if !e.caseSensitive {
+				c = lowerASCII(c)
+			}
+			return c, isWordByte(str[i])
+		})
+	}
+	// Each rune becomes one symbol. Runes outside of ASCII cannot be part of
+	// a term, and are not word characters, except that case-insensitive
+	// matching folds the Kelvin sign and long s into ASCII letters, as (?i)
+	// does. Even then, they do not count as word characters for \b.
+	syms := make([]byte, 0, len(str))
+	words := make([]bool, 0, len(str))
+	for _, r := range str {
+		switch {
+		case r < utf8.RuneSelf:
+			c := byte(r)
+			if !e.caseSensitive {
+				c = lowerASCII(c)
+			}
+			syms = append(syms, c)
+			words = append(words, isWordByte(byte(r)))
+		case !e.caseSensitive && r == 'K':
+			syms = append(syms, 'k')
+			words = append(words, false)
+		case !e.caseSensitive && r == 'ſ':
+			syms = append(syms, 's')
+			words = append(words, false)
+		default:
+			syms = append(syms, acNoSymbol)
+			words = append(words, false)
+		}
+	}
+	return e.ac.match(len(syms), func(i int) (byte, bool) {
+		return syms[i], words[i]
+	})
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// isWordByte reports whether c is an ASCII word character, as used by \b.
+func isWordByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_'
+}
+
+// acNoSymbol is a symbol that is not part of any term.
+const acNoSymbol = 0x80
+
+// acMatcher is an Aho-Corasick automaton over ASCII terms.
+type acMatcher struct {
+	nodes []acNode
+	// root transitions, which are the most frequently used, as a table
+	root [utf8.RuneSelf]int32
+	// first symbol of each term, to check word boundaries
+	termFirst []byte
+	termLen   []int
+}
+
+type acNode struct {
+	edges []acEdge
+	fail  int32
+	// term ending at this node, or -1
+	term int32
+	// next node on the fail chain that ends a term, or -1
+	dict int32
+}
+
+type acEdge struct {
+	sym  byte
+	node int32
+}
+
+func (n *acNode) child(sym byte) int32 {
+	for _, e := range n.edges {
+		if e.sym == sym {
+			return e.node
+		}
+	}
+	return -1
+}
+
+func newACMatcher(terms []string) *acMatcher {
+	ac := &acMatcher{
+		nodes: []acNode{{term: -1, dict: -1}},
+	}
+	for _, t := range terms {
+		cur := int32(0)
+		for i := 0; i < len(t); i++ {
+			next := ac.nodes[cur].child(t[i])
+			if next < 0 {
+				next = int32(len(ac.nodes))
+				ac.nodes = append(ac.nodes, acNode{term: -1, dict: -1})
+				ac.nodes[cur].edges = append(ac.nodes[cur].edges, acEdge{sym: t[i], node: next})
+			}
+			cur = next
+		}
+		if ac.nodes[cur].term < 0 {
+			ac.nodes[cur].term = int32(len(ac.termLen))
+			ac.termFirst = append(ac.termFirst, t[0])
+			ac.termLen = append(ac.termLen, len(t))
+		}
+	}
+	// breadth-first to set the fail and dictionary links
+	queue := make([]int32, 0, len(ac.nodes))
+	for _, e := range ac.nodes[0].edges {
+		ac.nodes[e.node].fail = 0
+		queue = append(queue, e.node)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, e := range ac.nodes[cur].edges {
+			fail := ac.nodes[cur].fail
+			for fail > 0 && ac.nodes[fail].child(e.sym) < 0 {
+				fail = ac.nodes[fail].fail
+			}
+			if next := ac.nodes[fail].child(e.sym); next >= 0 && next != e.node {
+				fail = next
+			} else {
+				fail = 0
+			}
+			child := &ac.nodes[e.node]
+			child.fail = fail
+			if ac.nodes[fail].term >= 0 {
+				child.dict = fail
+			} else {
+				child.dict = ac.nodes[fail].dict
+			}
+			queue = append(queue, e.node)
+		}
+	}
+	for i := range ac.root {
+		ac.root[i] = ac.nodes[0].child(byte(i))
+		if ac.root[i] < 0 {
+			ac.root[i] = 0
+		}
+	}
+	return ac
+}
+
+func (ac *acMatcher) step(cur int32, sym byte) int32 {
+	if sym >= utf8.RuneSelf {
+		return 0
+	}
+	for cur > 0 {
+		if next := ac.nodes[cur].child(sym); next >= 0 {
+			return next
+		}
+		cur = ac.nodes[cur].fail
+	}
+	return ac.root[sym]
+}
+
+// match reports whether any term is found as a whole word in a sequence of n
+// symbols, where at returns each symbol and whether it is a word character.
+func (ac *acMatcher) match(n int, at func(i int) (sym byte, word bool)) bool {
+	isWord := func(i int) bool {
+		if i < 0 || i >= n {
+			return false
+		}
+		_, w := at(i)
+		return w
+	}
+	cur := int32(0)
+	for i := 0; i < n; i++ {
+		sym, _ := at(i)
+		cur = ac.step(cur, sym)
+		for out := cur; out > 0; out = ac.nodes[out].dict {
+			term := ac.nodes[out].term
+			if term < 0 {
+				continue
+			}
+			start := i - ac.termLen[term] + 1
+			// \b asserts that the word-ness of the characters on either side
+			// of the boundary differ
+			if isWord(start-1) != isWord(start) && isWord(i) != isWord(i+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
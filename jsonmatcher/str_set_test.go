@@ -0,0 +1,115 @@
+package jsonmatcher
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// regexpSetMatch matches terms the way exprStringSet replaces, with a regular
+// expression for each.
+func regexpSetMatch(terms []string, str string, caseSensitive bool) bool {
+	for _, t := range terms {
+		if stringSearchRegexp(t, caseSensitive).MatchString(str) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStringSet(t *testing.T) {
+	tests := []struct {
+		terms []string
+		str   string
+		want  bool
+	}{
+		{[]string{"foo", "bar"}, "a foo b", true},
+		{[]string{"foo", "bar"}, "a BAR", true},
+		{[]string{"foo", "bar"}, "foobar", false},
+		{[]string{"foo", "foobar"}, "foobar", true},
+		{[]string{"oba", "foobar baz"}, "xfoobar baz", false},
+		{[]string{"he", "she", "hers"}, "ushers", false},
+		{[]string{"he", "she", "hers"}, "u she rs", true},
+		{[]string{"-x", "y"}, "a-x", true},
+		{[]string{"-x", "y"}, "a -x", false},
+		{[]string{"x-", "y"}, "x-y", true},
+		{[]string{"a b", "c"}, "a b", true},
+		{[]string{"a_b", "c"}, "a_b_c", false},
+		{[]string{"kg", "s"}, "5 Kg", false},
+		{[]string{"kg", "s"}, "é kg é", true},
+		{[]string{"ask", "z"}, "aſk", true},
+		{[]string{"ask", "z"}, "éaskb", false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q in %q", tt.terms, tt.str), func(t *testing.T) {
+			if want := regexpSetMatch(tt.terms, tt.str, false); want != tt.want {
+				t.Fatalf("bad test: regular expressions give %v", want)
+			}
+			if got := newStringSet(tt.terms, false).matchString(tt.str); got != tt.want {
+				t.Errorf("want: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestStringSetRandom checks exprStringSet against the regular expressions it
+// replaces with random terms and values.
+func TestStringSetRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []string{"a", "b", "A", "B", "k", "s", "K", " ", "-", "_", "1", "é", "K", "ſ"}
+	randString := func(maxLen int, ascii bool) string {
+		var sb strings.Builder
+		for n := 1 + r.Intn(maxLen); n > 0; n-- {
+			s := alphabet[r.Intn(len(alphabet))]
+			if ascii && !isASCII(s) {
+				continue
+			}
+			sb.WriteString(s)
+		}
+		return sb.String()
+	}
+	for i := 0; i < 5000; i++ {
+		var terms []string
+		for n := 2 + r.Intn(4); n > 0; n-- {
+			if term := randString(4, true); term != "" {
+				terms = append(terms, term)
+			}
+		}
+		str := randString(12, false)
+		for _, caseSensitive := range []bool{false, true} {
+			want := regexpSetMatch(terms, str, caseSensitive)
+			if got := newStringSet(terms, caseSensitive).matchString(str); got != want {
+				t.Fatalf("%q in %q (case-sensitive: %v): want: %v, got: %v", terms, str, caseSensitive, want, got)
+			}
+		}
+	}
+}
+
+func TestStringSetQuery(t *testing.T) {
+	const doc = `{"tags":["alpha","beta"],"name":"Bad Actor","n":12}`
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{`tags:("gamma","BETA")`, true},
+		{`tags:("gamma","delta")`, false},
+		// wildcards and other values are still matched individually
+		{`tags:("gamma","b*")`, true},
+		{`name:("good","actor",/^x/)`, true},
+		{`n:("11","12")`, true},
+	}
+	for _, tt := range tests {
+		m, err := NewMatcher(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := m.Match([]byte(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: want: %v, got: %v", tt.query, tt.want, got)
+		}
+	}
+}
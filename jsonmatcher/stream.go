@@ -0,0 +1,399 @@
+package jsonmatcher
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxDocumentSize is the default maximum size of a single document in a
+// [Stream].
+const DefaultMaxDocumentSize = 16 << 20
+
+var (
+	// ErrInvalidJSON is returned when a document is not valid JSON.
+	ErrInvalidJSON = errors.New("invalid JSON")
+	// ErrUnterminatedDocument is reported for a document in a stream which
+	// ends before it is complete.
+	ErrUnterminatedDocument = errors.New("unterminated JSON document")
+	// ErrDocumentTooLarge is reported for a document in a stream which is
+	// larger than the maximum document size.
+	ErrDocumentTooLarge = errors.New("JSON document exceeds maximum size")
+)
+
+// DocumentError is an error with a single document in a stream. It does not
+// prevent the stream from continuing.
+type DocumentError struct {
+	// Line is the 1-based line on which the document started.
+	Line int
+	Err  error
+}
+
+func (e *DocumentError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
+
+// Stream matches a stream of JSON documents, one at a time. The documents can
+// be newline-delimited (NDJSON) or simply concatenated, with or without
+// whitespace between them, and may span multiple lines. Only one document is
+// held in memory at a time.
+//
+// Its usage is similar to [bufio.Scanner]:
+//
+//	s := m.Stream(r)
+//	for s.Next() {
+//		if err := s.DocErr(); err != nil {
+//			log.Println(err)
+//			continue
+//		}
+//		if s.Matched() {
+//			fmt.Printf("%s\n", s.Doc())
+//		}
+//	}
+//	if err := s.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Errors with individual documents, such as invalid JSON, are reported by
+// [Stream.DocErr] and do not stop the stream. To recover from truncated
+// documents, a line starting with '{' or '[' where a document could not
+// continue is taken to be the start of a new document, as is the line after a
+// string which is broken by a newline.
+type Stream struct {
+	m       *Matcher
+	r       *bufio.Reader
+	buf     []byte
+	maxSize int
+	line    int
+
+	doc     []byte
+	docLine int
+	matched bool
+	docErr  error
+	err     error
+}
+
+// Stream returns a new [Stream] to match documents read from r.
+func (m *Matcher) Stream(r io.Reader) *Stream {
+	return &Stream{
+		m:       m,
+		r:       bufio.NewReader(r),
+		maxSize: DefaultMaxDocumentSize,
+		line:    1,
+	}
+}
+
+// SetMaxDocumentSize sets the maximum size of a single document. Larger
+// documents are skipped and reported with [ErrDocumentTooLarge]. It must be
+// called before the first call to [Stream.Next].
+func (s *Stream) SetMaxDocumentSize(size int) {
+	s.maxSize = size
+}
+
+// Next advances the stream to the next document, which is then available
+// through the other methods. It returns false when the stream is exhausted or
+// reading fails.
+func (s *Stream) Next() bool {
+	s.doc, s.matched, s.docErr = nil, false, nil
+	if s.err != nil {
+		return false
+	}
+	doc, line, docErr, err := s.readDoc()
+	if err != nil {
+		if err != io.EOF {
+			s.err = err
+		}
+		return false
+	}
+	s.docLine = line
+	if docErr != nil {
+		s.docErr = &DocumentError{Line: line, Err: docErr}
+		return true
+	}
+	s.doc = doc
+	matched, err := s.m.Match(doc)
+	if err != nil {
+		s.docErr = &DocumentError{Line: line, Err: err}
+		return true
+	}
+	s.matched = matched
+	return true
+}
+
+// Doc returns the current document. The underlying array may be overwritten by
+// the next call to [Stream.Next]. It is nil if the document could not be read
+// from the stream, such as when it is truncated.
+func (s *Stream) Doc() []byte {
+	return s.doc
+}
+
+// Line returns the 1-based line on which the current document started.
+func (s *Stream) Line() int {
+	return s.docLine
+}
+
+// Matched returns whether the current document matched the query.
+func (s *Stream) Matched() bool {
+	return s.matched
+}
+
+// DocErr returns a *[DocumentError] if the current document could not be read
+// or matched.
+func (s *Stream) DocErr() error {
+	return s.docErr
+}
+
+// Err returns the first non-EOF error encountered while reading the stream.
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// readDoc reads the next document. Errors with the document itself are
+// returned as docErr, while errors reading the stream are returned as err.
+func (s *Stream) readDoc() (doc []byte, line int, docErr error, err error) {
+	first, err := s.skipSpace()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	line = s.line
+	s.buf = append(s.buf[:0], first)
+
+	var (
+		depth    int
+		inString bool
+		escaped  bool
+		oversize bool
+		// last significant byte outside of a string, to determine whether
+		// the document can continue on the next line
+		last = first
+	)
+	switch first {
+	case '{', '[':
+		depth = 1
+	case '"':
+		inString = true
+	default:
+		if !isScalarStart(first) {
+			// not JSON, so skip to the next line
+			if err := s.skipLine(); err != nil && err != io.EOF {
+				return nil, 0, nil, err
+			}
+			return nil, line, ErrInvalidJSON, nil
+		}
+		return s.readScalar(line)
+	}
+
+	for depth > 0 || inString {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				return nil, line, ErrUnterminatedDocument, nil
+			}
+			return nil, 0, nil, err
+		}
+		if b == '\n' {
+			s.line++
+			if inString {
+				return nil, line, ErrUnterminatedDocument, nil
+			}
+			if !canContinue(last) {
+				if next, err := s.r.Peek(1); err == nil && (next[0] == '{' || next[0] == '[') {
+					return nil, line, ErrUnterminatedDocument, nil
+				}
+			}
+		}
+		if !oversize {
+			if len(s.buf) >= s.maxSize {
+				oversize = true
+				s.buf = s.buf[:0]
+			} else {
+				s.buf = append(s.buf, b)
+			}
+		}
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+				last = b
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		}
+		if !isSpace(b) {
+			last = b
+		}
+	}
+	if oversize {
+		return nil, line, ErrDocumentTooLarge, nil
+	}
+	return s.buf, line, nil, nil
+}
+
+// readScalar reads a top-level number, boolean or null, which ends at
+// whitespace or the start of another value.
+func (s *Stream) readScalar(line int) (doc []byte, l int, docErr error, err error) {
+	for {
+		next, err := s.r.Peek(1)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, 0, nil, err
+		}
+		if isSpace(next[0]) || next[0] == '{' || next[0] == '[' || next[0] == '"' {
+			break
+		}
+		if len(s.buf) >= s.maxSize {
+			if err := s.skipLine(); err != nil && err != io.EOF {
+				return nil, 0, nil, err
+			}
+			return nil, line, ErrDocumentTooLarge, nil
+		}
+		b, _ := s.r.ReadByte()
+		s.buf = append(s.buf, b)
+	}
+	if !json.Valid(s.buf) {
+		// a bare word, so skip the rest of the line rather than reporting
+		// every word on it
+		if err := s.skipLine(); err != nil && err != io.EOF {
+			return nil, 0, nil, err
+		}
+		return nil, line, ErrInvalidJSON, nil
+	}
+	return s.buf, line, nil, nil
+}
+
+func (s *Stream) skipSpace() (byte, error) {
+	for {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b == '\n' {
+			s.line++
+		}
+		if !isSpace(b) {
+			return b, nil
+		}
+	}
+}
+
+func (s *Stream) skipLine() error {
+	for {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b == '\n' {
+			s.line++
+			return nil
+		}
+	}
+}
+
+// canContinue returns whether a document can continue on the next line after
+// the given byte. After a value, the document can only continue with a comma
+// or closing bracket, never with the start of an object or array.
+func canContinue(last byte) bool {
+	switch last {
+	case ',', ':', '[', '{':
+		return true
+	}
+	return false
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func isScalarStart(b byte) bool {
+	return b == '-' || (b >= '0' && b <= '9') || b == 't' || b == 'f' || b == 'n'
+}
+
+// FilterOption configures [Matcher.Filter] and [Matcher.FilterParallel].
+type FilterOption func(*filterOpts)
+
+type filterOpts struct {
+	onError func(error)
+}
+
+func newFilterOpts(options []FilterOption) *filterOpts {
+	fo := &filterOpts{}
+	for _, o := range options {
+		o(fo)
+	}
+	return fo
+}
+
+// OnDocumentError passes each error with an individual document to onError, as
+// a *[DocumentError]. By default, such documents are skipped silently.
+func OnDocumentError(onError func(error)) FilterOption {
+	return func(fo *filterOpts) {
+		fo.onError = onError
+	}
+}
+
+// Filter reads a stream of JSON documents from r, as with [Matcher.Stream],
+// and writes those which match to w, one per line. Documents that span
+// multiple lines are compacted onto one. Errors with individual documents do
+// not stop the stream, and can be reported with [OnDocumentError]. Filter only
+// returns an error if reading from r or writing to w fails.
+func (m *Matcher) Filter(r io.Reader, w io.Writer, options ...FilterOption) error {
+	onError := newFilterOpts(options).onError
+	s := m.Stream(r)
+	bw := bufio.NewWriter(w)
+	var compacted bytes.Buffer
+	for s.Next() {
+		if err := s.DocErr(); err != nil {
+			if onError != nil {
+				onError(err)
+			}
+			continue
+		}
+		if !s.Matched() {
+			continue
+		}
+		if err := writeDoc(bw, s.Doc(), &compacted); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeDoc writes a matching document to bw on a single line, compacting it
+// into scratch if it spans multiple lines.
+func writeDoc(bw *bufio.Writer, doc []byte, scratch *bytes.Buffer) error {
+	if bytes.IndexByte(doc, '\n') >= 0 {
+		scratch.Reset()
+		if err := json.Compact(scratch, doc); err != nil {
+			// backstop, the document has already been validated
+			return err
+		}
+		doc = scratch.Bytes()
+	}
+	if _, err := bw.Write(doc); err != nil {
+		return err
+	}
+	return bw.WriteByte('\n')
+}
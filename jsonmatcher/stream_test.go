@@ -0,0 +1,198 @@
+package jsonmatcher
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	type doc struct {
+		Line    int
+		Doc     string
+		Matched bool
+		Err     error
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  []doc
+	}{
+		{
+			name:  "ndjson",
+			input: "{\"a\":1}\n{\"a\":2}\n\n{\"a\":1}\n",
+			want: []doc{
+				{Line: 1, Doc: `{"a":1}`, Matched: true},
+				{Line: 2, Doc: `{"a":2}`},
+				{Line: 4, Doc: `{"a":1}`, Matched: true},
+			},
+		},
+		{
+			name:  "concatenated",
+			input: `{"a":1}{"a":2} {"a":"}{"}[1]`,
+			want: []doc{
+				{Line: 1, Doc: `{"a":1}`, Matched: true},
+				{Line: 1, Doc: `{"a":2}`},
+				{Line: 1, Doc: `{"a":"}{"}`},
+				{Line: 1, Doc: `[1]`},
+			},
+		},
+		{
+			name:  "multiline",
+			input: "{\n  \"a\": [\n    {\"b\": 1},\n    1\n  ]\n}\n{\"a\":1}",
+			want: []doc{
+				{Line: 1, Doc: "{\n  \"a\": [\n    {\"b\": 1},\n    1\n  ]\n}", Matched: true},
+				{Line: 7, Doc: `{"a":1}`, Matched: true},
+			},
+		},
+		{
+			name:  "scalars",
+			input: "1 true\n\"str\" null",
+			want: []doc{
+				{Line: 1, Doc: `1`},
+				{Line: 1, Doc: `true`},
+				{Line: 2, Doc: `"str"`},
+				{Line: 2, Doc: `null`},
+			},
+		},
+		{
+			name:  "invalid line",
+			input: "{\"a\":1}\nnot json at all\n{\"a\":1,}\n{\"a\":1}",
+			want: []doc{
+				{Line: 1, Doc: `{"a":1}`, Matched: true},
+				{Line: 2, Err: ErrInvalidJSON},
+				{Line: 3, Doc: `{"a":1,}`, Err: ErrInvalidJSON},
+				{Line: 4, Doc: `{"a":1}`, Matched: true},
+			},
+		},
+		{
+			name:  "truncated string",
+			input: "{\"a\":1, \"b\":\"abc\n{\"a\":1}",
+			want: []doc{
+				{Line: 1, Err: ErrUnterminatedDocument},
+				{Line: 2, Doc: `{"a":1}`, Matched: true},
+			},
+		},
+		{
+			name:  "truncated value",
+			input: "{\"a\":1, \"b\":[1,2\n{\"a\":1}\n[1,\n{\"a\":1}]",
+			want: []doc{
+				{Line: 1, Err: ErrUnterminatedDocument},
+				{Line: 2, Doc: `{"a":1}`, Matched: true},
+				{Line: 3, Doc: "[1,\n{\"a\":1}]"},
+			},
+		},
+		{
+			name:  "truncated at EOF",
+			input: "{\"a\":1}\n{\"a\":",
+			want: []doc{
+				{Line: 1, Doc: `{"a":1}`, Matched: true},
+				{Line: 2, Err: ErrUnterminatedDocument},
+			},
+		},
+	}
+	m, err := NewMatcher(`a:1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []doc
+			s := m.Stream(strings.NewReader(tt.input))
+			for s.Next() {
+				d := doc{
+					Line:    s.Line(),
+					Doc:     string(s.Doc()),
+					Matched: s.Matched(),
+				}
+				if err := s.DocErr(); err != nil {
+					var docErr *DocumentError
+					if !errors.As(err, &docErr) {
+						t.Fatalf("expected *DocumentError, got %T", err)
+					}
+					if docErr.Line != s.Line() {
+						t.Errorf("error line %d, document line %d", docErr.Line, s.Line())
+					}
+					d.Err = docErr.Err
+				}
+				got = append(got, d)
+			}
+			if err := s.Err(); err != nil {
+				t.Fatalf("unexpected stream error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got:\n%+v\nwant:\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamMaxDocumentSize(t *testing.T) {
+	m, err := NewMatcher(`a:1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := `{"a":1}` + "\n" + `{"a":1,"b":"` + strings.Repeat("x", 100) + `"}` + "\n" + `{"a":1}`
+	s := m.Stream(strings.NewReader(input))
+	s.SetMaxDocumentSize(32)
+	var matched int
+	var docErrs []error
+	for s.Next() {
+		if err := s.DocErr(); err != nil {
+			docErrs = append(docErrs, err)
+			continue
+		}
+		if s.Matched() {
+			matched++
+		}
+	}
+	if matched != 2 {
+		t.Errorf("expected 2 matches, got %d", matched)
+	}
+	if len(docErrs) != 1 || !errors.Is(docErrs[0], ErrDocumentTooLarge) {
+		t.Errorf("expected a single ErrDocumentTooLarge, got %v", docErrs)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	m, err := NewMatcher(`a:1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := "{\"a\":1,\"n\":1}\n{\"a\":2}\ngarbage\n{\n  \"a\": 1,\n  \"n\": 2\n}\n{\"a\":1,\"n\":3}"
+	var out bytes.Buffer
+	var errs []string
+	err = m.Filter(strings.NewReader(input), &out, OnDocumentError(func(err error) {
+		errs = append(errs, err.Error())
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\"a\":1,\"n\":1}\n{\"a\":1,\"n\":2}\n{\"a\":1,\"n\":3}\n"
+	if out.String() != want {
+		t.Errorf("got output:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if !reflect.DeepEqual(errs, []string{"line 3: invalid JSON"}) {
+		t.Errorf("unexpected errors: %q", errs)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestFilterWriteError(t *testing.T) {
+	m, err := NewMatcher(`a:1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := strings.Repeat(`{"a":1}`+"\n", 10000)
+	err = m.Filter(strings.NewReader(input), failingWriter{})
+	if err == nil || err.Error() != "write failed" {
+		t.Errorf("expected write error, got %v", err)
+	}
+}
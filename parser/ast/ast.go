@@ -21,6 +21,10 @@ const (
 	GTE Op = `>=`
 	BET Op = `><`
 	SIM Op = `~`
+	// EXA and EXI match whole string values, case-sensitively and
+	// case-insensitively, rather than searching within them
+	EXA Op = `=`
+	EXI Op = `~=`
 	EXS Op = `exists`
 	NUL Op = `null`
 )
@@ -32,8 +36,9 @@ type Node interface {
 
 // TODO: AndExpr, etc
 type AndNode struct {
-	Left  Node
-	Right Node
+	Left     Node
+	Right    Node
+	Position Pos
 }
 
 func (a *AndNode) IsNode() {}
@@ -42,9 +47,14 @@ func (a *AndNode) String() string {
 	return fmt.Sprintf("(&& %s %s)", a.Left.String(), a.Right.String())
 }
 
+func (a *AndNode) Pos() Pos {
+	return a.Position
+}
+
 type OrNode struct {
-	Left  Node
-	Right Node
+	Left     Node
+	Right    Node
+	Position Pos
 }
 
 func (o *OrNode) IsNode() {}
@@ -52,8 +62,13 @@ func (o *OrNode) String() string {
 	return fmt.Sprintf("(|| %s %s)", o.Left.String(), o.Right.String())
 }
 
+func (o *OrNode) Pos() Pos {
+	return o.Position
+}
+
 type NotNode struct {
-	Expr Node
+	Expr     Node
+	Position Pos
 }
 
 func (n *NotNode) IsNode() {}
@@ -61,9 +76,16 @@ func (n *NotNode) String() string {
 	return fmt.Sprintf("(! %s)", n.Expr.String())
 }
 
+func (n *NotNode) Pos() Pos {
+	return n.Position
+}
+
+// SubdocNode scopes a query to the subdocument(s) found at Field, so that all
+// conditions in Expr must be satisfied by the same object.
 type SubdocNode struct {
-	Field []string
-	Expr  Node
+	Field    Path
+	Expr     Node
+	Position Pos
 }
 
 func (s *SubdocNode) IsNode() {}
@@ -71,20 +93,113 @@ func (s *SubdocNode) String() string {
 	return fmt.Sprintf(`(%s{%s})`, FieldString(s.Field), s.Expr.String())
 }
 
+func (s *SubdocNode) Pos() Pos {
+	return s.Position
+}
+
 type ExprNode struct {
-	Op       Op
-	Field    []string
+	Op Op
+	// Params are the parameters of the operation, if any, such as the
+	// language and strength of a similarity match: field:~[fr, ignore-case]
+	Params []string
+	// ParamPos holds the position of each of the Params
+	ParamPos []Pos
+	// Bounds are the lower and upper bounds of a range (BET) operation. The
+	// zero value is inclusive at both ends, as with field:><(1, 5). Ranges
+	// written field:{1 TO 5] may exclude either end, or leave it unbounded
+	// with *, in which case RVals only holds the value of the other end.
+	Bounds [2]Bound
+	Field  Path
+	// Func, if set, is a function applied to the field before the operation,
+	// as in len(field):>3. The field is its first argument, ahead of
+	// Func.Args().
+	Func     *FuncVal
 	RVals    []Val
 	Position Pos
 }
 
+// Subject returns the query representation of what the operation is applied
+// to, which is the field, or the call of Func on it.
+func (e *ExprNode) Subject() string {
+	if e.Func == nil {
+		return FieldString(e.Field)
+	}
+	var sb strings.Builder
+	sb.WriteString(e.Func.Name())
+	sb.WriteString(`(`)
+	sb.WriteString(FieldString(e.Field))
+	for _, a := range e.Func.Args() {
+		sb.WriteString(`, `)
+		sb.WriteString(a.String())
+	}
+	sb.WriteString(`)`)
+	return sb.String()
+}
+
+// Bound is the kind of bound at one end of a range.
+type Bound int
+
+const (
+	// BoundInclusive includes the value at the end of the range: [1 TO 5]
+	BoundInclusive Bound = iota
+	// BoundExclusive excludes the value at the end of the range: {1 TO 5}
+	BoundExclusive
+	// BoundUnbounded leaves the end of the range unlimited: [1 TO *]
+	BoundUnbounded
+)
+
+// RangeValues returns the values at the lower and upper ends of a range (BET)
+// operation. An unbounded end has no value, and is nil.
+func (e *ExprNode) RangeValues() (lo, hi Val) {
+	vals := e.RVals
+	if e.Bounds[0] != BoundUnbounded && len(vals) > 0 {
+		lo, vals = vals[0], vals[1:]
+	}
+	if e.Bounds[1] != BoundUnbounded && len(vals) > 0 {
+		hi = vals[0]
+	}
+	return lo, hi
+}
+
+// rangeString returns a range with bounds other than the default, like
+// {1 TO *], with the values separated by sep.
+func (e *ExprNode) rangeString(sep string) string {
+	var sb strings.Builder
+	lo, hi := e.RangeValues()
+	if e.Bounds[0] == BoundExclusive {
+		sb.WriteString(`{`)
+	} else {
+		sb.WriteString(`[`)
+	}
+	if lo != nil {
+		sb.WriteString(lo.String())
+	} else {
+		sb.WriteString(`*`)
+	}
+	sb.WriteString(sep)
+	if hi != nil {
+		sb.WriteString(hi.String())
+	} else {
+		sb.WriteString(`*`)
+	}
+	if e.Bounds[1] == BoundExclusive {
+		sb.WriteString(`}`)
+	} else {
+		sb.WriteString(`]`)
+	}
+	return sb.String()
+}
+
 func (e *ExprNode) IsNode() {}
 func (e *ExprNode) String() string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("(%s %s", e.Op, FieldString(e.Field)))
-	switch len(e.RVals) {
-	case 0:
-	case 1:
+	sb.WriteString(fmt.Sprintf("(%s%s %s", e.Op, e.paramString(`, `), e.Subject()))
+	switch {
+	case e.Op == BET && e.Bounds != [2]Bound{}:
+		sb.WriteString(` `)
+		sb.WriteString(e.rangeString(`, `))
+	case len(e.RVals) == 0:
+	case len(e.RVals) == 1:
 		sb.WriteString(` `)
 		sb.WriteString(e.RVals[0].String())
 	default:
@@ -106,12 +221,26 @@ func (e *ExprNode) Pos() Pos {
 	return e.Position
 }
 
+// paramString returns the parameters of the operation in brackets, if there
+// are any.
+func (e *ExprNode) paramString(sep string) string {
+	if len(e.Params) == 0 {
+		return ""
+	}
+	return `[` + strings.Join(e.Params, sep) + `]`
+}
+
 func (e *ExprNode) FriendlyString() string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf(`%s:`, FieldString(e.Field)))
+	sb.WriteString(fmt.Sprintf(`%s:`, e.Subject()))
+	if e.Op == BET && e.Bounds != [2]Bound{} {
+		sb.WriteString(e.rangeString(` TO `))
+		return sb.String()
+	}
 	if e.Op != `==` {
 		sb.WriteString(string(e.Op))
 	}
+	sb.WriteString(e.paramString(`,`))
 	sb.WriteString(` `)
 
 	switch len(e.RVals) {
@@ -183,6 +312,10 @@ const (
 	TypeRegex  ValType = "regex"
 	TypeNet    ValType = "netaddr"
 	TypeTime   ValType = "timestamp"
+	TypeFunc   ValType = "function"
+	// TypeField is the type of a function argument that must be a field, as
+	// in len(field):>3. Only the first argument can be one.
+	TypeField ValType = "field"
 )
 
 type Val interface {
@@ -261,10 +394,56 @@ func (f *FloatVal) Pos() Pos {
 	return f.pos
 }
 
+// Collation strengths for a similarity match, given as parameters of the
+// operation. Each ignores a different set of differences between strings.
+const (
+	// StrengthLoose ignores case, diacritics and width. It is the default.
+	StrengthLoose = `loose`
+	// StrengthIgnoreDiacritics ignores diacritics, but not case: "ecole"
+	// matches "école", but not "École".
+	StrengthIgnoreDiacritics = `ignore-diacritics`
+	// StrengthIgnoreCase ignores case and width, but not diacritics: "ÉCOLE"
+	// matches "école", but not "ecole".
+	StrengthIgnoreCase = `ignore-case`
+)
+
+// DefaultMaxEdits is the maximum edit distance of a fuzzy term written without
+// one: "value"~
+const DefaultMaxEdits = 2
+
+// Case is a modifier on a string value which overrides the case sensitivity
+// of the match. It is written as a suffix: "value"c or "value"i.
+type Case int
+
+const (
+	// CaseDefault leaves case sensitivity up to the matcher.
+	CaseDefault Case = iota
+	// CaseSensitive matches the string as written.
+	CaseSensitive
+	// CaseInsensitive ignores case when matching the string.
+	CaseInsensitive
+)
+
+// Suffix returns the modifier as it is written in a query.
+func (c Case) Suffix() string {
+	switch c {
+	case CaseSensitive:
+		return "c"
+	case CaseInsensitive:
+		return "i"
+	}
+	return ""
+}
+
 type StringVal struct {
-	sv  string
-	qsv string
-	pos Pos
+	sv      string
+	qsv     string
+	caseMod Case
+	// fuzzy is set when the value is a fuzzy term, "value"~N, which matches
+	// words within maxEdits edits of it.
+	fuzzy    bool
+	maxEdits int
+	pos      Pos
 }
 
 func NewStringVal(b []byte, pos Pos) (*StringVal, error) {
@@ -277,13 +456,40 @@ func NewStringVal(b []byte, pos Pos) (*StringVal, error) {
 }
 
 func (s *StringVal) String() string {
-	return s.qsv
+	str := s.qsv + s.caseMod.Suffix()
+	if s.fuzzy {
+		str += "~" + strconv.Itoa(s.maxEdits)
+	}
+	return str
 }
 
 func (s *StringVal) Value() string {
 	return s.sv
 }
 
+// Case returns the case modifier of the value, if any.
+func (s *StringVal) Case() Case {
+	return s.caseMod
+}
+
+// SetCase sets the case modifier of the value.
+func (s *StringVal) SetCase(c Case) {
+	s.caseMod = c
+}
+
+// Fuzziness returns the maximum edit distance of a fuzzy term, and whether the
+// value is one.
+func (s *StringVal) Fuzziness() (maxEdits int, ok bool) {
+	return s.maxEdits, s.fuzzy
+}
+
+// SetFuzziness makes the value a fuzzy term with the given maximum edit
+// distance.
+func (s *StringVal) SetFuzziness(maxEdits int) {
+	s.fuzzy = true
+	s.maxEdits = maxEdits
+}
+
 func (s *StringVal) Type() ValType {
 	return TypeString
 }
@@ -366,10 +572,17 @@ func (r *RegexpVal) Pos() Pos {
 	return r.pos
 }
 
+// NetVal is an IPv4 or IPv6 network block, or a single address, which is
+// represented as a block of its full length. It may also have a port or range
+// of ports, as in 10.0.0.0/8:3389, 10.0.0.1:8000-8080 or [2001:db8::1]:443,
+// to match sockets.
 type NetVal struct {
-	sv  string
-	nv  netip.Prefix
-	pos Pos
+	sv    string
+	nv    netip.Prefix
+	ports [2]uint16
+	// hasPorts is set if the value has a port or range of ports
+	hasPorts bool
+	pos      Pos
 }
 
 var (
@@ -378,9 +591,26 @@ var (
 )
 
 func NewNetVal(b []byte, pos Pos) (*NetVal, error) {
-	sv := string(b)
+	sv, port := string(b), ""
+	switch {
+	case strings.HasPrefix(sv, `[`):
+		// an IPv6 socket, [addr]:port
+		var found bool
+		sv, port, found = strings.Cut(sv[1:], `]:`)
+		if !found {
+			return nil, fmt.Errorf("invalid network value [%s]: expected a port after the IPv6 address", b)
+		}
+	case strings.Count(sv, `:`) == 1:
+		// an IPv4 socket, addr:port
+		sv, port, _ = strings.Cut(sv, `:`)
+	}
 	if !strings.Contains(sv, `/`) {
-		sv += "/32"
+		// a single address
+		if strings.Contains(sv, `:`) {
+			sv += "/128"
+		} else {
+			sv += "/32"
+		}
 	}
 	nv, err := netip.ParsePrefix(sv)
 	if err != nil {
@@ -389,15 +619,51 @@ func NewNetVal(b []byte, pos Pos) (*NetVal, error) {
 		errstr = removeParseAddr.ReplaceAllLiteralString(errstr, "")
 		return nil, fmt.Errorf("invalid network value [%s]: %s", sv, errstr)
 	}
-	return &NetVal{
+	n := &NetVal{
 		sv:  sv,
 		nv:  nv,
 		pos: pos,
-	}, nil
+	}
+	if port != "" {
+		if err := n.setPorts(port); err != nil {
+			return nil, fmt.Errorf("invalid network value [%s]: %w", b, err)
+		}
+	}
+	return n, nil
+}
+
+// setPorts parses a port, or a range of ports written lo-hi.
+func (n *NetVal) setPorts(port string) error {
+	lo, hi, isRange := strings.Cut(port, `-`)
+	if !isRange {
+		hi = lo
+	}
+	for i, p := range []string{lo, hi} {
+		v, err := strconv.ParseUint(p, 10, 16)
+		if err != nil {
+			return fmt.Errorf("port %s out of range", p)
+		}
+		n.ports[i] = uint16(v)
+	}
+	if n.ports[0] > n.ports[1] {
+		return fmt.Errorf("port range %s is reversed", port)
+	}
+	n.hasPorts = true
+	return nil
 }
 
 func (n *NetVal) String() string {
-	return n.sv
+	if !n.hasPorts {
+		return n.sv
+	}
+	host := n.sv
+	if n.nv.Addr().Is6() {
+		host = `[` + host + `]`
+	}
+	if n.ports[0] == n.ports[1] {
+		return fmt.Sprintf("%s:%d", host, n.ports[0])
+	}
+	return fmt.Sprintf("%s:%d-%d", host, n.ports[0], n.ports[1])
 }
 
 func (n *NetVal) Type() ValType {
@@ -408,26 +674,61 @@ func (n *NetVal) Value() netip.Prefix {
 	return n.nv
 }
 
+// Ports returns the lowest and highest port a socket may have to match the
+// value, and whether the value has ports at all.
+func (n *NetVal) Ports() (lo, hi uint16, ok bool) {
+	return n.ports[0], n.ports[1], n.hasPorts
+}
+
 func (n *NetVal) Pos() Pos {
 	return n.pos
 }
 
+// TimeVal is a datetime value, which is either an absolute RFC3339 datetime
+// or full date, or relative to the current time, such as now-15m or now/d. A
+// full date may name the time zone it is in, as in 2024-03-10@Europe/Paris.
 type TimeVal struct {
 	sv      string
 	tv      time.Time
 	dayOnly bool
-	pos     Pos
+	// loc is the time zone given for a full date, as in 2024-03-10@Europe/Paris
+	loc *time.Location
+	// steps are the date math applied to the current time for a relative value
+	relative bool
+	steps    []timeStep
+	pos      Pos
 }
 
+// timeStep is one step of date math in a relative datetime value: adding or
+// subtracting a number of units, or rounding down to the start of a unit.
+type timeStep struct {
+	op   byte
+	n    int
+	unit byte
+}
+
+// TimeUnits are the units of date math in relative datetime values, as in
+// Elasticsearch: years, months, weeks, days, hours, minutes and seconds. H is
+// a synonym for h.
+const TimeUnits = "yMwdhHms"
+
 func NewTimeVal(b []byte, pos Pos) (*TimeVal, error) {
 	sv := string(b)
 	var (
 		tv      time.Time
 		dayOnly bool
+		loc     *time.Location
 		err     error
 	)
-	if len(sv) == 10 {
-		tv, err = time.Parse(`2006-01-02`, sv)
+	date, zone, zoned := strings.Cut(sv, `@`)
+	if zoned {
+		loc, err = time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid datetime value [%s]: unknown time zone %s", sv, zone)
+		}
+	}
+	if len(date) == 10 {
+		tv, err = time.ParseInLocation(`2006-01-02`, date, orUTC(loc))
 		dayOnly = true
 	} else {
 		tv, err = time.Parse(time.RFC3339, sv)
@@ -447,18 +748,160 @@ func NewTimeVal(b []byte, pos Pos) (*TimeVal, error) {
 		sv:      sv,
 		tv:      tv,
 		dayOnly: dayOnly,
+		loc:     loc,
 		pos:     pos,
 	}, nil
 }
 
+func orUTC(loc *time.Location) *time.Location {
+	if loc == nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// NewRelativeTimeVal creates a datetime value relative to the current time,
+// which is "now" followed by any number of steps of date math: +N or -N units
+// to add or subtract them, or /unit to round down to the start of the unit.
+// For example, now-1d/d is the start of yesterday.
+func NewRelativeTimeVal(b []byte, pos Pos) (*TimeVal, error) {
+	sv := string(b)
+	expr, ok := strings.CutPrefix(sv, "now")
+	if !ok {
+		return nil, fmt.Errorf("invalid relative datetime value [%s]: must begin with \"now\"", sv)
+	}
+	var steps []timeStep
+	for len(expr) > 0 {
+		step := timeStep{op: expr[0]}
+		expr = expr[1:]
+		switch step.op {
+		case '+', '-':
+			i := 0
+			for i < len(expr) && expr[i] >= '0' && expr[i] <= '9' {
+				i++
+			}
+			n, err := strconv.Atoi(expr[:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid relative datetime value [%s]: expected a number after [%c]", sv, step.op)
+			}
+			step.n = n
+			expr = expr[i:]
+		case '/':
+		default:
+			return nil, fmt.Errorf("invalid relative datetime value [%s]: expected +, - or /", sv)
+		}
+		if len(expr) == 0 || !strings.ContainsRune(TimeUnits, rune(expr[0])) {
+			return nil, fmt.Errorf("invalid relative datetime value [%s]: expected a unit (y, M, w, d, h, m or s)", sv)
+		}
+		step.unit = expr[0]
+		expr = expr[1:]
+		steps = append(steps, step)
+	}
+	return &TimeVal{
+		sv:       sv,
+		relative: true,
+		steps:    steps,
+		pos:      pos,
+	}, nil
+}
+
 func (t *TimeVal) String() string {
 	return t.sv
 }
 
+// Value returns the moment an absolute value represents. It returns the zero
+// time for a relative value, which must be resolved with [TimeVal.Resolve].
 func (t *TimeVal) Value() time.Time {
 	return t.tv
 }
 
+// Relative reports whether the value is relative to the current time.
+func (t *TimeVal) Relative() bool {
+	return t.relative
+}
+
+// Rounded reports whether a relative value is rounded to a unit in its final
+// step, such as now/d, in which case it stands for the whole unit, like a full
+// date stands for the whole day.
+func (t *TimeVal) Rounded() bool {
+	return len(t.steps) > 0 && t.steps[len(t.steps)-1].op == '/'
+}
+
+// Resolve returns the moment a relative value represents at the time now,
+// rounding in the location of now. It returns Value() for an absolute value.
+func (t *TimeVal) Resolve(now time.Time) time.Time {
+	if !t.relative {
+		return t.tv
+	}
+	for _, s := range t.steps {
+		switch s.op {
+		case '+':
+			now = addTimeUnits(now, s.unit, s.n)
+		case '-':
+			now = addTimeUnits(now, s.unit, -s.n)
+		case '/':
+			now = truncateTime(now, s.unit)
+		}
+	}
+	return now
+}
+
+// Span returns the period a relative value represents at the time now, from
+// start up to, but not including, end. Unless the value is rounded, the span
+// is a single moment, and end is equal to start.
+func (t *TimeVal) Span(now time.Time) (start, end time.Time) {
+	start = t.Resolve(now)
+	if !t.Rounded() {
+		return start, start
+	}
+	return start, addTimeUnits(start, t.steps[len(t.steps)-1].unit, 1)
+}
+
+func addTimeUnits(t time.Time, unit byte, n int) time.Time {
+	switch unit {
+	case 'y':
+		return t.AddDate(n, 0, 0)
+	case 'M':
+		return t.AddDate(0, n, 0)
+	case 'w':
+		return t.AddDate(0, 0, 7*n)
+	case 'd':
+		return t.AddDate(0, 0, n)
+	case 'h', 'H':
+		return t.Add(time.Duration(n) * time.Hour)
+	case 'm':
+		return t.Add(time.Duration(n) * time.Minute)
+	case 's':
+		return t.Add(time.Duration(n) * time.Second)
+	}
+	panic(fmt.Sprintf("invalid time unit: %c", unit))
+}
+
+// truncateTime rounds t down to the start of a unit in its location. Weeks
+// start on Monday.
+func truncateTime(t time.Time, unit byte) time.Time {
+	y, mo, d := t.Date()
+	h, mi, s := t.Clock()
+	loc := t.Location()
+	switch unit {
+	case 'y':
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, loc)
+	case 'M':
+		return time.Date(y, mo, 1, 0, 0, 0, 0, loc)
+	case 'w':
+		return time.Date(y, mo, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	case 'd':
+		return time.Date(y, mo, d, 0, 0, 0, 0, loc)
+	case 'h', 'H':
+		return time.Date(y, mo, d, h, 0, 0, 0, loc)
+	case 'm':
+		return time.Date(y, mo, d, h, mi, 0, 0, loc)
+	case 's':
+		return time.Date(y, mo, d, h, mi, s, 0, loc)
+	}
+	panic(fmt.Sprintf("invalid time unit: %c", unit))
+}
+
 func (t *TimeVal) Type() ValType {
 	return TypeTime
 }
@@ -471,17 +914,57 @@ func (t *TimeVal) DayOnly() bool {
 	return t.dayOnly
 }
 
-func FieldString(pathparts []string) string {
+// Location returns the time zone given for a full date with an @zone suffix,
+// such as 2024-03-10@America/New_York, or nil if the value has none, in which
+// case the backend chooses the zone.
+func (t *TimeVal) Location() *time.Location {
+	return t.loc
+}
+
+// FuncVal is a call to a function, name(arg, ...), in place of a value. Which
+// functions exist, and the type of value each produces, is up to the backend.
+type FuncVal struct {
+	name string
+	args []Val
+	pos  Pos
+}
+
+func NewFuncVal(name string, args []Val, pos Pos) (*FuncVal, error) {
+	return &FuncVal{
+		name: name,
+		args: args,
+		pos:  pos,
+	}, nil
+}
+
+func (f *FuncVal) String() string {
 	var sb strings.Builder
-	for i, p := range pathparts {
-		if strings.ContainsAny(p, ` ."`) {
-			sb.WriteString(strconv.Quote(p))
-		} else {
-			sb.WriteString(p)
-		}
-		if i < len(pathparts)-1 {
-			sb.WriteString(`.`)
+	sb.WriteString(f.name)
+	sb.WriteString(`(`)
+	for i, a := range f.args {
+		if i > 0 {
+			sb.WriteString(`, `)
 		}
+		sb.WriteString(a.String())
 	}
+	sb.WriteString(`)`)
 	return sb.String()
 }
+
+// Name returns the name of the function.
+func (f *FuncVal) Name() string {
+	return f.name
+}
+
+// Args returns the arguments of the call, which may themselves be calls.
+func (f *FuncVal) Args() []Val {
+	return f.args
+}
+
+func (f *FuncVal) Type() ValType {
+	return TypeFunc
+}
+
+func (f *FuncVal) Pos() Pos {
+	return f.pos
+}
@@ -0,0 +1,169 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SegmentType is the type of a single field path segment.
+type SegmentType int
+
+const (
+	// SegmentKey selects a key in an object. When applied to an array, the key
+	// is selected in every object in that array.
+	SegmentKey SegmentType = iota
+	// SegmentIndex selects a single element of an array. Negative indexes count
+	// back from the end of the array, so [-1] is the last element.
+	SegmentIndex
+	// SegmentSlice selects a half-open range of array elements [Start:End).
+	// Bounds may be negative, and may be omitted.
+	SegmentSlice
+	// SegmentAll explicitly selects every element of an array.
+	SegmentAll
+)
+
+// PathSegment is a single segment of a field path, either a key or an array
+// selector.
+type PathSegment struct {
+	Type SegmentType `json:"type"`
+	// Key is the object key for SegmentKey
+	Key string `json:"key,omitempty"`
+	// Index is the element index for SegmentIndex
+	Index int `json:"index,omitempty"`
+	// Start and End are the bounds for SegmentSlice, when HasStart and HasEnd
+	// are set, respectively
+	Start    int  `json:"start,omitempty"`
+	End      int  `json:"end,omitempty"`
+	HasStart bool `json:"has_start,omitempty"`
+	HasEnd   bool `json:"has_end,omitempty"`
+}
+
+// KeySegment returns a path segment selecting an object key.
+func KeySegment(key string) PathSegment {
+	return PathSegment{
+		Type: SegmentKey,
+		Key:  key,
+	}
+}
+
+// IsSelector returns whether the segment selects array elements, rather than
+// an object key.
+func (p PathSegment) IsSelector() bool {
+	return p.Type != SegmentKey
+}
+
+// Bounds resolves an array selector against an array of the given length,
+// returning the half-open range of element indexes it selects. If nothing is
+// selected, start will equal end.
+func (p PathSegment) Bounds(length int) (start, end int) {
+	resolve := func(i int) int {
+		if i < 0 {
+			i += length
+		}
+		switch {
+		case i < 0:
+			return 0
+		case i > length:
+			return length
+		}
+		return i
+	}
+	switch p.Type {
+	case SegmentAll:
+		return 0, length
+	case SegmentIndex:
+		i := p.Index
+		if i < 0 {
+			i += length
+		}
+		if i < 0 || i >= length {
+			return 0, 0
+		}
+		return i, i + 1
+	case SegmentSlice:
+		start, end = 0, length
+		if p.HasStart {
+			start = resolve(p.Start)
+		}
+		if p.HasEnd {
+			end = resolve(p.End)
+		}
+		if end < start {
+			end = start
+		}
+		return start, end
+	}
+	return 0, 0
+}
+
+func (p PathSegment) String() string {
+	switch p.Type {
+	case SegmentKey:
+		if needsQuote(p.Key) {
+			return strconv.Quote(p.Key)
+		}
+		return p.Key
+	case SegmentIndex:
+		return `[` + strconv.Itoa(p.Index) + `]`
+	case SegmentSlice:
+		var sb strings.Builder
+		sb.WriteString(`[`)
+		if p.HasStart {
+			sb.WriteString(strconv.Itoa(p.Start))
+		}
+		sb.WriteString(`:`)
+		if p.HasEnd {
+			sb.WriteString(strconv.Itoa(p.End))
+		}
+		sb.WriteString(`]`)
+		return sb.String()
+	case SegmentAll:
+		return `[*]`
+	}
+	return ``
+}
+
+// Path is a field path, made up of keys and array selectors.
+type Path []PathSegment
+
+// NewPath creates a path made up of only keys.
+func NewPath(keys ...string) Path {
+	p := make(Path, 0, len(keys))
+	for _, k := range keys {
+		p = append(p, KeySegment(k))
+	}
+	return p
+}
+
+func (p Path) String() string {
+	return FieldString(p)
+}
+
+// FieldString returns the query representation of a field path, which will
+// parse back into an identical path.
+func FieldString(path Path) string {
+	var sb strings.Builder
+	for i, seg := range path {
+		if i > 0 && !seg.IsSelector() {
+			sb.WriteString(`.`)
+		}
+		sb.WriteString(seg.String())
+	}
+	return sb.String()
+}
+
+// needsQuote reports whether a key must be quoted to be parsed as a single
+// field piece.
+func needsQuote(key string) bool {
+	if key == "" || key == "*" {
+		return true
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return true
+		}
+	}
+	return false
+}
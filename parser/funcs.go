@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/flowchartsman/aql/parser/ast"
+)
+
+// FuncSignature describes a function that queries can call, so that calls can
+// be validated. A function is called in place of a value, name(arg, ...), or
+// applied to a field before the operation, name(field, arg, ...):>3, if its
+// first argument is [ast.TypeField].
+type FuncSignature struct {
+	// Args are the types of the arguments. An argument may also be a call to
+	// another function that returns the right type.
+	Args []ast.ValType
+	// Optional is the number of trailing arguments which can be left out.
+	Optional int
+	// Variadic allows the last argument to be repeated any number of times.
+	Variadic bool
+	// Returns is the type of value the function produces, which is checked
+	// against the operation it is used with, like any other value.
+	Returns ast.ValType
+}
+
+// Funcs declares the functions that queries can call. Calls to any other
+// function are an error. By default, there are none, since what a function
+// does is up to the backend evaluating the query. An invalid signature is
+// reported as an error when the query is parsed.
+func Funcs(funcs map[string]FuncSignature) Option {
+	return func(p *ParserOpts) {
+		if p.funcs == nil {
+			p.funcs = map[string]FuncSignature{}
+		}
+		for name, sig := range funcs {
+			p.funcs[name] = sig
+		}
+	}
+}
+
+// checkSignature returns an error if a signature cannot be satisfied, or does
+// not say what type each argument must be. Only the first argument can be a
+// field, and then only once.
+func checkSignature(sig FuncSignature) error {
+	switch {
+	case sig.Variadic && len(sig.Args) == 0:
+		return errors.New("variadic function declared without arguments")
+	case sig.Optional < 0:
+		return errors.New("negative number of optional arguments")
+	case sig.Optional > len(sig.Args):
+		return fmt.Errorf("more optional arguments (%d) than arguments (%d)", sig.Optional, len(sig.Args))
+	case sig.Variadic && len(sig.Args) == 1 && sig.Args[0] == ast.TypeField:
+		return errors.New("only the first argument can be a field")
+	}
+	for i, arg := range sig.Args {
+		if i > 0 && arg == ast.TypeField {
+			return errors.New("only the first argument can be a field")
+		}
+	}
+	return nil
+}
+
+// checkSignatures checks the declared functions in name order, so that the
+// same error is always reported first.
+func checkSignatures(funcs map[string]FuncSignature) *ParseError {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := checkSignature(funcs[name]); err != nil {
+			return genericParseError(fmt.Sprintf("invalid signature for function [%s]: %v", name, err))
+		}
+	}
+	return nil
+}
@@ -18,14 +18,14 @@ func ParseQuery(query string, options ...Option) (ast.Node, error) {
 }
 
 func ParseQueryReader(r io.Reader, options ...Option) (ast.Node, error) {
-	opts := &ParserOpts{
-		visitors: []Visitor{
-			VisitorFunc(opValidator),
-		},
-	}
+	opts := &ParserOpts{}
 	for _, o := range options {
 		o(opts)
 	}
+	if err := checkSignatures(opts.funcs); err != nil {
+		return nil, err
+	}
+	opts.visitors = append([]Visitor{&opValidator{funcs: opts.funcs}}, opts.visitors...)
 	v, err := grammar.ParseReader("", r, grammar.Debug(opts.debug))
 	if err != nil {
 		return nil, grammar.GetParseError(err)
@@ -108,6 +108,7 @@ func ErrorAt(pos ast.Pos, message string) *ParseError {
 type ParserOpts struct {
 	debug    bool
 	visitors []Visitor
+	funcs    map[string]FuncSignature
 }
 
 type Option func(*ParserOpts)
@@ -2,8 +2,11 @@ package parser
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/flowchartsman/aql/parser/ast"
 )
 
 func TestParseQuery(t *testing.T) {
@@ -113,6 +116,46 @@ func TestParseQuery(t *testing.T) {
 		"net value",
 		`internal:192.168.1.0/24`,
 		`(== internal 192.168.1.0/24)`)
+	testParse(t,
+		"simple ip address",
+		`internal:192.168.1.1`,
+		`(== internal 192.168.1.1/32)`)
+	testParse(t,
+		"simple ipv6 cidr",
+		`internal:2001:db8::/32`,
+		`(== internal 2001:db8::/32)`)
+	testParse(t,
+		"ipv6 address",
+		`internal:fe80::1`,
+		`(== internal fe80::1/128)`)
+	testParse(t,
+		"ipv6 loopback",
+		`internal:::1`,
+		`(== internal ::1/128)`)
+	testParse(t,
+		"ipv4-mapped ipv6 address",
+		`internal:::ffff:10.0.0.1`,
+		`(== internal ::ffff:10.0.0.1/128)`)
+	testParse(t,
+		"ip address and port",
+		`internal:10.0.0.1:443`,
+		`(== internal 10.0.0.1/32:443)`)
+	testParse(t,
+		"cidr and port range",
+		`internal:10.0.0.0/8:8000-8080`,
+		`(== internal 10.0.0.0/8:8000-8080)`)
+	testParse(t,
+		"ipv6 address and port",
+		`internal:[2001:db8::1]:443`,
+		`(== internal [2001:db8::1/128]:443)`)
+	testParse(t,
+		"ipv6 cidr and port",
+		`internal:[2001:db8::/32]:3389`,
+		`(== internal [2001:db8::/32]:3389)`)
+	testParse(t,
+		"ipv6 addresses in a set",
+		`internal:(2001:db8::/32, ::1, 10.0.0.0/8)`,
+		`(== internal [2001:db8::/32, ::1/128, 10.0.0.0/8])`)
 	testParse(t,
 		"fullDate value",
 		`Andy:1979-10-03`,
@@ -121,6 +164,58 @@ func TestParseQuery(t *testing.T) {
 		"dateTime value",
 		`AndyPrecise:2021-06-08T20:56:33+00:00`,
 		`(== AndyPrecise 2021-06-08T20:56:33+00:00)`)
+	testParse(t,
+		"short date with time zone",
+		`Andy:1979-10-03@America/New_York`,
+		`(== Andy 1979-10-03@America/New_York)`)
+	testParse(t,
+		"short dates with time zones in range",
+		`ts:><(2024-03-10@Etc/GMT+5, 2024-03-11@UTC)`,
+		`(>< ts [2024-03-10@Etc/GMT+5, 2024-03-11@UTC])`)
+	testParse(t,
+		"inclusive range",
+		`n:[1 TO 5]`,
+		`(>< n [1, 5])`)
+	testParse(t,
+		"exclusive range",
+		`n:{1 TO 5.5}`,
+		`(>< n {1, 5.5})`)
+	testParse(t,
+		"mixed range",
+		`n:{ 1 TO 5 ]`,
+		`(>< n {1, 5])`)
+	testParse(t,
+		"range unbounded above",
+		`n:[1 TO *}`,
+		`(>< n [1, *])`)
+	testParse(t,
+		"range unbounded below",
+		`n:{* TO 5}`,
+		`(>< n [*, 5})`)
+	testParse(t,
+		"datetime range",
+		`ts:[now-1d/d TO now/d}`,
+		`(>< ts [now-1d/d, now/d})`)
+	testParse(t,
+		"range with socket",
+		`ip:[::1]:80 AND n:[1 TO 2}`,
+		`(&& (== ip [::1/128]:80) (>< n [1, 2}))`)
+	testParse(t,
+		"relative datetime",
+		`ts:>now-1h`,
+		`(> ts now-1h)`)
+	testParse(t,
+		"rounded relative datetime",
+		`ts:now-1d/d`,
+		`(== ts now-1d/d)`)
+	testParse(t,
+		"relative datetime range",
+		`ts:><(now/d, now)`,
+		`(>< ts [now/d, now])`)
+	testParse(t,
+		"relative datetime with several steps",
+		`ts:<now+1M-2w/w`,
+		`(< ts now+1M-2w/w)`)
 	testParse(t,
 		"regexp value",
 		`domains:/.*\\.[a-z0-9]*\\.local/`,
@@ -148,6 +243,26 @@ func TestParseQuery(t *testing.T) {
 		"operator <=",
 		`pair:<=2`,
 		`(<= pair 2)`)
+	testParse(t,
+		"operator exact",
+		`name:="Andy"`,
+		`(= name "Andy")`)
+	testParse(t,
+		"operator exact case-insensitive",
+		`name:~=("andy", "bob")`,
+		`(~= name ["andy", "bob"])`)
+	testParse(t,
+		"case modifiers",
+		`hash:("dGVz"c, "ABC"i, "def")`,
+		`(== hash ["dGVz"c, "ABC"i, "def"])`)
+	testParse(t,
+		"similarity parameters",
+		`name:~[fr, ignore-case]"café"`,
+		`(~[fr, ignore-case] name "café")`)
+	testParse(t,
+		"fuzzy terms",
+		`name:~("colour"~1, "gray"~)`,
+		`(~ name ["colour"~1, "gray"~2])`)
 	testParse(t,
 		"operator exists",
 		`pair:exists`,
@@ -180,10 +295,90 @@ func TestParseQuery(t *testing.T) {
 		"mix of regular and  no-arg ops",
 		`a:<1 AND b:exists AND c:<=2 AND d:null AND e:"hello"`,
 		`(&& (< a 1) (&& (exists b) (&& (<= c 2) (&& (null d) (== e "hello")))))`)
-	// testParse(t,
-	// 	"subdoc node",
-	// 	`foo."ba r"{a:<1 AND b:"hello"}`,
-	// 	`(foo."ba r"{(&& (< a 1) (== b "hello"))})`)
+	testParse(t,
+		"array index",
+		`tags[0]:"siegfried"`,
+		`(== tags[0] "siegfried")`)
+	testParse(t,
+		"negative array index",
+		`events[-1].type:"login"`,
+		`(== events[-1].type "login")`)
+	testParse(t,
+		"array slice",
+		`events[0:3]:exists`,
+		`(exists events[0:3])`)
+	testParse(t,
+		"open array slices",
+		`a[:3]:1 AND b[-2:]:1 AND c[ : ]:1`,
+		`(&& (== a[:3] 1) (&& (== b[-2:] 1) (== c[:] 1)))`)
+	testParse(t,
+		"array wildcard and index",
+		`foo.bar[*].baz[1]:1`,
+		`(== foo.bar[*].baz[1] 1)`)
+	testParse(t,
+		"nested array index",
+		`matrix[0][1]:1`,
+		`(== matrix[0][1] 1)`)
+	testParse(t,
+		"quoted field with array index",
+		`"na.me"[0]:1`,
+		`(== "na.me"[0] 1)`)
+	testParse(t,
+		"legacy star removed",
+		`foo.*.bar:1`,
+		`(== foo.bar 1)`)
+	testParse(t,
+		"subdoc node",
+		`foo."ba r"{a:<1 AND b:"hello"}`,
+		`(foo."ba r"{(&& (< a 1) (== b "hello"))})`)
+	testParse(t,
+		"nested subdoc node",
+		`foo{ a:1 AND bar { b:exists } }`,
+		`(foo{(&& (== a 1) (bar{(exists b)}))})`)
+	testParse(t,
+		"negated subdoc node",
+		`!foo{a:1 OR b:2}`,
+		`(! (foo{(|| (== a 1) (== b 2))}))`)
+}
+
+func TestFieldStringRoundTrip(t *testing.T) {
+	for _, field := range []string{
+		`name`,
+		`name.givenname`,
+		`"na.me"."Given\"Name"`,
+		`"with space"`,
+		`"[0]"`,
+		`tags[0]`,
+		`events[-1].type`,
+		`events[0:3]`,
+		`events[:-1]`,
+		`events[2:]`,
+		`events[:]`,
+		`foo.bar[*].baz[1]`,
+		`matrix[0][-1]`,
+	} {
+		t.Run(field, func(t *testing.T) {
+			n, err := ParseQuery(field + `:exists`)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expr, ok := n.(*ast.ExprNode)
+			if !ok {
+				t.Fatalf("expected expression node, got %T", n)
+			}
+			fs := ast.FieldString(expr.Field)
+			if fs != field {
+				t.Fatalf("\nexpected:\n%s\ngot:\n%s", field, fs)
+			}
+			n2, err := ParseQuery(fs + `:exists`)
+			if err != nil {
+				t.Fatalf("unexpected error re-parsing: %v", err)
+			}
+			if !reflect.DeepEqual(n2.(*ast.ExprNode).Field, expr.Field) {
+				t.Fatalf("round-trip mismatch:\n%#v\n%#v", expr.Field, n2.(*ast.ExprNode).Field)
+			}
+		})
+	}
 }
 
 func testParse(t *testing.T, testName string, query string, want string) {
@@ -234,6 +429,30 @@ func TestValueErrors(t *testing.T) {
 		`invalid net block fails`,
 		`net:192.168.0.0/99`,
 		`1:5(4): invalid network value [192.168.0.0/99]: prefix length out of range`)
+	testParseErr(t,
+		`invalid ipv6 addr fails`,
+		`net:2001:db8:::1`,
+		`1:5(4): invalid network value [2001:db8:::1/128]: each colon-separated field must have at least one digit (at ":1")`)
+	testParseErr(t,
+		`invalid ipv6 block fails`,
+		`net:2001:db8::/129`,
+		`1:5(4): invalid network value [2001:db8::/129]: prefix length out of range`)
+	testParseErr(t,
+		`valid ipv6 block`,
+		`net:2001:db8::/32`,
+		``)
+	testParseErr(t,
+		`out of range port fails`,
+		`net:10.0.0.1:65536`,
+		`1:5(4): invalid network value [10.0.0.1:65536]: port 65536 out of range`)
+	testParseErr(t,
+		`reversed port range fails`,
+		`net:10.0.0.0/8:443-80`,
+		`1:5(4): invalid network value [10.0.0.0/8:443-80]: port range 443-80 is reversed`)
+	testParseErr(t,
+		`valid port range`,
+		`net:[::1]:80-443`,
+		``)
 	testParseErr(t,
 		`valid net block`,
 		`net:192.168.0.0/24`,
@@ -250,6 +469,26 @@ func TestValueErrors(t *testing.T) {
 		`valid short date`,
 		`Andy:1979-10-03`,
 		``)
+	testParseErr(t,
+		`invalid relative datetime unit fails`,
+		`ts:now-1x`,
+		`1:4(3): invalid relative datetime value [now-1x]: expected a unit (y, M, w, d, h, m or s)`)
+	testParseErr(t,
+		`valid relative datetime`,
+		`ts:now-15m/m`,
+		``)
+	testParseErr(t,
+		`word starting with now is not a relative datetime`,
+		`status:nowhere`,
+		`1:8(7): unknown type of value [nowhere] -- did you mean "nowhere"?`)
+	testParseErr(t,
+		`relative datetime followed by letters fails`,
+		`ts:now/dx`,
+		`1:4(3): unknown type of value [now/dx] -- did you mean "now/dx"?`)
+	testParseErr(t,
+		`unknown time zone fails`,
+		`Andy:1979-10-03@Mars/Olympus_Mons`,
+		`1:6(5): invalid datetime value [1979-10-03@Mars/Olympus_Mons]: unknown time zone Mars/Olympus_Mons`)
 	testParseErr(t,
 		`invalid long date time fails`,
 		`AndyPrecise:2021-06-08T20:74:33+00:00`,
@@ -262,6 +501,14 @@ func TestValueErrors(t *testing.T) {
 		`invalid value in list fails`,
 		`name:(/.*/,/*/)`,
 		"1:12(11): invalid regular expression [/*/]: error parsing regexp: missing argument to repetition operator: `*`")
+	testParseErr(t,
+		`out of range array index fails`,
+		`tags[99999999999999999999]:1`,
+		`1:6(5): invalid array index [99999999999999999999]`)
+	testParseErr(t,
+		`non-numeric array index fails`,
+		`tags[x]:1`,
+		`*expected: "*", "-", ":", [ \n\t\r] or [0-9]`)
 	testParseErr(t,
 		`unnecessary paren fails`,
 		`foo:("bar")`,
@@ -293,6 +540,76 @@ func TestOpErrors(t *testing.T) {
 		`between operator requires second value to be greater`,
 		`value:>< (2, 1)`,
 		`1:14(13): [><] operation requires the second argument be greater`)
+	testParseErr(t,
+		`range requires upper bound to be greater`,
+		`value:{5 TO 1]`,
+		`1:13(12): [><] operation requires the second argument be greater`)
+	testParseErr(t,
+		`range requires a bound`,
+		`value:[* TO *]`,
+		`1:7(6): range must have at least one bound`)
+	testParseErr(t,
+		`range requires numeric values`,
+		`value:[1 TO "a"]`,
+		`1:13(12): [><] operation needs numeric arguments`)
+	testParseErr(t,
+		`range requires values of one type`,
+		`value:[2024-01-01 TO 5]`,
+		`1:22(21): second argument must also be a datetime value`)
+	testParseErr(t,
+		`between operator compares dates in their time zones`,
+		`ts:><(2024-03-10@America/New_York, 2024-03-10@UTC)`,
+		`1:36(35): [><] operation requires the second argument be greater`)
+	for _, op := range []string{`=`, `~=`} {
+		query := fmt.Sprintf(`value:%s ("hello", 1)`, op)
+		expectedErr := fmt.Sprintf(`*[%s] operation needs string arguments`, op)
+		testName := fmt.Sprintf(`operation %s requires string value(s)`, op)
+		testParseErr(t,
+			testName,
+			query,
+			expectedErr,
+		)
+	}
+	testParseErr(t,
+		`exact operators do not accept case modifiers`,
+		`name:= ("Andy", "bob"i)`,
+		`1:17(16): [=] operation does not accept case modifiers`)
+	testParseErr(t,
+		`similarity does not accept case modifiers`,
+		`name:~"andy"i`,
+		`1:7(6): [~] operation does not accept case modifiers`)
+	testParseErr(t,
+		`only similarity accepts parameters`,
+		`name:=[fr]"andy"`,
+		`1:8(7): [=] operation does not accept parameters`)
+	testParseErr(t,
+		`similarity parameters must be a strength or language`,
+		`name:~[fr, nope]"andy"`,
+		`1:12(11): [~] operation parameter [nope] is not a strength (loose, ignore-diacritics or ignore-case) or a known language tag`)
+	testParseErr(t,
+		`similarity accepts one strength`,
+		`name:~[loose, ignore-case]"andy"`,
+		`1:15(14): [~] operation accepts one strength, found [loose] and [ignore-case]`)
+	testParseErr(t,
+		`similarity accepts one language`,
+		`name:~[fr, de]"andy"`,
+		`1:12(11): [~] operation accepts one language, found [fr] and [de]`)
+	testParseErr(t,
+		`parameter errors point at the parameter`,
+		`a:1 AND name:~[fr, nope]"andy"`,
+		`1:20(19): [~] operation parameter [nope] is not a strength (loose, ignore-diacritics or ignore-case) or a known language tag`)
+	testParseErr(t,
+		`fuzzy terms need a similarity match`,
+		`name:"colour"~1`,
+		`1:6(5): [==] operation does not accept fuzzy terms, use a similarity match: name:~"colour"~1`)
+	testParseErr(t,
+		`fuzzy terms are at most two edits away`,
+		`name:~"colour"~3`,
+		`1:7(6): fuzzy term "colour"~3 can be at most 2 edits away`)
+	testParseErr(t,
+		`fuzzy terms are single words`,
+		`name:~"red colour"~1`,
+		`1:7(6): fuzzy term "red colour"~1 must be a single word`)
 	// ensure numeric requirements
 	for _, op := range []string{`<`, `<=`, `>`, `>=`, `><`} {
 		query := fmt.Sprintf(`value:%s "hello"`, op)
@@ -306,6 +623,136 @@ func TestOpErrors(t *testing.T) {
 	}
 }
 
+var testFuncs = Funcs(map[string]FuncSignature{
+	"now":   {Returns: ast.TypeTime},
+	"lower": {Args: []ast.ValType{ast.TypeString}, Returns: ast.TypeString},
+	"ago":   {Args: []ast.ValType{ast.TypeInt, ast.TypeString}, Optional: 1, Returns: ast.TypeTime},
+	"any":   {Args: []ast.ValType{ast.TypeString}, Variadic: true, Returns: ast.TypeString},
+	"len":   {Args: []ast.ValType{ast.TypeField}, Returns: ast.TypeInt},
+	"sub":   {Args: []ast.ValType{ast.TypeField, ast.TypeInt, ast.TypeInt}, Optional: 1, Returns: ast.TypeString},
+})
+
+func TestFuncs(t *testing.T) {
+	for _, tt := range []struct {
+		name, query, want string
+		opts              []Option
+	}{
+		{"no arguments", `ts:>now()`, `(> ts now())`, nil},
+		{"whitespace", `ts:> now ( )`, `(> ts now())`, nil},
+		{"arguments", `name:lower("Andy")`, `(== name lower("Andy"))`, nil},
+		{"optional arguments", `ts:><(ago(2, "d"), ago(1))`, `(>< ts [ago(2, "d"), ago(1)])`, nil},
+		{"variadic", `name:any("a", "b", "c")`, `(== name any("a", "b", "c"))`, nil},
+		{"nested calls", `name:any(lower("A"), "b")`, `(== name any(lower("A"), "b"))`, nil},
+		{"in a set", `name:("bob", lower("Andy"))`, `(== name ["bob", lower("Andy")])`, nil},
+		{"applied to a field", `len(name):>3`, `(> len(name) 3)`, nil},
+		{"applied to a field with arguments", `sub( a.b[0] , 1):"x"`, `(== sub(a.b[0], 1) "x")`, nil},
+		{"applied to a field in a set", `sub(name, 0, 2):("an", "bo")`, `(== sub(name, 0, 2) ["an", "bo"])`, nil},
+		{"applied to a field in a range", `len(tags):[1 TO 3}`, `(>< len(tags) [1, 3})`, nil},
+		{"applied to a field with comparable values", `len(tags):(1, 2.5)`, `(== len(tags) [1, 2.5])`, nil},
+		{"applied to a field with a regexp", `sub(name, 0):/^a/`, `(== sub(name, 0) /^a/)`, nil},
+		{"applied to a field with a call argument", `len(tags):>=len_of("x")`, `(>= len(tags) len_of("x"))`, []Option{
+			Funcs(map[string]FuncSignature{"len_of": {Args: []ast.ValType{ast.TypeString}, Returns: ast.TypeInt}}),
+		}},
+		{"names are not keywords", `flag:true_thing()`, `(== flag true_thing())`, []Option{
+			Funcs(map[string]FuncSignature{"true_thing": {Returns: ast.TypeBool}}),
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := ParseQuery(tt.query, append([]Option{testFuncs}, tt.opts...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n.String() != tt.want {
+				t.Errorf("want: %s, got: %s", tt.want, n)
+			}
+		})
+	}
+	call := func(query string) *ast.FuncVal {
+		n, err := ParseQuery(query, testFuncs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n.(*ast.ExprNode).RVals[0].(*ast.FuncVal)
+	}
+	n, err := ParseQuery(`sub(name, 1):"x"`, testFuncs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := n.(*ast.ExprNode); e.Func.Name() != "sub" || ast.FieldString(e.Field) != "name" || len(e.Func.Args()) != 1 {
+		t.Errorf("unexpected field call: %s", e)
+	} else if pos := e.Func.Pos(); pos.Col != 1 || pos.Len != 12 {
+		t.Errorf("unexpected field call position: %+v", pos)
+	} else if e.FriendlyString() != `sub(name, 1): "x"` {
+		t.Errorf("unexpected friendly string: %s", e.FriendlyString())
+	}
+	fv := call(`name: lower("Andy")`)
+	if fv.Name() != "lower" || len(fv.Args()) != 1 || fv.Args()[0].String() != `"Andy"` {
+		t.Errorf("unexpected call: %s", fv)
+	}
+	if pos := fv.Pos(); pos.Col != 7 || pos.Len != 13 {
+		t.Errorf("unexpected call position: %+v", pos)
+	}
+	if pos := fv.Args()[0].Pos(); pos.Col != 13 || pos.Len != 6 {
+		t.Errorf("unexpected argument position: %+v", pos)
+	}
+}
+
+func TestFuncErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, query, wantErr string
+		opts                 []Option
+	}{
+		{"unknown function", `ts:>then()`, `1:5(4): unknown function [then]`, []Option{testFuncs}},
+		{"no functions by default", `ts:>now()`, `1:5(4): unknown function [now]`, nil},
+		{"too many arguments", `ts:>now(1)`, `1:5(4): function [now] requires exactly 0 arguments`, []Option{testFuncs}},
+		{"too few arguments", `ts:>ago()`, `1:5(4): function [ago] requires between 1 and 2 arguments`, []Option{testFuncs}},
+		{"too few variadic arguments", `name:any()`, `1:6(5): function [any] requires at least 1 arguments`, []Option{testFuncs}},
+		{"argument type", `ts:>ago("1")`, `1:9(8): function [ago] argument 1 must be integer, not string`, []Option{testFuncs}},
+		{"variadic argument type", `name:any("a", 1)`, `1:15(14): function [any] argument 2 must be string, not integer`, []Option{testFuncs}},
+		{"nested call type", `name:lower(now())`, `1:12(11): function [lower] argument 1 must be string, not timestamp`, []Option{testFuncs}},
+		{"unknown nested call", `name:lower(upper("a"))`, `1:12(11): unknown function [upper]`, []Option{testFuncs}},
+		{"return type checked by operation", `name:>lower("a")`, `1:7(6): [>] operation needs numeric arguments`, []Option{testFuncs}},
+		{"not applicable to a field", `lower(name):"a"`, `1:1(0): function [lower] cannot be applied to a field`, []Option{testFuncs}},
+		{"field call arguments", `len(name, 1):>3`, `1:1(0): function [len] requires exactly 1 arguments`, []Option{testFuncs}},
+		{"field call argument type", `sub(name, "0"):"a"`, `1:11(10): function [sub] argument 2 must be integer, not string`, []Option{testFuncs}},
+		{"field argument given a value", `name:len("a")`, `1:10(9): function [len] argument 1 must be field, not string`, []Option{testFuncs}},
+		{"field call return type checked by operation", `sub(name, 0):>"a"`, `1:15(14): [>] operation needs numeric arguments`, []Option{testFuncs}},
+		{"field call values checked by operation", `len(name):>"a"`, `1:12(11): function [len] returns integer, which cannot be compared with string`, []Option{testFuncs}},
+		{"field call equality values checked", `len(a):"x"`, `1:8(7): function [len] returns integer, which cannot be compared with string`, []Option{testFuncs}},
+		{"field call set values checked", `len(a):(1, "x")`, `1:12(11): function [len] returns integer, which cannot be compared with string`, []Option{testFuncs}},
+		{"field call compared with call", `len(a):lower("x")`, `1:8(7): function [len] returns integer, which cannot be compared with string`, []Option{testFuncs}},
+		{"unknown field call", `size(name):>3`, `1:1(0): unknown function [size]`, []Option{testFuncs}},
+		{"field after the first argument", `name:"a"`, `0:0(-1): invalid signature for function [at]: only the first argument can be a field`, []Option{
+			Funcs(map[string]FuncSignature{"at": {Args: []ast.ValType{ast.TypeInt, ast.TypeField}, Returns: ast.TypeString}}),
+		}},
+		{"variadic field", `name:"a"`, `0:0(-1): invalid signature for function [all]: only the first argument can be a field`, []Option{
+			Funcs(map[string]FuncSignature{"all": {Args: []ast.ValType{ast.TypeField}, Variadic: true, Returns: ast.TypeInt}}),
+		}},
+		{"variadic without arguments", `name:any()`, `0:0(-1): invalid signature for function [any]: variadic function declared without arguments`, []Option{
+			Funcs(map[string]FuncSignature{"any": {Variadic: true, Returns: ast.TypeString}}),
+		}},
+		{"negative optional arguments", `ts:>now()`, `0:0(-1): invalid signature for function [now]: negative number of optional arguments`, []Option{
+			Funcs(map[string]FuncSignature{"now": {Optional: -1, Returns: ast.TypeTime}}),
+		}},
+		{"too many optional arguments", `ts:>ago(1)`, `0:0(-1): invalid signature for function [ago]: more optional arguments (2) than arguments (1)`, []Option{
+			Funcs(map[string]FuncSignature{"ago": {Args: []ast.ValType{ast.TypeInt}, Optional: 2, Returns: ast.TypeTime}}),
+		}},
+		{"signatures checked without calls", `name:"a"`, `0:0(-1): invalid signature for function [any]: variadic function declared without arguments`, []Option{
+			testFuncs, Funcs(map[string]FuncSignature{"any": {Variadic: true, Returns: ast.TypeString}}),
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseQuery(tt.query, tt.opts...)
+			if err == nil {
+				t.Fatalf("expected error: %s", tt.wantErr)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("\nexpected:\n%s\ngot:\n%s", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func testParseErr(t *testing.T, testName string, query string, wantErr string) {
 	t.Helper()
 	t.Run(testName, func(t *testing.T) {
@@ -0,0 +1,250 @@
+// Package transform rewrites parsed queries into simpler forms that match the
+// same documents.
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flowchartsman/aql/parser"
+	"github.com/flowchartsman/aql/parser/ast"
+)
+
+// Normalize simplifies a query without changing which documents it matches. It
+// returns the rewritten query, which may share nodes with the original, along
+// with a hint for each rewrite made or problem found. The original query is not
+// modified.
+//
+// The following rewrites are made:
+//   - double negations are removed
+//   - negations of AND and OR clauses are pushed down to their operands, using
+//     De Morgan's laws
+//   - duplicate clauses in a chain of AND or OR clauses are removed
+//   - equality comparisons on the same field joined by OR are merged into an
+//     equality set, so a:1 OR a:2 becomes a:(1,2)
+//
+// Numeric conditions on the same field joined by AND which no single value can
+// satisfy, such as a:>5 AND a:<3, are also reported. Since a field matches if
+// any of its values match, these are not removed, as they can still match a
+// field with multiple values, such as an array.
+func Normalize(root ast.Node) (ast.Node, []*parser.ParserMessage) {
+	n := &normalizer{}
+	return n.normalize(root), n.messages
+}
+
+type normalizer struct {
+	messages []*parser.ParserMessage
+}
+
+func (n *normalizer) hint(pos ast.Pos, msg string, v ...any) {
+	n.messages = append(n.messages, &parser.ParserMessage{
+		Position: pos,
+		Msg:      fmt.Sprintf(msg, v...),
+		Type:     parser.MsgHint,
+	})
+}
+
+func (n *normalizer) normalize(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case *ast.NotNode:
+		return n.normalizeNot(node)
+	case *ast.AndNode:
+		return n.normalizeChain(node, false)
+	case *ast.OrNode:
+		return n.normalizeChain(node, true)
+	case *ast.SubdocNode:
+		expr := n.normalize(node.Expr)
+		if expr == node.Expr {
+			return node
+		}
+		return &ast.SubdocNode{
+			Field:    node.Field,
+			Expr:     expr,
+			Position: node.Position,
+		}
+	}
+	return node
+}
+
+func (n *normalizer) normalizeNot(node *ast.NotNode) ast.Node {
+	switch inner := node.Expr.(type) {
+	case *ast.NotNode:
+		n.hint(node.Position, "double negation has no effect")
+		return n.normalize(inner.Expr)
+	case *ast.AndNode, *ast.OrNode:
+		// NOT (a AND b) == NOT a OR NOT b
+		// NOT (a OR b) == NOT a AND NOT b
+		_, isOr := inner.(*ast.OrNode)
+		from, to := "AND", "OR"
+		if isOr {
+			from, to = to, from
+		}
+		n.hint(node.Position, "negated %s clauses are equivalent to negating each clause and joining them with %s", from, to)
+		operands := chainOperands(inner, isOr)
+		negated := make([]ast.Node, len(operands))
+		for i, operand := range operands {
+			negated[i] = &ast.NotNode{
+				Expr:     operand,
+				Position: pos(operand),
+			}
+		}
+		return n.normalize(chain(negated, !isOr, node.Position))
+	}
+	expr := n.normalize(node.Expr)
+	if expr == node.Expr {
+		return node
+	}
+	return &ast.NotNode{
+		Expr:     expr,
+		Position: node.Position,
+	}
+}
+
+func (n *normalizer) normalizeChain(node ast.Node, isOr bool) ast.Node {
+	var operands []ast.Node
+	for _, operand := range chainOperands(node, isOr) {
+		operand = n.normalize(operand)
+		// normalizing may produce a chain of the same kind, such as when a
+		// negation is pushed down, whose operands are already normalized
+		operands = append(operands, chainOperands(operand, isOr)...)
+	}
+	operands = n.removeDuplicates(operands)
+	if isOr {
+		operands = n.mergeEqualitySets(operands)
+	} else {
+		n.checkRanges(operands)
+	}
+	return chain(operands, isOr, pos(node))
+}
+
+func (n *normalizer) removeDuplicates(operands []ast.Node) []ast.Node {
+	seen := map[string]bool{}
+	out := operands[:0:0]
+	for _, operand := range operands {
+		key := operand.String()
+		if seen[key] {
+			n.hint(pos(operand), "duplicate clause has no effect")
+			continue
+		}
+		seen[key] = true
+		out = append(out, operand)
+	}
+	return out
+}
+
+// mergeEqualitySets merges equality comparisons on the same field into a
+// single equality set, in the position of the first.
+func (n *normalizer) mergeEqualitySets(operands []ast.Node) []ast.Node {
+	counts := map[string]int{}
+	for _, operand := range operands {
+		if expr, ok := equality(operand); ok {
+			counts[expr.Subject()]++
+		}
+	}
+	var out []ast.Node
+	merged := map[string]*ast.ExprNode{}
+	for _, operand := range operands {
+		expr, ok := equality(operand)
+		if !ok || counts[expr.Subject()] < 2 {
+			out = append(out, operand)
+			continue
+		}
+		field := expr.Subject()
+		if set, ok := merged[field]; ok {
+			set.RVals = appendNewValues(set.RVals, expr.RVals)
+			continue
+		}
+		merged[field] = &ast.ExprNode{
+			Op:       ast.EQ,
+			Field:    expr.Field,
+			Func:     expr.Func,
+			RVals:    expr.RVals,
+			Position: expr.Position,
+		}
+		out = append(out, merged[field])
+	}
+	for _, operand := range out {
+		expr, ok := equality(operand)
+		if !ok || merged[expr.Subject()] != expr {
+			continue
+		}
+		field := expr.Subject()
+		n.hint(expr.Position, "%d comparisons on %s joined by OR can be combined into a single equality set: %s:(%s)",
+			counts[field], field, field, joinValues(expr.RVals))
+	}
+	return out
+}
+
+func equality(node ast.Node) (*ast.ExprNode, bool) {
+	expr, ok := node.(*ast.ExprNode)
+	if !ok || expr.Op != ast.EQ {
+		return nil, false
+	}
+	return expr, true
+}
+
+// appendNewValues appends the values in add that are not already in values,
+// without modifying values.
+func appendNewValues(values []ast.Val, add []ast.Val) []ast.Val {
+	out := append([]ast.Val(nil), values...)
+	seen := map[string]bool{}
+	for _, v := range values {
+		seen[v.String()] = true
+	}
+	for _, v := range add {
+		if !seen[v.String()] {
+			seen[v.String()] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func joinValues(values []ast.Val) string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = v.String()
+	}
+	return strings.Join(strs, ", ")
+}
+
+// chainOperands returns the operands of a chain of AND or OR nodes, flattening
+// (a AND (b AND c)) into [a, b, c]. Any other node is its own single operand.
+func chainOperands(node ast.Node, isOr bool) []ast.Node {
+	switch node := node.(type) {
+	case *ast.AndNode:
+		if !isOr {
+			return append(chainOperands(node.Left, isOr), chainOperands(node.Right, isOr)...)
+		}
+	case *ast.OrNode:
+		if isOr {
+			return append(chainOperands(node.Left, isOr), chainOperands(node.Right, isOr)...)
+		}
+	}
+	return []ast.Node{node}
+}
+
+// chain joins operands with AND or OR nodes, nested to the right as the parser
+// does. The outermost node takes position p, and the rest take the position of
+// their first operand.
+func chain(operands []ast.Node, isOr bool, p ast.Pos) ast.Node {
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	right := chain(operands[1:], isOr, pos(operands[1]))
+	if isOr {
+		return &ast.OrNode{Left: operands[0], Right: right, Position: p}
+	}
+	return &ast.AndNode{Left: operands[0], Right: right, Position: p}
+}
+
+type positioned interface {
+	Pos() ast.Pos
+}
+
+func pos(node ast.Node) ast.Pos {
+	if p, ok := node.(positioned); ok {
+		return p.Pos()
+	}
+	return ast.NoPosition()
+}
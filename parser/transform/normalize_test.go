@@ -0,0 +1,177 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flowchartsman/aql/parser"
+	"github.com/flowchartsman/aql/parser/ast"
+)
+
+type hint struct {
+	Offset int
+	Msg    string
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+		hints []hint
+	}{
+		{
+			query: `a:1 AND b:2`,
+			want:  `(&& (== a 1) (== b 2))`,
+		},
+		{
+			query: `NOT (NOT a:1)`,
+			want:  `(== a 1)`,
+			hints: []hint{{0, "double negation has no effect"}},
+		},
+		{
+			query: `x:1 AND NOT (a:1 AND b:2)`,
+			want:  `(&& (== x 1) (|| (! (== a 1)) (! (== b 2))))`,
+			hints: []hint{{8, "negated AND clauses are equivalent to negating each clause and joining them with OR"}},
+		},
+		{
+			query: `NOT (a:1 OR NOT b:2 OR c:3)`,
+			want:  `(&& (! (== a 1)) (&& (== b 2) (! (== c 3))))`,
+			hints: []hint{
+				{0, "negated OR clauses are equivalent to negating each clause and joining them with AND"},
+				{12, "double negation has no effect"},
+			},
+		},
+		{
+			// the pushed-down negation joins the enclosing chain
+			query: `x:1 OR NOT (a:1 AND b:2)`,
+			want:  `(|| (== x 1) (|| (! (== a 1)) (! (== b 2))))`,
+			hints: []hint{{7, "negated AND clauses are equivalent to negating each clause and joining them with OR"}},
+		},
+		{
+			query: `(a:1 OR a:1)`,
+			want:  `(== a 1)`,
+			hints: []hint{{8, "duplicate clause has no effect"}},
+		},
+		{
+			query: `a:"x" AND b:2 AND a:"x"`,
+			want:  `(&& (== a "x") (== b 2))`,
+			hints: []hint{{18, "duplicate clause has no effect"}},
+		},
+		{
+			query: `a:1 OR b:2 OR a:3`,
+			want:  `(|| (== a [1, 3]) (== b 2))`,
+			hints: []hint{{0, "2 comparisons on a joined by OR can be combined into a single equality set: a:(1, 3)"}},
+		},
+		{
+			query: `a:(1,2) OR a:2 OR a.b:"x" OR a:"x"`,
+			want:  `(|| (== a [1, 2, "x"]) (== a.b "x"))`,
+			hints: []hint{{0, `3 comparisons on a joined by OR can be combined into a single equality set: a:(1, 2, "x")`}},
+		},
+		{
+			// other operations are not merged
+			query: `a:1 OR a:>5`,
+			want:  `(|| (== a 1) (> a 5))`,
+		},
+		{
+			query: `a:1 AND a:2`,
+			want:  `(&& (== a 1) (== a 2))`,
+			hints: []hint{{8, "no single value of a can satisfy this and the preceding conditions, so they can only match if a has multiple values, such as an array"}},
+		},
+		{
+			query: `a:>5 AND b:1 AND a:<=5`,
+			want:  `(&& (> a 5) (&& (== b 1) (<= a 5)))`,
+			hints: []hint{{17, "no single value of a can satisfy this and the preceding conditions, so they can only match if a has multiple values, such as an array"}},
+		},
+		{
+			query: `a:><(1,5) AND a:>=5 AND a:(5,6)`,
+			want:  `(&& (>< a [1, 5]) (&& (>= a 5) (== a [5, 6])))`,
+		},
+		{
+			query: `a:[1 TO 5} AND a:[5 TO *]`,
+			want:  `(&& (>< a [1, 5}) (>< a [5, *]))`,
+			hints: []hint{{15, "no single value of a can satisfy this and the preceding conditions, so they can only match if a has multiple values, such as an array"}},
+		},
+		{
+			query: `a:[1 TO 5] AND a:[5 TO *]`,
+			want:  `(&& (>< a [1, 5]) (>< a [5, *]))`,
+		},
+		{
+			query: `a:[* TO 1} AND a:{1 TO 5]`,
+			want:  `(&& (>< a [*, 1}) (>< a {1, 5]))`,
+			hints: []hint{{15, "no single value of a can satisfy this and the preceding conditions, so they can only match if a has multiple values, such as an array"}},
+		},
+		{
+			// different bounds are different clauses
+			query: `a:[1 TO 5] AND a:{1 TO 5}`,
+			want:  `(&& (>< a [1, 5]) (>< a {1, 5}))`,
+		},
+		{
+			// conditions on other fields or of other types are independent
+			query: `a:>5 AND b:<3 AND a:"x"`,
+			want:  `(&& (> a 5) (&& (< b 3) (== a "x")))`,
+		},
+		{
+			// a function applied to a field is compared separately from it
+			query: `len(a):1 OR a:2 OR len(a):3`,
+			want:  `(|| (== len(a) [1, 3]) (== a 2))`,
+			hints: []hint{{0, "2 comparisons on len(a) joined by OR can be combined into a single equality set: len(a):(1, 3)"}},
+		},
+		{
+			query: `len(a):>5 AND a:<3 AND len(a):<3`,
+			want:  `(&& (> len(a) 5) (&& (< a 3) (< len(a) 3)))`,
+			hints: []hint{{23, "no single value of len(a) can satisfy this and the preceding conditions, so they can only match if len(a) has multiple values, such as an array"}},
+		},
+		{
+			query: `d{!(!a:1) AND a:1}`,
+			want:  `(d{(== a 1)})`,
+			hints: []hint{
+				{2, "double negation has no effect"},
+				{14, "duplicate clause has no effect"},
+			},
+		},
+	}
+	funcs := parser.Funcs(map[string]parser.FuncSignature{
+		"len": {Args: []ast.ValType{ast.TypeField}, Returns: ast.TypeInt},
+	})
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			root, err := parser.ParseQuery(tt.query, funcs)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			original := root.String()
+			got, messages := Normalize(root)
+			if got.String() != tt.want {
+				t.Errorf("\nexpected:\n%s\ngot:\n%s", tt.want, got.String())
+			}
+			if root.String() != original {
+				t.Errorf("original query was modified: %s", root.String())
+			}
+			var hints []hint
+			for _, m := range messages {
+				if m.Type != parser.MsgHint {
+					t.Errorf("expected hint, got %s", m)
+				}
+				hints = append(hints, hint{m.Position.Offset, m.Msg})
+			}
+			if !reflect.DeepEqual(hints, tt.hints) {
+				t.Errorf("\nexpected hints:\n%q\ngot:\n%q", tt.hints, hints)
+			}
+		})
+	}
+}
+
+func TestNormalizeUnchanged(t *testing.T) {
+	root, err := parser.ParseQuery(`a:1 AND (b:2 OR NOT c{d:3})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, messages := Normalize(root)
+	if len(messages) != 0 {
+		t.Errorf("unexpected messages: %v", messages)
+	}
+	// unchanged clauses keep their original nodes
+	if got.(*ast.AndNode).Left != root.(*ast.AndNode).Left {
+		t.Errorf("expected unchanged clause to be reused")
+	}
+}
@@ -0,0 +1,122 @@
+package transform
+
+import (
+	"math"
+
+	"github.com/flowchartsman/aql/parser/ast"
+)
+
+// interval is a range of numbers, which may be open or closed at either end.
+type interval struct {
+	lo, hi         float64
+	loOpen, hiOpen bool
+}
+
+func (i interval) intersect(o interval) (interval, bool) {
+	out := i
+	if o.lo > out.lo || (o.lo == out.lo && o.loOpen) {
+		out.lo, out.loOpen = o.lo, o.loOpen
+	}
+	if o.hi < out.hi || (o.hi == out.hi && o.hiOpen) {
+		out.hi, out.hiOpen = o.hi, o.hiOpen
+	}
+	if out.lo > out.hi || (out.lo == out.hi && (out.loOpen || out.hiOpen)) {
+		return interval{}, false
+	}
+	return out, true
+}
+
+// rangeSet is the set of numbers a condition accepts, as a union of intervals.
+type rangeSet []interval
+
+func (r rangeSet) intersect(o rangeSet) rangeSet {
+	var out rangeSet
+	for _, a := range r {
+		for _, b := range o {
+			if i, ok := a.intersect(b); ok {
+				out = append(out, i)
+			}
+		}
+	}
+	return out
+}
+
+// numericRange returns the set of numbers a numeric comparison accepts, or
+// false if it is not a numeric comparison.
+func numericRange(expr *ast.ExprNode) (rangeSet, bool) {
+	values := make([]float64, len(expr.RVals))
+	for i, rv := range expr.RVals {
+		switch v := rv.(type) {
+		case *ast.IntVal:
+			values[i] = float64(v.Value())
+		case *ast.FloatVal:
+			values[i] = v.Value()
+		default:
+			return nil, false
+		}
+	}
+	inf := math.Inf(1)
+	switch expr.Op {
+	case ast.EQ:
+		out := make(rangeSet, len(values))
+		for i, v := range values {
+			out[i] = interval{lo: v, hi: v}
+		}
+		return out, true
+	case ast.LT:
+		return rangeSet{{lo: -inf, hi: values[0], hiOpen: true}}, true
+	case ast.LTE:
+		return rangeSet{{lo: -inf, hi: values[0]}}, true
+	case ast.GT:
+		return rangeSet{{lo: values[0], hi: inf, loOpen: true}}, true
+	case ast.GTE:
+		return rangeSet{{lo: values[0], hi: inf}}, true
+	case ast.BET:
+		i := interval{
+			lo:     -inf,
+			hi:     inf,
+			loOpen: expr.Bounds[0] == ast.BoundExclusive,
+			hiOpen: expr.Bounds[1] == ast.BoundExclusive,
+		}
+		if expr.Bounds[0] != ast.BoundUnbounded {
+			i.lo, values = values[0], values[1:]
+		}
+		if expr.Bounds[1] != ast.BoundUnbounded {
+			i.hi = values[0]
+		}
+		return rangeSet{i}, true
+	}
+	return nil, false
+}
+
+// checkRanges reports numeric comparisons on the same field joined by AND
+// which no single value can satisfy.
+func (n *normalizer) checkRanges(operands []ast.Node) {
+	ranges := map[string]rangeSet{}
+	// only report the first conflict for each field
+	conflicted := map[string]bool{}
+	for _, operand := range operands {
+		expr, ok := operand.(*ast.ExprNode)
+		if !ok {
+			continue
+		}
+		r, ok := numericRange(expr)
+		if !ok {
+			continue
+		}
+		field := expr.Subject()
+		if conflicted[field] {
+			continue
+		}
+		existing, ok := ranges[field]
+		if !ok {
+			ranges[field] = r
+			continue
+		}
+		ranges[field] = existing.intersect(r)
+		if len(ranges[field]) == 0 {
+			conflicted[field] = true
+			n.hint(expr.Position, "no single value of %s can satisfy this and the preceding conditions, so they can only match if %s has multiple values, such as an array", field, field)
+		}
+	}
+}
@@ -2,20 +2,32 @@ package parser
 
 import (
 	"fmt"
+	"unicode"
 
 	"github.com/flowchartsman/aql/parser/ast"
+	"golang.org/x/text/language"
 )
 
 type exprCheck func(expr *ast.ExprNode) *ParseError
 
-func opValidator(node ast.Node) error {
+// opValidator checks that each operation is given the right number and types
+// of values, and that function calls match the declared functions.
+type opValidator struct {
+	funcs map[string]FuncSignature
+}
+
+func (v *opValidator) Visit(node ast.Node) error {
 	switch n := node.(type) {
 	case *ast.ExprNode:
 		for _, check := range []exprCheck{
-			checkValues,
+			v.checkFuncs,
+			v.checkValues,
 			checkArity,
 			checkRVals,
 			checkBetween,
+			checkCase,
+			checkParams,
+			checkFuzzy,
 		} {
 			if err := check(n); err != nil {
 				return err
@@ -25,21 +37,133 @@ func opValidator(node ast.Node) error {
 	return nil
 }
 
-func checkValues(e *ast.ExprNode) *ParseError {
+// valueType returns the type of a value, or the type a function call
+// returns.
+func (v *opValidator) valueType(val ast.Val) ast.ValType {
+	if fv, ok := val.(*ast.FuncVal); ok {
+		return v.funcs[fv.Name()].Returns
+	}
+	return val.Type()
+}
+
+func (v *opValidator) checkFuncs(e *ast.ExprNode) *ParseError {
+	if e.Func != nil {
+		if err := v.checkCall(e.Func, true); err != nil {
+			return err
+		}
+	}
+	for _, rv := range e.RVals {
+		if fv, ok := rv.(*ast.FuncVal); ok {
+			if err := v.checkCall(fv, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkCall checks a call against the function's signature. If onField is
+// set, the function is applied to a field, which is its first argument.
+func (v *opValidator) checkCall(fv *ast.FuncVal, onField bool) *ParseError {
+	sig, ok := v.funcs[fv.Name()]
+	if !ok {
+		return ErrorWith(fv, fmt.Sprintf("unknown function [%s]", fv.Name()))
+	}
+	args := fv.Args()
+	numArgs := len(args)
+	if onField {
+		if len(sig.Args) == 0 || sig.Args[0] != ast.TypeField {
+			return ErrorWith(fv, fmt.Sprintf("function [%s] cannot be applied to a field", fv.Name()))
+		}
+		numArgs++
+	}
+	min, max := len(sig.Args)-sig.Optional, len(sig.Args)
+	var msg string
+	switch {
+	case sig.Variadic && numArgs < min:
+		msg = fmt.Sprintf("requires at least %d arguments", min)
+	case sig.Variadic:
+	case min == max && numArgs != min:
+		msg = fmt.Sprintf("requires exactly %d arguments", min)
+	case numArgs < min || numArgs > max:
+		msg = fmt.Sprintf("requires between %d and %d arguments", min, max)
+	}
+	if msg != "" {
+		return ErrorWith(fv, fmt.Sprintf("function [%s] %s", fv.Name(), msg))
+	}
+	for i, arg := range args {
+		if afv, ok := arg.(*ast.FuncVal); ok {
+			if err := v.checkCall(afv, false); err != nil {
+				return err
+			}
+		}
+		// the position of the argument in the signature
+		argIdx := i
+		if onField {
+			argIdx++
+		}
+		var want ast.ValType
+		switch {
+		case argIdx < len(sig.Args):
+			want = sig.Args[argIdx]
+		case len(sig.Args) > 0:
+			// variadic
+			want = sig.Args[len(sig.Args)-1]
+		default:
+			// backstop, rejected by checkSignatures
+			panic(fmt.Sprintf("variadic function [%s] declared without arguments", fv.Name()))
+		}
+		if got := v.valueType(arg); got != want {
+			return ErrorWith(arg, fmt.Sprintf("function [%s] argument %d must be %s, not %s", fv.Name(), argIdx+1, want, got))
+		}
+	}
+	return nil
+}
+
+// comparableTypes are the types of value that a function result of each type
+// can be compared with. Results of other types are not checked.
+var comparableTypes = map[ast.ValType][]ast.ValType{
+	ast.TypeInt:    {ast.TypeInt, ast.TypeFloat},
+	ast.TypeFloat:  {ast.TypeInt, ast.TypeFloat},
+	ast.TypeString: {ast.TypeString, ast.TypeRegex},
+	ast.TypeBool:   {ast.TypeBool},
+	ast.TypeTime:   {ast.TypeTime},
+	ast.TypeNet:    {ast.TypeNet},
+}
+
+func (v *opValidator) checkValues(e *ast.ExprNode) *ParseError {
+	// the values compared with a function applied to the field must suit the
+	// type it returns, whatever the operation
+	if e.Func != nil {
+		returns := v.funcs[e.Func.Name()].Returns
+		if comparable, ok := comparableTypes[returns]; ok {
+			if badIdx := v.mustBeOneOf(e.RVals, comparable...); badIdx >= 0 {
+				rv := e.RVals[badIdx]
+				return ErrorWith(rv, fmt.Sprintf("function [%s] returns %s, which cannot be compared with %s", e.Func.Name(), returns, v.valueType(rv)))
+			}
+		}
+	}
 	var failMsg string
-	var badIdx int
+	var allowed []ast.ValType
 	switch e.Op {
 	case ast.LT, ast.LTE, ast.GT, ast.GTE, ast.BET:
-		failMsg, badIdx = "needs numeric arguments", mustBeOneOf(e.RVals, ast.TypeInt, ast.TypeFloat, ast.TypeTime)
+		failMsg, allowed = "needs numeric arguments", []ast.ValType{ast.TypeInt, ast.TypeFloat, ast.TypeTime}
 	case ast.SIM:
 		// Temporarily accept regexp as well for legacy reasons. TODO: remove
-		failMsg, badIdx = "needs string, or boolean arguments", mustBeOneOf(e.RVals, ast.TypeString, ast.TypeRegex, ast.TypeBool)
+		failMsg, allowed = "needs string, or boolean arguments", []ast.ValType{ast.TypeString, ast.TypeRegex, ast.TypeBool}
+	case ast.EXA, ast.EXI:
+		failMsg, allowed = "needs string arguments", []ast.ValType{ast.TypeString}
 	default:
 		return nil
 	}
-	if badIdx >= 0 {
+	if badIdx := v.mustBeOneOf(e.RVals, allowed...); badIdx >= 0 {
 		return ErrorWith(e.RVals[badIdx], fmt.Sprintf("[%s] operation %s", e.Op, failMsg))
 	}
+	// a function applied to the field must return a value the operation can
+	// compare
+	if e.Func != nil && v.mustBeOneOf([]ast.Val{e.Func}, allowed...) >= 0 {
+		return ErrorWith(e.Func, fmt.Sprintf("[%s] operation %s", e.Op, failMsg))
+	}
 	return nil
 }
 
@@ -60,10 +184,17 @@ func checkArity(e *ast.ExprNode) *ParseError {
 
 	// ternary
 	case ast.BET:
-		min, max = 2, 2
+		// less any unbounded ends of a range, which have no value
+		min = 2
+		for _, b := range e.Bounds {
+			if b == ast.BoundUnbounded {
+				min--
+			}
+		}
+		max = min
 
 	// n-ary
-	case ast.EQ, ast.SIM:
+	case ast.EQ, ast.SIM, ast.EXA, ast.EXI:
 		min, max = 1, inf
 
 	// backstop
@@ -127,52 +258,152 @@ func checkRVals(e *ast.ExprNode) *ParseError {
 	return nil
 }
 
+// exact matches have their own operators for case sensitivity, and
+// similarity matches have a strength
+func checkCase(e *ast.ExprNode) *ParseError {
+	if e.Op != ast.EXA && e.Op != ast.EXI && e.Op != ast.SIM {
+		return nil
+	}
+	for _, rv := range e.RVals {
+		if sv, ok := rv.(*ast.StringVal); ok && sv.Case() != ast.CaseDefault {
+			return ErrorAt(rv.Pos(), fmt.Sprintf("[%s] operation does not accept case modifiers", e.Op))
+		}
+	}
+	return nil
+}
+
+// only similarity matches accept parameters: a language tag and a collation
+// strength, in any order
+func checkParams(e *ast.ExprNode) *ParseError {
+	if len(e.Params) == 0 {
+		return nil
+	}
+	if e.Op != ast.SIM {
+		return ErrorAt(e.ParamPos[0], fmt.Sprintf("[%s] operation does not accept parameters", e.Op))
+	}
+	var lang, strength string
+	for i, p := range e.Params {
+		switch p {
+		case ast.StrengthLoose, ast.StrengthIgnoreDiacritics, ast.StrengthIgnoreCase:
+			if strength != "" {
+				return ErrorAt(e.ParamPos[i], fmt.Sprintf("[%s] operation accepts one strength, found [%s] and [%s]", e.Op, strength, p))
+			}
+			strength = p
+		default:
+			if _, err := language.Parse(p); err != nil {
+				return ErrorAt(e.ParamPos[i], fmt.Sprintf("[%s] operation parameter [%s] is not a strength (%s, %s or %s) or a known language tag", e.Op, p, ast.StrengthLoose, ast.StrengthIgnoreDiacritics, ast.StrengthIgnoreCase))
+			}
+			if lang != "" {
+				return ErrorAt(e.ParamPos[i], fmt.Sprintf("[%s] operation accepts one language, found [%s] and [%s]", e.Op, lang, p))
+			}
+			lang = p
+		}
+	}
+	return nil
+}
+
+// fuzzy terms are single words matched by a similarity match, and, as in
+// Lucene, can be at most two edits away
+func checkFuzzy(e *ast.ExprNode) *ParseError {
+	for _, rv := range e.RVals {
+		sv, ok := rv.(*ast.StringVal)
+		if !ok {
+			continue
+		}
+		maxEdits, fuzzy := sv.Fuzziness()
+		if !fuzzy {
+			continue
+		}
+		switch {
+		case e.Op != ast.SIM:
+			return ErrorAt(rv.Pos(), fmt.Sprintf("[%s] operation does not accept fuzzy terms, use a similarity match: %s:~%s", e.Op, e.Subject(), sv))
+		case maxEdits > maxFuzzyEdits:
+			return ErrorAt(rv.Pos(), fmt.Sprintf("fuzzy term %s can be at most %d edits away", sv, maxFuzzyEdits))
+		case !isWord(sv.Value()):
+			return ErrorAt(rv.Pos(), fmt.Sprintf("fuzzy term %s must be a single word", sv))
+		}
+	}
+	return nil
+}
+
+const maxFuzzyEdits = 2
+
+// isWord returns whether s consists only of word characters: letters, marks,
+// digits and underscores.
+func isWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
 func checkBetween(e *ast.ExprNode) *ParseError {
 	if e.Op != ast.BET {
 		return nil
 	}
-	if e.RVals[0].Type() == ast.TypeTime {
-		if e.RVals[1].Type() != ast.TypeTime {
-			return ErrorAt(e.RVals[1].Pos(), "second argument must also be a datetime value")
+	lo, hi := e.RangeValues()
+	if lo == nil || hi == nil {
+		// only one end of the range is bounded, so there is nothing to compare
+		return nil
+	}
+	for _, rv := range e.RVals {
+		if _, ok := rv.(*ast.FuncVal); ok {
+			// the values of calls aren't known until they are evaluated
+			return nil
+		}
+	}
+	if lo.Type() == ast.TypeTime {
+		if hi.Type() != ast.TypeTime {
+			return ErrorAt(hi.Pos(), "second argument must also be a datetime value")
+		}
+		lt, rt := lo.(*ast.TimeVal), hi.(*ast.TimeVal)
+		if lt.Relative() || rt.Relative() {
+			// the order of relative values can depend on the current time, as
+			// with (now/d, now-1h), so an empty range is allowed
+			return nil
 		}
-		lt, rt := e.RVals[0].(*ast.TimeVal), e.RVals[1].(*ast.TimeVal)
 		if lt.Value().After(rt.Value()) || lt.Value().Equal(rt.Value()) {
-			return ErrorAt(e.RVals[1].Pos(), "[><] operation requires the second argument be greater")
+			return ErrorAt(hi.Pos(), "[><] operation requires the second argument be greater")
 		}
 		return nil
 	}
 
 	var l, r float64
-	switch lnv := e.RVals[0].(type) {
+	switch lnv := lo.(type) {
 	case *ast.IntVal:
 		l = float64(lnv.Value())
 	case *ast.FloatVal:
 		l = lnv.Value()
 	}
-	switch rnv := e.RVals[1].(type) {
+	switch rnv := hi.(type) {
 	case *ast.IntVal:
 		r = float64(rnv.Value())
 	case *ast.FloatVal:
 		r = rnv.Value()
 	}
 	if r <= l {
-		return ErrorAt(e.RVals[1].Pos(), "[><] operation requires the second argument be greater")
+		return ErrorAt(hi.Pos(), "[><] operation requires the second argument be greater")
 	}
 	return nil
 }
 
-func mustBeOneOf(values []ast.Val, types ...ast.ValType) (badIdx int) {
+func (v *opValidator) mustBeOneOf(values []ast.Val, types ...ast.ValType) (badIdx int) {
 	if len(types) == 0 {
 		panic("invalid type check")
 	}
 VLOOP:
-	for v := range values {
+	for i := range values {
 		for t := range types {
-			if values[v].Type() == types[t] {
+			if v.valueType(values[i]) == types[t] {
 				continue VLOOP
 			}
 		}
-		return v
+		return i
 	}
 	return -1
 }
@@ -1,3 +1,5 @@
+//go:build js && wasm
+
 package main
 
 import (
@@ -23,7 +25,7 @@ func warningVisitor(node ast.Node, tape *parser.MessageTape) error {
 				}
 				reStr := strings.Trim(v.String(), `/`)
 				if strings.HasPrefix(reStr, "(?i)") && !isASCII(v.String()) {
-					tape.WarningAt(rv.Pos(), `Case-insensitive regular expression %s contains unicode characters. This may not work as intended. Consider a fuzzy match - 'field:~("value", "language")`, v.String())
+					tape.WarningAt(rv.Pos(), `Case-insensitive regular expression %s contains unicode characters. This may not work as intended. Consider a similarity match - 'field:~[language]"value"'`, v.String())
 				}
 				reStr = strings.TrimPrefix(reStr, "(?i)")
 				if strings.HasPrefix(reStr, ".*") || strings.HasSuffix(reStr, ".*") {
@@ -38,7 +40,7 @@ func warningVisitor(node ast.Node, tape *parser.MessageTape) error {
 					reg = reg.Sub[0]
 				}
 				if reg.Op == syntax.OpAlternate && !doesSomethingSpecial(reg) {
-					tape.HintAt(rv.Pos(), `if you are doing large string alternations, consider using a multi-string match: such as '%s:("one", "two")'`, strings.Join(n.Field, "."))
+					tape.HintAt(rv.Pos(), `if you are doing large string alternations, consider using a multi-string match: such as '%s:("one", "two")'`, n.Subject())
 				}
 				if len(reg.Sub) >= 2 {
 					if isDotStar(reg.Sub[0]) {